@@ -16,6 +16,10 @@
 package devfile
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+
 	"github.com/devfile/api/v2/pkg/validation/variables"
 	"github.com/devfile/library/v2/pkg/devfile/parser"
 	"github.com/devfile/library/v2/pkg/devfile/validate"
@@ -88,6 +92,7 @@ func ParseAndValidate(path string) (d parser.DevfileObj, err error) {
 // ParseDevfileAndValidate func parses the devfile data, validates the devfile integrity with the schema
 // replaces the top-level variable keys if present and validates the devfile data.
 // It returns devfile context and runtime objects, variable substitution warning if any and an error.
+// Concurrent calls are safe, provided they do not share the mutable fields of their args, e.g. ExternalVariables.
 func ParseDevfileAndValidate(args parser.ParserArgs) (d parser.DevfileObj, varWarning variables.VariableWarning, err error) {
 	d, err = parser.ParseDevfile(args)
 	if err != nil {
@@ -124,5 +129,67 @@ func ParseDevfileAndValidate(args parser.ParserArgs) (d parser.DevfileObj, varWa
 		return d, varWarning, err
 	}
 
+	if args.StrictWarnings {
+		err = warningsAsError(d, varWarning, args.WarnNonPersistentPaths)
+	}
+
 	return d, varWarning, err
 }
+
+// warningsAsError returns an error listing the warnings of the parsed devfile, see collectWarnings.
+// It returns nil if there are no warnings.
+func warningsAsError(d parser.DevfileObj, varWarning variables.VariableWarning, warnNonPersistentPaths bool) error {
+	warnings, err := collectWarnings(d, varWarning, warnNonPersistentPaths)
+	if err != nil {
+		return err
+	}
+	if len(warnings) == 0 {
+		return nil
+	}
+	return fmt.Errorf("devfile has warnings, which are treated as errors:\n- %s", strings.Join(warnings, "\n- "))
+}
+
+// collectWarnings returns the warnings of the parsed devfile: the references to undefined variables,
+// the skipped schema validation, the ignored unknown fields, the relative uris that cannot be resolved and, if warnNonPersistentPaths is set,
+// the paths of the containers that are not persisted
+func collectWarnings(d parser.DevfileObj, varWarning variables.VariableWarning, warnNonPersistentPaths bool) ([]string, error) {
+	var warnings []string
+	for _, elements := range []struct {
+		kind           string
+		invalidVarRefs map[string][]string
+	}{
+		{"command", varWarning.Commands},
+		{"component", varWarning.Components},
+		{"project", varWarning.Projects},
+		{"starter project", varWarning.StarterProjects},
+	} {
+		var names []string
+		for name := range elements.invalidVarRefs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			warnings = append(warnings, fmt.Sprintf("%s %s references undefined variables: %s", elements.kind, name, strings.Join(elements.invalidVarRefs[name], ", ")))
+		}
+	}
+
+	if schemaWarning := d.Ctx.GetSchemaWarning(); schemaWarning != "" {
+		warnings = append(warnings, schemaWarning)
+	}
+	warnings = append(warnings, d.Ctx.GetUnknownFieldWarnings()...)
+
+	uriWarnings, err := validate.ValidateRelativeURIs(d)
+	if err != nil {
+		return nil, err
+	}
+	warnings = append(warnings, uriWarnings...)
+
+	if warnNonPersistentPaths {
+		pathWarnings, err := validate.ValidatePersistentPaths(d.Data)
+		if err != nil {
+			return nil, err
+		}
+		warnings = append(warnings, pathWarnings...)
+	}
+	return warnings, nil
+}
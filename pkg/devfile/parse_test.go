@@ -16,11 +16,15 @@
 package devfile
 
 import (
+	"fmt"
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
 
 	v1 "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
@@ -416,3 +420,442 @@ schemaVersion: 2.2.0
 		})
 	}
 }
+
+func TestParseDevfileAndValidate_ResolvedVariables(t *testing.T) {
+	devfileContent := `schemaVersion: 2.2.0
+metadata:
+  name: resolved-variables
+variables:
+  name: my-app
+  tag: latest
+components:
+- name: runtime
+  container:
+    image: "{{name}}:{{tag}}"
+`
+
+	tests := []struct {
+		name              string
+		externalVariables map[string]string
+		wantVariables     map[string]string
+		wantImage         string
+	}{
+		{
+			name: "should resolve the devfile defined variables",
+			wantVariables: map[string]string{
+				"name": "my-app",
+				"tag":  "latest",
+			},
+			wantImage: "my-app:latest",
+		},
+		{
+			name: "should resolve the overridden and defaulted variables",
+			externalVariables: map[string]string{
+				"tag": "v1.0.0",
+			},
+			wantVariables: map[string]string{
+				"name": "my-app",
+				"tag":  "v1.0.0",
+			},
+			wantImage: "my-app:v1.0.0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotD, _, err := ParseDevfileAndValidate(parser.ParserArgs{
+				Data:              []byte(devfileContent),
+				ExternalVariables: tt.externalVariables,
+			})
+			if err != nil {
+				t.Fatalf("ParseDevfileAndValidate() error = %v, wantErr nil", err)
+			}
+
+			gotVariables := gotD.GetResolvedVariables()
+			if !reflect.DeepEqual(gotVariables, tt.wantVariables) {
+				t.Errorf("resolved variables are %+v, expected %+v", gotVariables, tt.wantVariables)
+			}
+
+			components, err := gotD.Data.GetComponents(common.DevfileOptions{})
+			if err != nil {
+				t.Fatalf("unexpected error getting components: %v", err)
+			}
+			if components[0].Container.Image != tt.wantImage {
+				t.Errorf("image is %q, should be %q", components[0].Container.Image, tt.wantImage)
+			}
+
+			// the resolved variables are a copy of the devfile variables
+			gotVariables["name"] = "modified"
+			if gotD.GetResolvedVariables()["name"] != tt.wantVariables["name"] {
+				t.Errorf("modifying the resolved variables should not modify the devfile variables")
+			}
+		})
+	}
+}
+
+func TestParseDevfileAndValidate_ExternalVariables(t *testing.T) {
+	devfileContent := `schemaVersion: 2.2.0
+metadata:
+  name: external-variables
+variables:
+  tag: latest
+components:
+- name: runtime
+  container:
+    image: "my-app:{{tag}}"
+    env:
+    - name: MODE
+      value: "{{mode}}"
+`
+
+	emptyWarning := variables.VariableWarning{
+		Commands:        map[string][]string{},
+		Components:      map[string][]string{},
+		Projects:        map[string][]string{},
+		StarterProjects: map[string][]string{},
+	}
+
+	tests := []struct {
+		name              string
+		externalVariables map[string]string
+		wantImage         string
+		wantMode          string
+		wantVarWarning    variables.VariableWarning
+	}{
+		{
+			name:      "external variables should take precedence over devfile defined variables",
+			wantImage: "my-app:v2",
+			wantMode:  "debug",
+			externalVariables: map[string]string{
+				"tag":  "v2",
+				"mode": "debug",
+			},
+			wantVarWarning: emptyWarning,
+		},
+		{
+			name: "unused external variables should be ignored",
+			externalVariables: map[string]string{
+				"mode":   "run",
+				"unused": "value",
+			},
+			wantImage:      "my-app:latest",
+			wantMode:       "run",
+			wantVarWarning: emptyWarning,
+		},
+		{
+			name:      "variables neither defined nor external should not be substituted",
+			wantImage: "my-app:latest",
+			wantMode:  "{{mode}}",
+			wantVarWarning: variables.VariableWarning{
+				Commands:        map[string][]string{},
+				Components:      map[string][]string{"runtime": {"mode"}},
+				Projects:        map[string][]string{},
+				StarterProjects: map[string][]string{},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotD, gotVarWarning, err := ParseDevfileAndValidate(parser.ParserArgs{
+				Data:              []byte(devfileContent),
+				ExternalVariables: tt.externalVariables,
+			})
+			if err != nil {
+				t.Fatalf("ParseDevfileAndValidate() error = %v, wantErr nil", err)
+			}
+
+			components, err := gotD.Data.GetComponents(common.DevfileOptions{})
+			if err != nil {
+				t.Fatalf("unexpected error getting components: %v", err)
+			}
+			container := components[0].Container
+			if container.Image != tt.wantImage {
+				t.Errorf("image is %q, should be %q", container.Image, tt.wantImage)
+			}
+			if container.Env[0].Value != tt.wantMode {
+				t.Errorf("env value is %q, should be %q", container.Env[0].Value, tt.wantMode)
+			}
+			if !reflect.DeepEqual(gotVarWarning, tt.wantVarWarning) {
+				t.Errorf("ParseDevfileAndValidate() gotVarWarning = %v, want %v", gotVarWarning, tt.wantVarWarning)
+			}
+		})
+	}
+}
+
+func TestParseDevfileAndValidate_AllowMissingSchema(t *testing.T) {
+	// the schema of schemaVersion 2.99.0 is not bundled with the parser, simulating a newer devfile
+	devfileContent := `schemaVersion: 2.99.0
+metadata:
+  name: missing-schema
+components:
+- name: runtime
+  container:
+    image: my-app:latest
+`
+	missingSchemaErr := `unable to find schema for version "2.99.0"`
+
+	tests := []struct {
+		name               string
+		allowMissingSchema bool
+		wantErr            string
+	}{
+		{
+			name:    "should fail if the schema is missing by default",
+			wantErr: missingSchemaErr,
+		},
+		{
+			name:               "should parse without schema validation if the schema is missing and allowed",
+			allowMissingSchema: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotD, _, err := ParseDevfileAndValidate(parser.ParserArgs{
+				Data:               []byte(devfileContent),
+				AllowMissingSchema: tt.allowMissingSchema,
+			})
+			if (err != nil) != (tt.wantErr != "") {
+				t.Fatalf("ParseDevfileAndValidate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("ParseDevfileAndValidate() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+
+			if !strings.Contains(gotD.Ctx.GetSchemaWarning(), missingSchemaErr) {
+				t.Errorf("Got schema warning: %q, want: %q", gotD.Ctx.GetSchemaWarning(), missingSchemaErr)
+			}
+			if gotD.Data.GetSchemaVersion() != "2.99.0" {
+				t.Errorf("Got schemaVersion: %v, want: %v", gotD.Data.GetSchemaVersion(), "2.99.0")
+			}
+			components, err := gotD.Data.GetComponents(common.DevfileOptions{})
+			if err != nil {
+				t.Fatalf("GetComponents() unexpected error: %v", err)
+			}
+			if len(components) != 1 || components[0].Container == nil || components[0].Container.Image != "my-app:latest" {
+				t.Errorf("Got components: %v, want the runtime container component", components)
+			}
+		})
+	}
+}
+
+func TestParseDevfileAndValidate_StrictWarnings(t *testing.T) {
+	undefinedVariableDevfile := `schemaVersion: 2.2.0
+metadata:
+  name: strict-warnings
+components:
+- name: runtime
+  container:
+    image: "my-app:{{tag}}"
+`
+	validDevfile := `schemaVersion: 2.2.0
+metadata:
+  name: strict-warnings
+variables:
+  tag: latest
+components:
+- name: runtime
+  container:
+    image: "my-app:{{tag}}"
+`
+	nonPersistentPathDevfile := `schemaVersion: 2.2.0
+metadata:
+  name: strict-warnings
+components:
+- name: runtime
+  container:
+    image: my-app
+commands:
+- id: build
+  exec:
+    component: runtime
+    commandLine: make
+    workingDir: /build
+`
+	unknownFieldDevfile := `schemaVersion: 2.2.0
+metadata:
+  name: strict-warnings
+legacyField: true
+`
+	undefinedVariableErr := "devfile has warnings, which are treated as errors:\n- component runtime references undefined variables: tag"
+	nonPersistentPathErr := "devfile has warnings, which are treated as errors:\n- command build has the workingDir /build, which is not under a volume mount or the project sources of component runtime, and loses its files when the container restarts"
+	unknownFieldErr := "failed to populateAndParseDevfile: invalid devfile schema. errors :\n- (root): Additional property legacyField is not allowed\n"
+	unknownFieldWarningErr := "devfile has warnings, which are treated as errors:\n- ignoring the unknown top-level field legacyField of the devfile"
+
+	tests := []struct {
+		name                   string
+		devfile                string
+		strictWarnings         bool
+		warnNonPersistentPaths bool
+		lenientUnknownFields   bool
+		wantErr                string
+		wantVarWarning         bool
+	}{
+		{
+			name:           "undefined variable should only be a warning by default",
+			devfile:        undefinedVariableDevfile,
+			wantVarWarning: true,
+		},
+		{
+			name:           "undefined variable should be an error in strict mode",
+			devfile:        undefinedVariableDevfile,
+			strictWarnings: true,
+			wantErr:        undefinedVariableErr,
+			wantVarWarning: true,
+		},
+		{
+			name:           "devfile without warnings should parse in strict mode",
+			devfile:        validDevfile,
+			strictWarnings: true,
+		},
+		{
+			name:           "non persistent workingDir should not be a warning by default",
+			devfile:        nonPersistentPathDevfile,
+			strictWarnings: true,
+		},
+		{
+			name:                   "non persistent workingDir should be an error in strict mode when warned",
+			devfile:                nonPersistentPathDevfile,
+			strictWarnings:         true,
+			warnNonPersistentPaths: true,
+			wantErr:                nonPersistentPathErr,
+		},
+		{
+			name:    "unknown top-level field should fail the schema validation by default",
+			devfile: unknownFieldDevfile,
+			wantErr: unknownFieldErr,
+		},
+		{
+			name:                 "unknown top-level field should only be a warning in lenient mode",
+			devfile:              unknownFieldDevfile,
+			lenientUnknownFields: true,
+		},
+		{
+			name:                 "unknown top-level field should be an error in strict and lenient mode",
+			devfile:              unknownFieldDevfile,
+			strictWarnings:       true,
+			lenientUnknownFields: true,
+			wantErr:              unknownFieldWarningErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, gotVarWarning, err := ParseDevfileAndValidate(parser.ParserArgs{
+				Data:                   []byte(tt.devfile),
+				StrictWarnings:         tt.strictWarnings,
+				WarnNonPersistentPaths: tt.warnNonPersistentPaths,
+				LenientUnknownFields:   tt.lenientUnknownFields,
+			})
+			if (err != nil) != (tt.wantErr != "") {
+				t.Fatalf("ParseDevfileAndValidate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && err.Error() != tt.wantErr {
+				t.Errorf("ParseDevfileAndValidate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if gotWarning := len(gotVarWarning.Components) > 0; gotWarning != tt.wantVarWarning {
+				t.Errorf("Got variable warning: %v, want a warning: %v", gotVarWarning, tt.wantVarWarning)
+			}
+		})
+	}
+}
+
+func TestParseDevfileAndValidate_Concurrent(t *testing.T) {
+	const parallelism = 24
+	const parentDevfile = `schemaVersion: 2.2.0
+metadata:
+  name: parent
+components:
+- name: parent-runtime
+  container:
+    image: "parent:{{tag}}"
+`
+	const devfileTemplate = `schemaVersion: 2.2.0
+metadata:
+  name: devfile-%[1]d
+%[2]s
+variables:
+  tag: "%[1]d"
+components:
+- name: runtime-%[1]d
+  container:
+    image: "my-app:{{tag}}"
+commands:
+- id: run
+  exec:
+    component: runtime-%[1]d
+    commandLine: npm start
+    group:
+      kind: run
+      isDefault: true
+`
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var i int
+		if _, err := fmt.Sscanf(r.URL.Path, "/devfile-%d.yaml", &i); err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(fmt.Sprintf(devfileTemplate, i, "")))
+	}))
+	defer testServer.Close()
+
+	// the devfiles parsed from a path have a parent in their directory
+	devfilePaths := make([]string, parallelism)
+	for i := range devfilePaths {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, "parent.yaml"), []byte(parentDevfile), 0644); err != nil {
+			t.Fatalf("TestParseDevfileAndValidate_Concurrent() unexpected error: %v", err)
+		}
+		devfilePaths[i] = filepath.Join(dir, "devfile.yaml")
+		if err := os.WriteFile(devfilePaths[i], []byte(fmt.Sprintf(devfileTemplate, i, "parent:\n  uri: parent.yaml")), 0644); err != nil {
+			t.Fatalf("TestParseDevfileAndValidate_Concurrent() unexpected error: %v", err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, parallelism)
+	for i := 0; i < parallelism; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var want []string
+			args := parser.ParserArgs{ExternalVariables: map[string]string{"external": fmt.Sprint(i)}}
+			switch i % 3 {
+			case 0:
+				args.URL = fmt.Sprintf("%s/devfile-%d.yaml", testServer.URL, i)
+			case 1:
+				args.Data = []byte(fmt.Sprintf(devfileTemplate, i, ""))
+			default:
+				args.Path = devfilePaths[i]
+				want = append(want, fmt.Sprintf("parent-runtime=parent:%d", i))
+			}
+			want = append(want, fmt.Sprintf("runtime-%d=my-app:%d", i, i))
+
+			d, _, err := ParseDevfileAndValidate(args)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			components, err := d.Data.GetComponents(common.DevfileOptions{})
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			var got []string
+			for _, component := range components {
+				got = append(got, component.Name+"="+component.Container.Image)
+			}
+			if !reflect.DeepEqual(got, want) {
+				errs[i] = fmt.Errorf("got components: %v, want: %v", got, want)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("TestParseDevfileAndValidate_Concurrent() parse %d unexpected error: %v", i, err)
+		}
+	}
+}
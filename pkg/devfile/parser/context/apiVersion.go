@@ -21,10 +21,33 @@ import (
 	"strings"
 
 	"github.com/devfile/library/v2/pkg/devfile/parser/data"
+	versionpkg "github.com/hashicorp/go-version"
 	"github.com/pkg/errors"
+	yamlv3 "gopkg.in/yaml.v3"
 	"k8s.io/klog"
 )
 
+var (
+	// ErrSchemaVersionMissing is the error, checked with errors.Is, of a devfile without a schemaVersion field
+	ErrSchemaVersionMissing = errors.New("schemaVersion not present in devfile")
+	// ErrSchemaVersionEmpty is the error, checked with errors.Is, of a devfile with an empty schemaVersion
+	ErrSchemaVersionEmpty = errors.New("schemaVersion cannot be empty")
+)
+
+// schemaVersionError is an error about the schemaVersion of a devfile, matching its sentinel error with errors.Is
+type schemaVersionError struct {
+	sentinel error
+	message  string
+}
+
+func (e *schemaVersionError) Error() string {
+	return e.message
+}
+
+func (e *schemaVersionError) Unwrap() error {
+	return e.sentinel
+}
+
 // SetDevfileAPIVersion returns the devfile APIVersion
 func (d *DevfileCtx) SetDevfileAPIVersion() error {
 
@@ -47,20 +70,75 @@ func (d *DevfileCtx) SetDevfileAPIVersion() error {
 	if okSchema {
 		// SchemaVersion cannot be empty
 		if schemaVersion.(string) == "" {
-			return fmt.Errorf("schemaVersion in devfile: %s cannot be empty", devfilePath)
+			return &schemaVersionError{sentinel: ErrSchemaVersionEmpty, message: fmt.Sprintf("schemaVersion in devfile: %s cannot be empty", devfilePath)}
 		}
 	} else {
-		return fmt.Errorf("schemaVersion not present in devfile: %s", devfilePath)
+		return &schemaVersionError{sentinel: ErrSchemaVersionMissing, message: fmt.Sprintf("schemaVersion not present in devfile: %s", devfilePath)}
 	}
 
 	// Successful
 	// split by `-` and get the first substring as schema version, schemaVersion without `-` won't get affected
 	// e.g. 2.2.0-latest => 2.2.0, 2.2.0 => 2.2.0
-	d.apiVersion = strings.Split(schemaVersion.(string), "-")[0]
+	d.schemaVersion = schemaVersion.(string)
+	d.apiVersion = apiVersionOf(d.schemaVersion)
 	klog.V(4).Infof("devfile schemaVersion: '%s'", d.apiVersion)
 	return nil
 }
 
+// apiVersionOf returns the schemaVersion without its pre-release suffix
+func apiVersionOf(schemaVersion string) string {
+	return strings.Split(schemaVersion, "-")[0]
+}
+
+// DetectSchemaVersion returns the schemaVersion of the devfile content in YAML or JSON format without its pre-release suffix,
+// e.g. 2.2.0 for 2.2.0-latest, like the apiVersion set by SetDevfileAPIVersion. Only the schemaVersion field is decoded,
+// the rest of the devfile is neither decoded into a map nor validated.
+func DetectSchemaVersion(data []byte) (string, error) {
+	var devfile struct {
+		SchemaVersion *string `json:"schemaVersion" yaml:"schemaVersion"`
+	}
+
+	data, err := toUTF8(data)
+	if err != nil {
+		return "", err
+	}
+	if hasJSONPrefix(data) {
+		err = json.Unmarshal(data, &devfile)
+	} else {
+		err = yamlv3.Unmarshal(data, &devfile)
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to decode devfile schemaVersion")
+	}
+
+	if devfile.SchemaVersion == nil {
+		return "", &schemaVersionError{sentinel: ErrSchemaVersionMissing, message: "schemaVersion not present in devfile"}
+	}
+	if *devfile.SchemaVersion == "" {
+		return "", &schemaVersionError{sentinel: ErrSchemaVersionEmpty, message: "schemaVersion in devfile cannot be empty"}
+	}
+	return apiVersionOf(*devfile.SchemaVersion), nil
+}
+
+// checkMaxSchemaVersion returns an error if the devfile apiVersion is higher than the maximum supported schemaVersion
+func (d *DevfileCtx) checkMaxSchemaVersion() error {
+	if d.maxSchemaVersion == "" {
+		return nil
+	}
+	maxVersion, err := versionpkg.NewVersion(d.maxSchemaVersion)
+	if err != nil {
+		return fmt.Errorf("fail to parse maximum supported schemaVersion %s: %v", d.maxSchemaVersion, err)
+	}
+	devfileVersion, err := versionpkg.NewVersion(d.apiVersion)
+	if err != nil {
+		return fmt.Errorf("fail to parse devfile schemaVersion %s: %v", d.apiVersion, err)
+	}
+	if devfileVersion.GreaterThan(maxVersion) {
+		return fmt.Errorf("devfile schemaVersion %s exceeds maximum supported %s", d.apiVersion, d.maxSchemaVersion)
+	}
+	return nil
+}
+
 // GetApiVersion returns apiVersion stored in devfile context
 func (d *DevfileCtx) GetApiVersion() string {
 	return d.apiVersion
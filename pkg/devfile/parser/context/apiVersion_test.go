@@ -16,9 +16,12 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
-	"reflect"
+	"os"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestSetDevfileAPIVersion(t *testing.T) {
@@ -38,31 +41,32 @@ func TestSetDevfileAPIVersion(t *testing.T) {
 		name       string
 		devfileCtx DevfileCtx
 		want       string
-		wantErr    error
+		wantErr    string
+		wantErrIs  error
 	}{
 		{
 			name:       "valid schemaVersion",
 			devfileCtx: DevfileCtx{rawContent: []byte(validJson), absPath: devfilePath},
 			want:       schemaVersion,
-			wantErr:    nil,
 		},
 		{
 			name:       "concrete schemaVersion",
 			devfileCtx: DevfileCtx{rawContent: []byte(concreteSchema), absPath: devfilePath},
 			want:       schemaVersion,
-			wantErr:    nil,
 		},
 		{
 			name:       "schemaVersion not present",
 			devfileCtx: DevfileCtx{rawContent: []byte(emptyJson), absPath: devfilePath},
 			want:       "",
-			wantErr:    fmt.Errorf("schemaVersion not present in devfile: %s", devfilePath),
+			wantErr:    fmt.Sprintf("schemaVersion not present in devfile: %s", devfilePath),
+			wantErrIs:  ErrSchemaVersionMissing,
 		},
 		{
 			name:       "schemaVersion empty",
 			devfileCtx: DevfileCtx{rawContent: []byte(emptySchemaVersionJson), url: devfileURL},
 			want:       "",
-			wantErr:    fmt.Errorf("schemaVersion in devfile: %s cannot be empty", devfileURL),
+			wantErr:    fmt.Sprintf("schemaVersion in devfile: %s cannot be empty", devfileURL),
+			wantErrIs:  ErrSchemaVersionEmpty,
 		},
 	}
 
@@ -76,8 +80,19 @@ func TestSetDevfileAPIVersion(t *testing.T) {
 			gotErr := d.SetDevfileAPIVersion()
 			got := d.apiVersion
 
-			if !reflect.DeepEqual(gotErr, tt.wantErr) {
+			if (gotErr != nil) != (tt.wantErr != "") {
 				t.Errorf("TestSetDevfileAPIVersion() unexpected error: '%v', wantErr: '%v'", gotErr, tt.wantErr)
+			} else if gotErr != nil {
+				assert.Equal(t, tt.wantErr, gotErr.Error(), "TestSetDevfileAPIVersion(): Error message should match")
+				if !errors.Is(gotErr, tt.wantErrIs) {
+					t.Errorf("TestSetDevfileAPIVersion() error: '%v' is not '%v'", gotErr, tt.wantErrIs)
+				}
+				// a schemaVersion error is not mistaken for the other one
+				for _, sentinel := range []error{ErrSchemaVersionMissing, ErrSchemaVersionEmpty} {
+					if sentinel != tt.wantErrIs && errors.Is(gotErr, sentinel) {
+						t.Errorf("TestSetDevfileAPIVersion() error: '%v' is unexpectedly '%v'", gotErr, sentinel)
+					}
+				}
 			}
 
 			if got != tt.want {
@@ -106,3 +121,107 @@ func TestGetApiVersion(t *testing.T) {
 		}
 	})
 }
+
+func TestDetectSchemaVersion(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		want      string
+		wantErr   string
+		wantErrIs error
+	}{
+		{
+			name: "YAML devfile",
+			data: "schemaVersion: 2.2.0\nmetadata:\n  name: nodejs\n",
+			want: "2.2.0",
+		},
+		{
+			name: "JSON devfile",
+			data: `{"metadata": {"name": "nodejs"}, "schemaVersion": "2.1.0"}`,
+			want: "2.1.0",
+		},
+		{
+			name: "pre-release suffix is stripped",
+			data: "schemaVersion: 2.2.0-latest\n",
+			want: "2.2.0",
+		},
+		{
+			name: "UTF-8 byte order mark",
+			data: "\xEF\xBB\xBFschemaVersion: 2.2.0\n",
+			want: "2.2.0",
+		},
+		{
+			name:      "missing schemaVersion",
+			data:      "metadata:\n  name: nodejs\n",
+			wantErr:   "schemaVersion not present in devfile",
+			wantErrIs: ErrSchemaVersionMissing,
+		},
+		{
+			name:      "empty schemaVersion",
+			data:      `{"schemaVersion": ""}`,
+			wantErr:   "schemaVersion in devfile cannot be empty",
+			wantErrIs: ErrSchemaVersionEmpty,
+		},
+		{
+			name:      "missing schemaVersion of a JSON devfile",
+			data:      `{"metadata": {"name": "nodejs"}}`,
+			wantErr:   "schemaVersion not present in devfile",
+			wantErrIs: ErrSchemaVersionMissing,
+		},
+		{
+			name:      "empty schemaVersion of a YAML devfile",
+			data:      "schemaVersion: \"\"\n",
+			wantErr:   "schemaVersion in devfile cannot be empty",
+			wantErrIs: ErrSchemaVersionEmpty,
+		},
+		{
+			name:    "invalid YAML",
+			data:    ":: invalid :: content",
+			wantErr: "failed to decode devfile schemaVersion",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DetectSchemaVersion([]byte(tt.data))
+			if (err != nil) != (tt.wantErr != "") {
+				t.Fatalf("unexpected error: %v, wantErr: %v", err, tt.wantErr)
+			}
+			if err != nil {
+				assert.Regexp(t, tt.wantErr, err.Error(), "Error message should match")
+				if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+					t.Errorf("Got error: %v, want error matching: %v", err, tt.wantErrIs)
+				}
+			} else if got != tt.want {
+				t.Errorf("Got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func BenchmarkDetectSchemaVersion(b *testing.B) {
+	devfileContent, err := os.ReadFile("../../../../tests/v2/devfiles/samples/Test_220.yaml")
+	if err != nil {
+		b.Fatalf("failed to read test devfile: %v", err)
+	}
+	for i := 0; i < b.N; i++ {
+		if _, err := DetectSchemaVersion(devfileContent); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSetDevfileAPIVersion(b *testing.B) {
+	devfileContent, err := os.ReadFile("../../../../tests/v2/devfiles/samples/Test_220.yaml")
+	if err != nil {
+		b.Fatalf("failed to read test devfile: %v", err)
+	}
+	for i := 0; i < b.N; i++ {
+		d := DevfileCtx{}
+		if err := d.SetDevfileContentFromBytes(devfileContent); err != nil {
+			b.Fatal(err)
+		}
+		if err := d.SetDevfileAPIVersion(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -17,20 +17,36 @@ package parser
 
 import (
 	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
 	"unicode"
+	"unicode/utf16"
 
 	"github.com/devfile/library/v2/pkg/util"
 	"github.com/pkg/errors"
+	yamlv2 "gopkg.in/yaml.v2"
+	yamlv3 "gopkg.in/yaml.v3"
 	"k8s.io/klog"
-	"sigs.k8s.io/yaml"
 )
 
 // Every JSON document starts with "{"
 var jsonPrefix = []byte("{")
 
+// Byte order marks of the supported devfile encodings
+var (
+	utf8BOM    = []byte{0xEF, 0xBB, 0xBF}
+	utf16LEBOM = []byte{0xFF, 0xFE}
+	utf16BEBOM = []byte{0xFE, 0xFF}
+)
+
 // YAMLToJSON converts a single YAML document into a JSON document
 // or returns an error. If the document appears to be JSON the
-// YAML decoding path is not used.
+// YAML decoding path is not used. Anchors and aliases, including
+// merge keys (<<: *alias), are expanded in the JSON document.
 func YAMLToJSON(data []byte) ([]byte, error) {
 
 	// Is already JSON
@@ -39,7 +55,184 @@ func YAMLToJSON(data []byte) ([]byte, error) {
 	}
 
 	// Is YAML, convert to JSON
-	data, err := yaml.YAMLToJSON(data)
+	data, err := convertYAMLToJSON(data)
+	if err != nil {
+		return data, errors.Wrapf(err, "failed to convert devfile yaml to json")
+	}
+
+	// Successful
+	klog.V(4).Infof("converted devfile YAML to JSON")
+	return data, nil
+}
+
+// convertYAMLToJSON converts a YAML document into a JSON document, with the same output as sigs.k8s.io/yaml.YAMLToJSON.
+// The JSON document is written from the decoded YAML document directly into a single buffer, instead of converting the
+// decoded document into a JSON compatible copy then marshaling it, to reduce the peak memory of large devfiles.
+func convertYAMLToJSON(data []byte) ([]byte, error) {
+	var yamlObj interface{}
+	if err := yamlv2.Unmarshal(data, &yamlObj); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(len(data))
+	w := jsonWriter{buf: &buf, encoder: json.NewEncoder(&buf)}
+	if err := w.write(yamlObj); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// jsonWriter writes a decoded YAML document as JSON
+type jsonWriter struct {
+	buf *bytes.Buffer
+	// encoder encodes the scalar values into buf, as json.Marshal does
+	encoder *json.Encoder
+}
+
+// jsonMember is a member of a JSON object
+type jsonMember struct {
+	key   string
+	value interface{}
+}
+
+func (w *jsonWriter) write(yamlObj interface{}) error {
+	switch typedYAMLObj := yamlObj.(type) {
+	case map[interface{}]interface{}:
+		members := make([]jsonMember, 0, len(typedYAMLObj))
+		for k, v := range typedYAMLObj {
+			key, err := jsonKey(k)
+			if err != nil {
+				return err
+			}
+			members = append(members, jsonMember{key: key, value: v})
+		}
+		// json.Marshal sorts the keys of a map
+		sort.Slice(members, func(i, j int) bool {
+			return members[i].key < members[j].key
+		})
+
+		w.buf.WriteByte('{')
+		written := 0
+		for i, member := range members {
+			// keys that are equal once converted to strings are a single key of the JSON object
+			if i+1 < len(members) && members[i+1].key == member.key {
+				continue
+			}
+			if written > 0 {
+				w.buf.WriteByte(',')
+			}
+			written++
+			if err := w.writeScalar(member.key); err != nil {
+				return err
+			}
+			w.buf.WriteByte(':')
+			if err := w.write(member.value); err != nil {
+				return err
+			}
+		}
+		w.buf.WriteByte('}')
+		return nil
+	case []interface{}:
+		w.buf.WriteByte('[')
+		for i, v := range typedYAMLObj {
+			if i > 0 {
+				w.buf.WriteByte(',')
+			}
+			if err := w.write(v); err != nil {
+				return err
+			}
+		}
+		w.buf.WriteByte(']')
+		return nil
+	default:
+		return w.writeScalar(typedYAMLObj)
+	}
+}
+
+// writeScalar writes the JSON encoding of the value, without the newline written by the encoder
+func (w *jsonWriter) writeScalar(value interface{}) error {
+	if err := w.encoder.Encode(value); err != nil {
+		return err
+	}
+	w.buf.Truncate(w.buf.Len() - 1)
+	return nil
+}
+
+// jsonKey converts the key of a YAML mapping to the key of a JSON object, as sigs.k8s.io/yaml.YAMLToJSON does
+func jsonKey(k interface{}) (string, error) {
+	switch typedKey := k.(type) {
+	case string:
+		return typedKey, nil
+	case int:
+		return strconv.Itoa(typedKey), nil
+	case int64:
+		return strconv.FormatInt(typedKey, 10), nil
+	case float64:
+		s := strconv.FormatFloat(typedKey, 'g', -1, 32)
+		switch s {
+		case "+Inf":
+			s = ".inf"
+		case "-Inf":
+			s = "-.inf"
+		case "NaN":
+			s = ".nan"
+		}
+		return s, nil
+	case bool:
+		return strconv.FormatBool(typedKey), nil
+	default:
+		return "", fmt.Errorf("unsupported map key of type: %T, key: %+#v", k, k)
+	}
+}
+
+// toUTF8 strips the byte order mark from the provided buffer and transcodes UTF-16 content to UTF-8.
+// UTF-16 content without a byte order mark is detected from the null byte of its leading ASCII character.
+func toUTF8(data []byte) ([]byte, error) {
+	var order binary.ByteOrder
+	switch {
+	case bytes.HasPrefix(data, utf8BOM):
+		return data[len(utf8BOM):], nil
+	case bytes.HasPrefix(data, utf16LEBOM):
+		order, data = binary.LittleEndian, data[len(utf16LEBOM):]
+	case bytes.HasPrefix(data, utf16BEBOM):
+		order, data = binary.BigEndian, data[len(utf16BEBOM):]
+	case len(data) >= 2 && data[0] != 0 && data[1] == 0:
+		order = binary.LittleEndian
+	case len(data) >= 2 && data[0] == 0 && data[1] != 0:
+		order = binary.BigEndian
+	default:
+		return data, nil
+	}
+
+	if len(data)%2 != 0 {
+		return nil, fmt.Errorf("failed to decode UTF-16 devfile content, odd number of bytes")
+	}
+	codes := make([]uint16, len(data)/2)
+	for i := range codes {
+		codes[i] = order.Uint16(data[2*i:])
+	}
+
+	klog.V(4).Infof("transcoded UTF-16 devfile content to UTF-8")
+	return []byte(string(utf16.Decode(codes))), nil
+}
+
+// YAMLToJSONStrict converts a single YAML document into a JSON document like YAMLToJSON,
+// but returns an error if the YAML document contains duplicate keys. Keys merged from an
+// alias with a merge key (<<: *alias) may be overridden, as they are not duplicates.
+func YAMLToJSONStrict(data []byte) ([]byte, error) {
+
+	// Is already JSON
+	if hasJSONPrefix(data) {
+		return data, nil
+	}
+
+	if err := checkDuplicateKeys(data); err != nil {
+		return nil, errors.Wrapf(err, "failed to convert devfile yaml to json")
+	}
+
+	// Is YAML, convert to JSON
+	data, err := convertYAMLToJSON(data)
 	if err != nil {
 		return data, errors.Wrapf(err, "failed to convert devfile yaml to json")
 	}
@@ -49,6 +242,98 @@ func YAMLToJSON(data []byte) ([]byte, error) {
 	return data, nil
 }
 
+// checkDuplicateKeys returns an error if a mapping of the YAML document contains a key more than once.
+// The keys of an alias merged with a merge key are not keys of the mapping, so they can be overridden.
+func checkDuplicateKeys(data []byte) error {
+	var root yamlv3.Node
+	if err := yamlv3.Unmarshal(data, &root); err != nil {
+		return err
+	}
+	return checkNodeDuplicateKeys(&root)
+}
+
+func checkNodeDuplicateKeys(node *yamlv3.Node) error {
+	if node.Kind == yamlv3.MappingNode {
+		keys := map[string]bool{}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			if key.Tag == "!!merge" {
+				continue
+			}
+			if keys[key.Value] {
+				return fmt.Errorf("line %d: key %q already set in map", key.Line, key.Value)
+			}
+			keys[key.Value] = true
+		}
+	}
+	// aliased nodes are checked where they are anchored
+	for _, child := range node.Content {
+		if err := checkNodeDuplicateKeys(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// extractDevfileDocument returns the devfile document of YAML content of several documents separated by ---, e.g. a devfile
+// concatenated with the Kubernetes manifests it deploys. The devfile document is the document with a top-level schemaVersion.
+// Content of a single document is returned as is. An error is returned if none or several of the documents are devfiles.
+func extractDevfileDocument(data []byte) ([]byte, error) {
+	// every stream of several documents has a document separator
+	if !bytes.Contains(data, []byte("---")) {
+		return data, nil
+	}
+
+	var documents, devfiles []*yamlv3.Node
+	decoder := yamlv3.NewDecoder(bytes.NewReader(data))
+	for {
+		document := &yamlv3.Node{}
+		err := decoder.Decode(document)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// invalid YAML is reported by the conversion of the content to JSON
+			return data, nil
+		}
+		// skip the empty documents, e.g. of a trailing separator
+		if len(document.Content) == 0 {
+			continue
+		}
+		documents = append(documents, document)
+		if isDevfileDocument(document) {
+			devfiles = append(devfiles, document)
+		}
+	}
+	if len(documents) <= 1 {
+		return data, nil
+	}
+
+	switch len(devfiles) {
+	case 0:
+		return nil, fmt.Errorf("the YAML content has %d documents and none of them is a devfile with a schemaVersion", len(documents))
+	case 1:
+		klog.V(4).Infof("extracted the devfile document of the %d YAML documents", len(documents))
+		return yamlv3.Marshal(devfiles[0])
+	default:
+		return nil, fmt.Errorf("the YAML content has %d devfile documents with a schemaVersion, only one devfile is supported", len(devfiles))
+	}
+}
+
+// isDevfileDocument checks if the YAML document is a mapping with a schemaVersion key
+func isDevfileDocument(document *yamlv3.Node) bool {
+	root := document.Content[0]
+	if root.Kind != yamlv3.MappingNode {
+		return false
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "schemaVersion" {
+			return true
+		}
+	}
+	return false
+}
+
 // hasJSONPrefix returns true if the provided buffer appears to start with
 // a JSON open brace.
 func hasJSONPrefix(buf []byte) bool {
@@ -61,14 +346,21 @@ func hasPrefix(buf []byte, prefix []byte) bool {
 	return bytes.HasPrefix(trim, prefix)
 }
 
-// SetDevfileContent reads devfile and if devfile is in YAML format converts it to JSON
+// SetDevfileContent reads devfile and if devfile is in YAML format converts it to JSON.
+// Devfiles of object storage urls, e.g. s3://bucket/key, are fetched with the client registered by RegisterObjectStoreClient.
 func (d *DevfileCtx) SetDevfileContent() error {
 
 	var err error
 	var data []byte
-	if d.url != "" {
+	if isObjectStoreURL(d.url) {
+		data, err = getObject(d.requestContext, d.url)
+		if err != nil {
+			return errors.Wrap(err, "error getting devfile info from url")
+		}
+	} else if d.url != "" {
 		// set the client identifier for telemetry
-		params := util.HTTPRequestParams{URL: d.url, TelemetryClientName: util.TelemetryClientName}
+		params := util.HTTPRequestParams{URL: d.url, TelemetryClientName: util.TelemetryClientName, Context: d.requestContext,
+			BasicAuth: d.urlCredentials}
 		if d.token != "" {
 			params.Token = d.token
 		}
@@ -90,10 +382,24 @@ func (d *DevfileCtx) SetDevfileContent() error {
 }
 
 // SetDevfileContentFromBytes sets devfile content from byte input
+// A byte order mark is stripped and UTF-16 content is transcoded to UTF-8 before the conversion, and the devfile document
+// is extracted from YAML content of several documents
 func (d *DevfileCtx) SetDevfileContentFromBytes(data []byte) error {
+	data, err := toUTF8(data)
+	if err != nil {
+		return err
+	}
+	data, err = extractDevfileDocument(data)
+	if err != nil {
+		return err
+	}
+
 	// If YAML file convert it to JSON
-	var err error
-	d.rawContent, err = YAMLToJSON(data)
+	if d.strictYAML {
+		d.rawContent, err = YAMLToJSONStrict(data)
+	} else {
+		d.rawContent, err = YAMLToJSON(data)
+	}
 	if err != nil {
 		return err
 	}
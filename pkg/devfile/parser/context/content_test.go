@@ -16,10 +16,19 @@
 package parser
 
 import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"unicode/utf16"
 
 	"github.com/devfile/library/v2/pkg/testingutil/filesystem"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/yaml"
 )
 
 const (
@@ -190,3 +199,361 @@ func TestSetDevfileContentFromBytes(t *testing.T) {
 		}
 	})
 }
+
+func TestSetDevfileContentFromBytes_Encodings(t *testing.T) {
+
+	const devfileYaml = "schemaVersion: 2.2.0\nmetadata:\n  name: nodejs\n"
+
+	// encodeUTF16 helper encodes content to UTF-16 with the given byte order, prefixed by the given byte order mark
+	encodeUTF16 := func(content string, order binary.ByteOrder, bom []byte) []byte {
+		encoded := append([]byte{}, bom...)
+		buf := make([]byte, 2)
+		for _, code := range utf16.Encode([]rune(content)) {
+			order.PutUint16(buf, code)
+			encoded = append(encoded, buf...)
+		}
+		return encoded
+	}
+
+	tests := []struct {
+		name        string
+		data        []byte
+		wantVersion string
+		wantErr     bool
+	}{
+		{
+			name:        "UTF-8 content",
+			data:        []byte(devfileYaml),
+			wantVersion: "2.2.0",
+		},
+		{
+			name:        "UTF-8 content with BOM",
+			data:        append([]byte{0xEF, 0xBB, 0xBF}, devfileYaml...),
+			wantVersion: "2.2.0",
+		},
+		{
+			name:        "UTF-8 JSON content with BOM",
+			data:        append([]byte{0xEF, 0xBB, 0xBF}, validJsonRawContent200()...),
+			wantVersion: "2.0.0",
+		},
+		{
+			name:        "UTF-16LE content with BOM",
+			data:        encodeUTF16(devfileYaml, binary.LittleEndian, []byte{0xFF, 0xFE}),
+			wantVersion: "2.2.0",
+		},
+		{
+			name:        "UTF-16BE content with BOM",
+			data:        encodeUTF16(devfileYaml, binary.BigEndian, []byte{0xFE, 0xFF}),
+			wantVersion: "2.2.0",
+		},
+		{
+			name:        "UTF-16LE content without BOM",
+			data:        encodeUTF16(devfileYaml, binary.LittleEndian, nil),
+			wantVersion: "2.2.0",
+		},
+		{
+			name:        "UTF-16BE content without BOM",
+			data:        encodeUTF16(devfileYaml, binary.BigEndian, nil),
+			wantVersion: "2.2.0",
+		},
+		{
+			name:    "truncated UTF-16 content",
+			data:    encodeUTF16(devfileYaml, binary.LittleEndian, []byte{0xFF, 0xFE})[:9],
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := DevfileCtx{}
+			err := d.SetDevfileContentFromBytes(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v, wantErr: %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if err := d.SetDevfileAPIVersion(); err != nil {
+				t.Fatalf("unexpected error while setting the devfile api version: %v", err)
+			}
+			if d.GetApiVersion() != tt.wantVersion {
+				t.Errorf("Got: %v, want: %v", d.GetApiVersion(), tt.wantVersion)
+			}
+		})
+	}
+}
+
+func TestSetDevfileContentFromBytes_StrictYAML(t *testing.T) {
+
+	const duplicateKeysDevfile = `schemaVersion: 2.2.0
+components:
+- name: runtime
+  container:
+    image: nodejs
+components:
+- name: tools
+  container:
+    image: golang
+`
+
+	tests := []struct {
+		name    string
+		strict  bool
+		data    []byte
+		wantErr string
+	}{
+		{
+			name: "duplicate keys are collapsed by default",
+			data: []byte(duplicateKeysDevfile),
+		},
+		{
+			name:    "duplicate keys are rejected in strict mode",
+			strict:  true,
+			data:    []byte(duplicateKeysDevfile),
+			wantErr: "failed to convert devfile yaml to json(.|\n)*key \"components\" already set in map",
+		},
+		{
+			name:   "valid yaml in strict mode",
+			strict: true,
+			data:   []byte("schemaVersion: 2.2.0\ncomponents:\n- name: runtime\n  container:\n    image: nodejs\n"),
+		},
+		{
+			name:   "json content in strict mode",
+			strict: true,
+			data:   validJsonRawContent200(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := DevfileCtx{}
+			d.SetStrictYAML(tt.strict)
+			err := d.SetDevfileContentFromBytes(tt.data)
+			if (err != nil) != (tt.wantErr != "") {
+				t.Fatalf("unexpected error: %v, wantErr: %v", err, tt.wantErr)
+			}
+			if err != nil {
+				assert.Regexp(t, tt.wantErr, err.Error(), "Error message should match")
+			}
+		})
+	}
+}
+
+func TestSetDevfileContentFromBytes_MultipleDocuments(t *testing.T) {
+
+	const devfileDocument = `schemaVersion: 2.2.0
+metadata:
+  name: nodejs
+components:
+- name: deploy
+  kubernetes:
+    uri: deploy.yaml
+`
+	const deploymentDocument = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nodejs
+`
+
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{
+			name: "devfile followed by a manifest",
+			data: devfileDocument + "---\n" + deploymentDocument,
+		},
+		{
+			name: "manifest followed by a devfile, with leading and trailing separators",
+			data: "---\n" + deploymentDocument + "---\n" + devfileDocument + "---\n",
+		},
+		{
+			name: "single document with a leading separator",
+			data: "---\n" + devfileDocument,
+		},
+		{
+			name:    "documents without a devfile",
+			data:    deploymentDocument + "---\n" + deploymentDocument,
+			wantErr: "the YAML content has 2 documents and none of them is a devfile with a schemaVersion",
+		},
+		{
+			name:    "documents with several devfiles",
+			data:    devfileDocument + "---\n" + devfileDocument,
+			wantErr: "the YAML content has 2 devfile documents with a schemaVersion, only one devfile is supported",
+		},
+	}
+
+	wantContent, err := yaml.YAMLToJSON([]byte(devfileDocument))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := DevfileCtx{}
+			err := d.SetDevfileContentFromBytes([]byte(tt.data))
+			if (err != nil) != (tt.wantErr != "") {
+				t.Fatalf("unexpected error: %v, wantErr: %v", err, tt.wantErr)
+			}
+			if err != nil {
+				assert.Regexp(t, tt.wantErr, err.Error(), "Error message should match")
+				return
+			}
+			assert.JSONEq(t, string(wantContent), string(d.GetDevfileContent()), "the devfile document should be extracted")
+			if err = d.SetDevfileAPIVersion(); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestSetDevfileContentFromBytes_AnchorsAndAliases(t *testing.T) {
+
+	const anchorsDevfile = `schemaVersion: 2.2.0
+metadata:
+  name: anchors
+components:
+- name: runtime
+  container: &container
+    image: nodejs
+    memoryLimit: 1Gi
+    mountSources: true
+- name: tests
+  container: *container
+- name: tools
+  container:
+    <<: *container
+    image: golang
+`
+	wantComponents := []interface{}{
+		map[string]interface{}{"name": "runtime", "container": map[string]interface{}{"image": "nodejs", "memoryLimit": "1Gi", "mountSources": true}},
+		map[string]interface{}{"name": "tests", "container": map[string]interface{}{"image": "nodejs", "memoryLimit": "1Gi", "mountSources": true}},
+		map[string]interface{}{"name": "tools", "container": map[string]interface{}{"image": "golang", "memoryLimit": "1Gi", "mountSources": true}},
+	}
+
+	for _, strict := range []bool{false, true} {
+		t.Run(fmt.Sprintf("strict %v", strict), func(t *testing.T) {
+			d := DevfileCtx{}
+			d.SetStrictYAML(strict)
+			if err := d.SetDevfileContentFromBytes([]byte(anchorsDevfile)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var content map[string]interface{}
+			if err := json.Unmarshal(d.GetDevfileContent(), &content); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(content["components"], wantComponents) {
+				t.Errorf("Got: %v, want: %v", content["components"], wantComponents)
+			}
+		})
+	}
+}
+
+func TestConvertYAMLToJSON(t *testing.T) {
+	tests := map[string]string{
+		"empty document": "",
+		"scalars": `string: value
+html: "<a href=\"x\">&</a>"
+unicode: "caf\u00e9 \U0001F600"
+int: 42
+negative: -7
+octal: 0777
+hex: 0x1F
+float: 3.14
+exponent: 1e+30
+bools: [true, false, yes, no, on, off, y, n, True, OFF]
+nulls: [null, ~, ]
+timestamp: 2001-12-14t21:59:43.10-05:00
+date: 2023-04-13
+`,
+		"keys": `1: int key
+2.5: float key
+true: bool key
+b: 1
+a: 2
+`,
+		"anchors and aliases": `base: &base
+  image: nodejs
+  memoryLimit: 1Gi
+components:
+- name: runtime
+  container:
+    <<: *base
+    memoryLimit: 2Gi
+- name: copy
+  container: *base
+`,
+		"nested collections": `a:
+- - 1
+  - [2, {b: [3, {}]}]
+- []
+- {}
+`,
+		"block scalars": `literal: |
+  line 1
+    line 2
+folded: >
+  folded
+  text
+`,
+	}
+	samples, err := filepath.Glob(filepath.Join("..", "..", "..", "..", "tests", "v2", "devfiles", "samples", "*.yaml"))
+	if err != nil {
+		t.Fatalf("TestConvertYAMLToJSON() unexpected error: %v", err)
+	}
+	for _, sample := range samples {
+		data, err := os.ReadFile(sample)
+		if err != nil {
+			t.Fatalf("TestConvertYAMLToJSON() unexpected error: %v", err)
+		}
+		tests[filepath.Base(sample)] = string(data)
+	}
+	tests["large devfile"] = string(largeDevfile(20, 10))
+
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			want, wantErr := yaml.YAMLToJSON([]byte(data))
+			got, err := convertYAMLToJSON([]byte(data))
+			if (err != nil) != (wantErr != nil) {
+				t.Fatalf("TestConvertYAMLToJSON() error: %v, want: %v", err, wantErr)
+			}
+			if string(got) != string(want) {
+				t.Errorf("TestConvertYAMLToJSON() got: %s, want: %s", got, want)
+			}
+		})
+	}
+}
+
+// largeDevfile returns a devfile with the given number of kubernetes components, each inlining a manifest of the given
+// number of kilobytes
+func largeDevfile(components int, kilobytes int) []byte {
+	var devfile strings.Builder
+	devfile.WriteString("schemaVersion: 2.2.0\nmetadata:\n  name: large\ncomponents:\n")
+	for i := 0; i < components; i++ {
+		fmt.Fprintf(&devfile, "- name: deploy-%d\n  kubernetes:\n    inlined: |\n      apiVersion: v1\n      kind: ConfigMap\n      data:\n", i)
+		for j := 0; j < kilobytes*1024/32; j++ {
+			fmt.Fprintf(&devfile, "        key-%06d: value-%011d\n", j, j)
+		}
+	}
+	return []byte(devfile.String())
+}
+
+// BenchmarkYAMLToJSON compares the allocations of the conversion of a large devfile with those of sigs.k8s.io/yaml
+func BenchmarkYAMLToJSON(b *testing.B) {
+	data := largeDevfile(100, 64)
+	for name, convert := range map[string]func([]byte) ([]byte, error){
+		"YAMLToJSON":       YAMLToJSON,
+		"sigs.k8s.io/yaml": yaml.YAMLToJSON,
+	} {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := convert(data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+//
+// Copyright 2023 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+const (
+	// S3Scheme is the scheme of Amazon S3 object urls, s3://bucket/key
+	S3Scheme = "s3"
+	// GCSScheme is the scheme of Google Cloud Storage object urls, gs://bucket/object
+	GCSScheme = "gs"
+)
+
+// ObjectStoreClient fetches objects from an object storage service, such as Amazon S3 or Google Cloud Storage.
+// Clients may be called concurrently, so they should be safe for concurrent use.
+type ObjectStoreClient interface {
+	// GetObject returns the content of the object of the bucket. ctx is cancelled when the devfile parsing is cancelled.
+	GetObject(ctx context.Context, bucket string, key string) ([]byte, error)
+}
+
+var (
+	objectStoreClientsLock sync.RWMutex
+	objectStoreClients     = map[string]ObjectStoreClient{}
+)
+
+// RegisterObjectStoreClient registers the client fetching the devfiles of the urls of the scheme, e.g. S3Scheme for s3://bucket/key urls.
+// Registering a scheme again replaces the previous client.
+func RegisterObjectStoreClient(scheme string, client ObjectStoreClient) error {
+	scheme = strings.ToLower(scheme)
+	if scheme == "" || scheme == "http" || scheme == "https" || scheme == "file" {
+		return fmt.Errorf("failed to register object storage client, scheme should not be empty, http, https or file; received: %q", scheme)
+	}
+	if client == nil {
+		return fmt.Errorf("failed to register object storage client of scheme %s, client should not be nil", scheme)
+	}
+
+	objectStoreClientsLock.Lock()
+	defer objectStoreClientsLock.Unlock()
+	objectStoreClients[scheme] = client
+	return nil
+}
+
+// UnregisterObjectStoreClient removes the client registered for the scheme
+func UnregisterObjectStoreClient(scheme string) {
+	objectStoreClientsLock.Lock()
+	defer objectStoreClientsLock.Unlock()
+	delete(objectStoreClients, strings.ToLower(scheme))
+}
+
+// isObjectStoreURL returns if the url is an object storage url, either of a scheme with a registered client or of S3Scheme or GCSScheme
+func isObjectStoreURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	scheme := strings.ToLower(u.Scheme)
+	if scheme == S3Scheme || scheme == GCSScheme {
+		return true
+	}
+	objectStoreClientsLock.RLock()
+	defer objectStoreClientsLock.RUnlock()
+	_, ok := objectStoreClients[scheme]
+	return ok
+}
+
+// getObject fetches the object of an object storage url, scheme://bucket/key, with the client registered for its scheme
+func getObject(ctx context.Context, rawURL string) ([]byte, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	objectStoreClientsLock.RLock()
+	client := objectStoreClients[strings.ToLower(u.Scheme)]
+	objectStoreClientsLock.RUnlock()
+	if client == nil {
+		return nil, fmt.Errorf("failed to get devfile from url %s, no object storage client is registered for %s:// urls", rawURL, u.Scheme)
+	}
+
+	bucket, key := u.Host, strings.TrimPrefix(u.Path, "/")
+	if bucket == "" || key == "" {
+		return nil, fmt.Errorf("failed to get devfile from url %s, object storage urls should be %s://bucket/key", rawURL, u.Scheme)
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	data, err := client.GetObject(ctx, bucket, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object %s of bucket %s: %w", key, bucket, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,153 @@
+//
+// Copyright 2023 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeObjectStoreClient returns the objects of its buckets, keyed by bucket then key
+type fakeObjectStoreClient struct {
+	buckets map[string]map[string][]byte
+}
+
+func (c fakeObjectStoreClient) GetObject(ctx context.Context, bucket string, key string) ([]byte, error) {
+	data, ok := c.buckets[bucket][key]
+	if !ok {
+		return nil, fmt.Errorf("object not found")
+	}
+	return data, nil
+}
+
+func TestPopulateFromURL_ObjectStore(t *testing.T) {
+	const devfileContent = "schemaVersion: 2.2.0\nmetadata:\n  name: nodejs\n"
+	client := fakeObjectStoreClient{buckets: map[string]map[string][]byte{
+		"devfiles": {"stacks/nodejs/devfile.yaml": []byte(devfileContent)},
+	}}
+
+	tests := []struct {
+		name           string
+		url            string
+		register       []string
+		wantApiVersion string
+		wantErr        string
+	}{
+		{
+			name:           "should fetch a devfile from an s3 url",
+			url:            "s3://devfiles/stacks/nodejs/devfile.yaml",
+			register:       []string{S3Scheme},
+			wantApiVersion: "2.2.0",
+		},
+		{
+			name:           "should fetch a devfile from a gs url",
+			url:            "gs://devfiles/stacks/nodejs/devfile.yaml",
+			register:       []string{GCSScheme},
+			wantApiVersion: "2.2.0",
+		},
+		{
+			name:           "should fetch a devfile from the url of a custom scheme",
+			url:            "minio://devfiles/stacks/nodejs/devfile.yaml",
+			register:       []string{"minio"},
+			wantApiVersion: "2.2.0",
+		},
+		{
+			name:     "should fail without a client registered for the scheme",
+			url:      "gs://devfiles/stacks/nodejs/devfile.yaml",
+			register: []string{S3Scheme},
+			wantErr:  "no object storage client is registered for gs:// urls",
+		},
+		{
+			name:     "should fail if the object is not found",
+			url:      "s3://devfiles/stacks/java/devfile.yaml",
+			register: []string{S3Scheme},
+			wantErr:  "failed to get object stacks/java/devfile.yaml of bucket devfiles: object not found",
+		},
+		{
+			name:     "should fail without an object key",
+			url:      "s3://devfiles",
+			register: []string{S3Scheme},
+			wantErr:  "object storage urls should be s3://bucket/key",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, scheme := range tt.register {
+				if err := RegisterObjectStoreClient(scheme, client); err != nil {
+					t.Fatalf("TestPopulateFromURL_ObjectStore() unexpected error: %v", err)
+				}
+				defer UnregisterObjectStoreClient(scheme)
+			}
+
+			d := NewURLDevfileCtx(tt.url)
+			err := d.PopulateFromURL()
+			if (err != nil) != (tt.wantErr != "") {
+				t.Fatalf("TestPopulateFromURL_ObjectStore() unexpected error: %v, wantErr: %v", err, tt.wantErr)
+			}
+			if err != nil {
+				assert.Regexp(t, tt.wantErr, err.Error(), "Error message should match")
+			} else if d.GetApiVersion() != tt.wantApiVersion {
+				t.Errorf("Got: %v, want: %v", d.GetApiVersion(), tt.wantApiVersion)
+			}
+		})
+	}
+}
+
+func TestRegisterObjectStoreClient(t *testing.T) {
+	tests := []struct {
+		name    string
+		scheme  string
+		client  ObjectStoreClient
+		wantErr string
+	}{
+		{
+			name:   "should register a client",
+			scheme: S3Scheme,
+			client: fakeObjectStoreClient{},
+		},
+		{
+			name:    "should fail with an empty scheme",
+			client:  fakeObjectStoreClient{},
+			wantErr: "scheme should not be empty, http, https or file",
+		},
+		{
+			name:    "should fail with the https scheme",
+			scheme:  "https",
+			client:  fakeObjectStoreClient{},
+			wantErr: "scheme should not be empty, http, https or file",
+		},
+		{
+			name:    "should fail with a nil client",
+			scheme:  S3Scheme,
+			wantErr: "client should not be nil",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := RegisterObjectStoreClient(tt.scheme, tt.client)
+			defer UnregisterObjectStoreClient(tt.scheme)
+			if (err != nil) != (tt.wantErr != "") {
+				t.Fatalf("TestRegisterObjectStoreClient() unexpected error: %v, wantErr: %v", err, tt.wantErr)
+			}
+			if err != nil {
+				assert.Regexp(t, tt.wantErr, err.Error(), "Error message should match")
+			}
+		})
+	}
+}
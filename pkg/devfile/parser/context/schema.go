@@ -16,7 +16,9 @@
 package parser
 
 import (
+	"encoding/json"
 	"fmt"
+	"sort"
 
 	"github.com/devfile/library/v2/pkg/devfile/parser/data"
 	"github.com/pkg/errors"
@@ -27,6 +29,15 @@ import (
 // SetDevfileJSONSchema returns the JSON schema for the given devfile apiVersion
 func (d *DevfileCtx) SetDevfileJSONSchema() error {
 
+	// Prefer the schema registered for the pre-release schemaVersion, if any
+	if d.usePreReleaseSchema && d.schemaVersion != "" && d.schemaVersion != d.apiVersion {
+		if jsonSchema, err := data.GetDevfileJSONSchema(d.schemaVersion); err == nil {
+			d.jsonSchema = jsonSchema
+			return nil
+		}
+		klog.V(4).Infof("no schema registered for pre-release schemaVersion '%s', using the schema of '%s'", d.schemaVersion, d.apiVersion)
+	}
+
 	// Check if json schema is present for the given apiVersion
 	jsonSchema, err := data.GetDevfileJSONSchema(d.apiVersion)
 	if err != nil {
@@ -36,17 +47,102 @@ func (d *DevfileCtx) SetDevfileJSONSchema() error {
 	return nil
 }
 
+// removeUnknownTopLevelFields removes the top-level fields of the devfile content missing from the properties of its JSON schema,
+// and records a warning for each of them
+func (d *DevfileCtx) removeUnknownTopLevelFields() error {
+	var schema struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+	}
+	if err := json.Unmarshal([]byte(d.jsonSchema), &schema); err != nil {
+		return errors.Wrapf(err, "failed to decode the devfile JSON schema")
+	}
+	var content map[string]json.RawMessage
+	if err := json.Unmarshal(d.rawContent, &content); err != nil {
+		// the devfile is reported by the schema validation
+		return nil
+	}
+
+	var unknownFields []string
+	for field := range content {
+		if _, ok := schema.Properties[field]; !ok {
+			unknownFields = append(unknownFields, field)
+		}
+	}
+	if len(unknownFields) == 0 {
+		return nil
+	}
+	sort.Strings(unknownFields)
+
+	d.unknownFieldWarnings = nil
+	for _, field := range unknownFields {
+		delete(content, field)
+		warning := fmt.Sprintf("ignoring the unknown top-level field %s of the devfile", field)
+		klog.Warning(warning)
+		d.unknownFieldWarnings = append(d.unknownFieldWarnings, warning)
+	}
+	rawContent, err := json.Marshal(content)
+	if err != nil {
+		return errors.Wrapf(err, "failed to encode the devfile without its unknown fields")
+	}
+	d.rawContent = rawContent
+	return nil
+}
+
+// ValidationResult is the result of validating a devfile against its JSON schema
+type ValidationResult struct {
+	// Valid is true if the devfile conforms to the JSON schema
+	Valid bool
+	// Errors are the schema violations of the devfile
+	Errors []ValidationError
+}
+
+// ValidationError is a schema violation of a devfile
+type ValidationError struct {
+	// Field is the path of the field in violation, e.g. components.0.name
+	Field string
+	// Type is the type of the violation, e.g. required or invalid_type
+	Type string
+	// Description describes the violation
+	Description string
+}
+
+// ValidateDevfileSchemaResult validates the JSON schema of the provided devfile content and returns the structured
+// result of the validation. An error is returned only if the devfile could not be validated.
+func ValidateDevfileSchemaResult(data []byte) (*ValidationResult, error) {
+	d, err := NewByteContentDevfileCtx(data)
+	if err != nil {
+		return nil, err
+	}
+	if err = d.populateDevfile(); err != nil {
+		return nil, err
+	}
+
+	result, err := d.validateSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	validationResult := &ValidationResult{Valid: result.Valid()}
+	for _, desc := range result.Errors() {
+		validationResult.Errors = append(validationResult.Errors, ValidationError{
+			Field:       desc.Field(),
+			Type:        desc.Type(),
+			Description: desc.Description(),
+		})
+	}
+	return validationResult, nil
+}
+
 // ValidateDevfileSchema validate JSON schema of the provided devfile
 func (d *DevfileCtx) ValidateDevfileSchema() error {
-	var (
-		schemaLoader   = gojsonschema.NewStringLoader(d.jsonSchema)
-		documentLoader = gojsonschema.NewStringLoader(string(d.rawContent))
-	)
+	if d.jsonSchema == "" && d.schemaWarning != "" {
+		klog.V(4).Info(d.schemaWarning)
+		return nil
+	}
 
-	// Validate devfile with JSON schema
-	result, err := gojsonschema.Validate(schemaLoader, documentLoader)
+	result, err := d.validateSchema()
 	if err != nil {
-		return errors.Wrapf(err, "failed to validate devfile schema")
+		return err
 	}
 
 	if !result.Valid() {
@@ -61,3 +157,18 @@ func (d *DevfileCtx) ValidateDevfileSchema() error {
 	klog.V(4).Info("validated devfile schema")
 	return nil
 }
+
+// validateSchema validates the devfile content with the JSON schema of the devfile context
+func (d *DevfileCtx) validateSchema() (*gojsonschema.Result, error) {
+	var (
+		schemaLoader   = gojsonschema.NewStringLoader(d.jsonSchema)
+		documentLoader = gojsonschema.NewStringLoader(string(d.rawContent))
+	)
+
+	// Validate devfile with JSON schema
+	result, err := gojsonschema.Validate(schemaLoader, documentLoader)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to validate devfile schema")
+	}
+	return result, nil
+}
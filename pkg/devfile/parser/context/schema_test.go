@@ -19,6 +19,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"testing"
 
+	"github.com/devfile/library/v2/pkg/devfile/parser/data"
 	v200 "github.com/devfile/library/v2/pkg/devfile/parser/data/v2/2.0.0"
 )
 
@@ -172,3 +173,159 @@ func TestValidateDevfileSchema(t *testing.T) {
 func validJsonRawContent200() []byte {
 	return []byte(validJson200)
 }
+
+func TestValidateDevfileSchemaResult(t *testing.T) {
+
+	t.Run("valid devfile", func(t *testing.T) {
+		result, err := ValidateDevfileSchemaResult(validJsonRawContent200())
+		if err != nil {
+			t.Fatalf("TestValidateDevfileSchemaResult() unexpected error: '%v'", err)
+		}
+		if !result.Valid || len(result.Errors) != 0 {
+			t.Errorf("TestValidateDevfileSchemaResult() expected a valid result, got: %+v", result)
+		}
+	})
+
+	t.Run("invalid devfile", func(t *testing.T) {
+		invalidDevfile := []byte("schemaVersion: 2.2.0\nmetadata:\n  name: 1\ncomponents:\n- container:\n    image: foo\n")
+		result, err := ValidateDevfileSchemaResult(invalidDevfile)
+		if err != nil {
+			t.Fatalf("TestValidateDevfileSchemaResult() unexpected error: '%v'", err)
+		}
+		if result.Valid {
+			t.Errorf("TestValidateDevfileSchemaResult() expected an invalid result")
+		}
+		wantErrors := []ValidationError{
+			{
+				Field:       "components.0",
+				Type:        "required",
+				Description: "name is required",
+			},
+			{
+				Field:       "metadata.name",
+				Type:        "invalid_type",
+				Description: "Invalid type. Expected: string, given: integer",
+			},
+		}
+		assert.ElementsMatch(t, wantErrors, result.Errors, "TestValidateDevfileSchemaResult(): Errors should match")
+	})
+
+	t.Run("devfile without schemaVersion", func(t *testing.T) {
+		_, err := ValidateDevfileSchemaResult([]byte("{}"))
+		if err == nil {
+			t.Errorf("TestValidateDevfileSchemaResult() expected error, didn't get one")
+		} else {
+			assert.Regexp(t, "schemaVersion not present in devfile", err.Error(), "TestValidateDevfileSchemaResult(): Error message should match")
+		}
+	})
+}
+
+func TestValidateDevfileSchema_PreReleaseSchema(t *testing.T) {
+	// the alpha schema requires a field unknown to the 2.2.0 schema
+	alphaSchema := `{
+		"type": "object",
+		"required": ["schemaVersion", "alphaFeature"],
+		"properties": {
+			"schemaVersion": {"type": "string"},
+			"alphaFeature": {"type": "string"}
+		}
+	}`
+	err := data.RegisterPreReleaseJSONSchema("2.2.0-alpha", alphaSchema)
+	if err != nil {
+		t.Fatalf("TestValidateDevfileSchema_PreReleaseSchema(): unexpected error: %v", err)
+	}
+
+	alphaFeatureErr := "alphaFeature is required"
+
+	tests := []struct {
+		name                string
+		schemaVersion       string
+		usePreReleaseSchema bool
+		wantErr             *string
+	}{
+		{
+			name:                "should validate against the registered pre-release schema",
+			schemaVersion:       "2.2.0-alpha",
+			usePreReleaseSchema: true,
+			wantErr:             &alphaFeatureErr,
+		},
+		{
+			name:          "should validate against the stable schema by default",
+			schemaVersion: "2.2.0-alpha",
+		},
+		{
+			name:                "should fall back to the stable schema if no pre-release schema is registered",
+			schemaVersion:       "2.2.0-beta",
+			usePreReleaseSchema: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := NewByteContentDevfileCtx([]byte("schemaVersion: " + tt.schemaVersion + "\nmetadata:\n  name: pre-release\n"))
+			if err != nil {
+				t.Fatalf("TestValidateDevfileSchema_PreReleaseSchema(): unexpected error: %v", err)
+			}
+			d.SetUsePreReleaseSchema(tt.usePreReleaseSchema)
+			err = d.populateDevfile()
+			if err != nil {
+				t.Fatalf("TestValidateDevfileSchema_PreReleaseSchema(): unexpected error: %v", err)
+			}
+			if d.GetApiVersion() != "2.2.0" {
+				t.Errorf("TestValidateDevfileSchema_PreReleaseSchema(): got apiVersion: %v, want: 2.2.0", d.GetApiVersion())
+			}
+
+			err = d.ValidateDevfileSchema()
+			if (err != nil) != (tt.wantErr != nil) {
+				t.Errorf("TestValidateDevfileSchema_PreReleaseSchema(): unexpected error: %v, wantErr: %v", err, tt.wantErr)
+			} else if err != nil {
+				assert.Regexp(t, *tt.wantErr, err.Error(), "TestValidateDevfileSchema_PreReleaseSchema(): Error message should match")
+			}
+		})
+	}
+}
+
+func TestValidateDevfileSchema_LenientUnknownFields(t *testing.T) {
+	const content = "schemaVersion: 2.2.0\nmetadata:\n  name: nodejs\nlegacyField: true\nannotations: {}\n"
+	unknownFieldErr := "Additional property legacyField is not allowed"
+
+	tests := []struct {
+		name         string
+		lenient      bool
+		wantWarnings []string
+		wantErr      *string
+	}{
+		{
+			name:    "should ignore the unknown top-level fields with a warning in lenient mode",
+			lenient: true,
+			wantWarnings: []string{
+				"ignoring the unknown top-level field annotations of the devfile",
+				"ignoring the unknown top-level field legacyField of the devfile",
+			},
+		},
+		{
+			name:    "should fail on the unknown top-level fields by default",
+			wantErr: &unknownFieldErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := NewByteContentDevfileCtx([]byte(content))
+			if err != nil {
+				t.Fatalf("TestValidateDevfileSchema_LenientUnknownFields(): unexpected error: %v", err)
+			}
+			d.SetLenientUnknownFields(tt.lenient)
+			err = d.populateDevfile()
+			if err != nil {
+				t.Fatalf("TestValidateDevfileSchema_LenientUnknownFields(): unexpected error: %v", err)
+			}
+			assert.Equal(t, tt.wantWarnings, d.GetUnknownFieldWarnings(), "TestValidateDevfileSchema_LenientUnknownFields(): warnings should match")
+
+			err = d.ValidateDevfileSchema()
+			if (err != nil) != (tt.wantErr != nil) {
+				t.Errorf("TestValidateDevfileSchema_LenientUnknownFields(): unexpected error: %v, wantErr: %v", err, tt.wantErr)
+			} else if err != nil {
+				assert.Regexp(t, *tt.wantErr, err.Error(), "TestValidateDevfileSchema_LenientUnknownFields(): Error message should match")
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+//
+// Copyright 2023 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/devfile/library/v2/pkg/devfile/parser/data/v2/common"
+)
+
+// DevfileDiff is the structured diff between two devfiles, as returned by DiffDevfiles
+type DevfileDiff struct {
+	// Components lists the components of the diff, by name
+	Components ObjectsDiff
+	// Commands lists the commands of the diff, by id
+	Commands ObjectsDiff
+	// Projects lists the projects of the diff, by name
+	Projects ObjectsDiff
+	// Metadata lists the changed metadata fields, sorted by field
+	Metadata []MetadataChange
+}
+
+// ObjectsDiff lists the keys of the added, removed and modified objects of a devfile list, e.g. of the components, sorted
+type ObjectsDiff struct {
+	Added    []string
+	Removed  []string
+	Modified []string
+}
+
+// MetadataChange is a changed field of the devfile metadata
+type MetadataChange struct {
+	// Field is the JSON name of the field, e.g. version
+	Field string
+	// Old and New are the JSON values of the field, nil if the field is unset
+	Old interface{}
+	New interface{}
+}
+
+// IsEmpty returns true if the devfiles of the diff have the same components, commands, projects and metadata
+func (d DevfileDiff) IsEmpty() bool {
+	return d.Components.IsEmpty() && d.Commands.IsEmpty() && d.Projects.IsEmpty() && len(d.Metadata) == 0
+}
+
+// IsEmpty returns true if no object is added, removed or modified
+func (d ObjectsDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Modified) == 0
+}
+
+// DiffDevfiles returns the diff from the devfile a to the devfile b, e.g. to review the changes of a devfile: the added,
+// removed and modified components, commands and projects, matched by name or id, and the changed metadata fields.
+// The order of the objects is not part of the diff. The devfiles are not modified.
+func DiffDevfiles(a, b DevfileData) (DevfileDiff, error) {
+	var diff DevfileDiff
+
+	aComponents, err := a.GetComponents(common.DevfileOptions{})
+	if err != nil {
+		return diff, err
+	}
+	bComponents, err := b.GetComponents(common.DevfileOptions{})
+	if err != nil {
+		return diff, err
+	}
+	aObjects, bObjects := make(map[string]interface{}), make(map[string]interface{})
+	for _, component := range aComponents {
+		aObjects[component.Name] = component
+	}
+	for _, component := range bComponents {
+		bObjects[component.Name] = component
+	}
+	diff.Components = diffObjects(aObjects, bObjects)
+
+	aCommands, err := a.GetCommands(common.DevfileOptions{})
+	if err != nil {
+		return diff, err
+	}
+	bCommands, err := b.GetCommands(common.DevfileOptions{})
+	if err != nil {
+		return diff, err
+	}
+	aObjects, bObjects = make(map[string]interface{}), make(map[string]interface{})
+	for _, command := range aCommands {
+		aObjects[command.Id] = command
+	}
+	for _, command := range bCommands {
+		bObjects[command.Id] = command
+	}
+	diff.Commands = diffObjects(aObjects, bObjects)
+
+	aProjects, err := a.GetProjects(common.DevfileOptions{})
+	if err != nil {
+		return diff, err
+	}
+	bProjects, err := b.GetProjects(common.DevfileOptions{})
+	if err != nil {
+		return diff, err
+	}
+	aObjects, bObjects = make(map[string]interface{}), make(map[string]interface{})
+	for _, project := range aProjects {
+		aObjects[project.Name] = project
+	}
+	for _, project := range bProjects {
+		bObjects[project.Name] = project
+	}
+	diff.Projects = diffObjects(aObjects, bObjects)
+
+	diff.Metadata, err = diffMetadata(a, b)
+	if err != nil {
+		return diff, err
+	}
+	return diff, nil
+}
+
+// diffObjects returns the diff from the objects a to the objects b, keyed by their name or id
+func diffObjects(a, b map[string]interface{}) ObjectsDiff {
+	var diff ObjectsDiff
+	for key, aObject := range a {
+		bObject, ok := b[key]
+		if !ok {
+			diff.Removed = append(diff.Removed, key)
+		} else if !reflect.DeepEqual(aObject, bObject) {
+			diff.Modified = append(diff.Modified, key)
+		}
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			diff.Added = append(diff.Added, key)
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Modified)
+	return diff
+}
+
+// diffMetadata returns the changed metadata fields from the devfile a to the devfile b, compared by their JSON values
+func diffMetadata(a, b DevfileData) ([]MetadataChange, error) {
+	aFields, err := metadataFields(a)
+	if err != nil {
+		return nil, err
+	}
+	bFields, err := metadataFields(b)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]bool)
+	for field := range aFields {
+		fields[field] = true
+	}
+	for field := range bFields {
+		fields[field] = true
+	}
+	var changes []MetadataChange
+	for field := range fields {
+		if !reflect.DeepEqual(aFields[field], bFields[field]) {
+			changes = append(changes, MetadataChange{Field: field, Old: aFields[field], New: bFields[field]})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Field < changes[j].Field
+	})
+	return changes, nil
+}
+
+// metadataFields returns the JSON values of the metadata fields of the devfile, keyed by their JSON name
+func metadataFields(data DevfileData) (map[string]interface{}, error) {
+	content, err := json.Marshal(data.GetMetadata())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the devfile metadata: %v", err)
+	}
+	var fields map[string]interface{}
+	if err = json.Unmarshal(content, &fields); err != nil {
+		return nil, fmt.Errorf("failed to decode the devfile metadata: %v", err)
+	}
+	return fields, nil
+}
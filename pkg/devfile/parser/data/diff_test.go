@@ -0,0 +1,151 @@
+//
+// Copyright 2023 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package data
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	v2 "github.com/devfile/library/v2/pkg/devfile/parser/data/v2"
+	"sigs.k8s.io/yaml"
+)
+
+func TestDiffDevfiles(t *testing.T) {
+	const devfileContent = `schemaVersion: 2.2.0
+metadata:
+  name: nodejs
+  version: 1.0.0
+components:
+- name: runtime
+  container:
+    image: nodejs
+commands:
+- id: run
+  exec:
+    component: runtime
+    commandLine: npm start
+projects:
+- name: nodejs-starter
+  git:
+    remotes:
+      origin: https://github.com/odo-devfiles/nodejs-ex.git
+`
+	const changedContent = `schemaVersion: 2.2.0
+metadata:
+  name: nodejs
+  version: 1.0.0
+components:
+- name: runtime
+  container:
+    image: nodejs
+- name: tools
+  container:
+    image: tools
+commands:
+- id: run
+  exec:
+    component: runtime
+    commandLine: npm run dev
+projects:
+- name: nodejs-starter
+  git:
+    remotes:
+      origin: https://github.com/odo-devfiles/nodejs-ex.git
+`
+	const reorderedContent = `schemaVersion: 2.2.0
+metadata:
+  name: nodejs
+  version: 1.0.0
+commands:
+- id: run
+  exec:
+    commandLine: npm start
+    component: runtime
+components:
+- container:
+    image: nodejs
+  name: runtime
+projects:
+- name: nodejs-starter
+  git:
+    remotes:
+      origin: https://github.com/odo-devfiles/nodejs-ex.git
+`
+
+	parse := func(t *testing.T, content string) *v2.DevfileV2 {
+		t.Helper()
+		devfileData := &v2.DevfileV2{}
+		if err := yaml.Unmarshal([]byte(content), devfileData); err != nil {
+			t.Fatalf("TestDiffDevfiles() unexpected error: %v", err)
+		}
+		return devfileData
+	}
+
+	tests := []struct {
+		name     string
+		content  string
+		wantDiff DevfileDiff
+	}{
+		{
+			name:    "should have an empty diff for the same devfile",
+			content: devfileContent,
+		},
+		{
+			name:    "should have an empty diff for a devfile of reordered keys",
+			content: reorderedContent,
+		},
+		{
+			name:    "should diff an added component and a modified command",
+			content: changedContent,
+			wantDiff: DevfileDiff{
+				Components: ObjectsDiff{Added: []string{"tools"}},
+				Commands:   ObjectsDiff{Modified: []string{"run"}},
+			},
+		},
+		{
+			name:    "should diff a removed project",
+			content: devfileContent[:strings.Index(devfileContent, "projects:")],
+			wantDiff: DevfileDiff{
+				Projects: ObjectsDiff{Removed: []string{"nodejs-starter"}},
+			},
+		},
+		{
+			name:    "should diff the changed metadata fields",
+			content: strings.Replace(devfileContent, "  version: 1.0.0\n", "  version: 1.1.0\n  displayName: Node.js\n", 1),
+			wantDiff: DevfileDiff{
+				Metadata: []MetadataChange{
+					{Field: "displayName", New: "Node.js"},
+					{Field: "version", Old: "1.0.0", New: "1.1.0"},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DiffDevfiles(parse(t, devfileContent), parse(t, tt.content))
+			if err != nil {
+				t.Fatalf("TestDiffDevfiles() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.wantDiff) {
+				t.Errorf("TestDiffDevfiles() got diff: %+v, want: %+v", got, tt.wantDiff)
+			}
+			if wantEmpty := reflect.DeepEqual(tt.wantDiff, DevfileDiff{}); got.IsEmpty() != wantEmpty {
+				t.Errorf("TestDiffDevfiles() got empty diff: %v, want empty: %v", got.IsEmpty(), wantEmpty)
+			}
+		})
+	}
+}
@@ -16,13 +16,25 @@
 package data
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"reflect"
+	"regexp"
+	"sort"
 	"strings"
+	"sync"
 
+	v1 "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	"github.com/devfile/library/v2/pkg/devfile/parser/data/v2/common"
 	"k8s.io/klog"
 )
 
+// devfileJSONSchemaLock guards devfileApiVersionToJSONSchema against concurrent registrations
+var devfileJSONSchemaLock sync.RWMutex
+
 // String converts supportedApiVersion type to string type
 func (s supportedApiVersion) String() string {
 	return string(s)
@@ -44,6 +56,9 @@ func NewDevfileData(version string) (obj DevfileData, err error) {
 // GetDevfileJSONSchema returns the devfile JSON schema of the supported apiVersion
 func GetDevfileJSONSchema(version string) (string, error) {
 
+	devfileJSONSchemaLock.RLock()
+	defer devfileJSONSchemaLock.RUnlock()
+
 	// Fetch json schema from the devfileApiVersionToJSONSchema map
 	schema, ok := devfileApiVersionToJSONSchema[supportedApiVersion(version)]
 	if !ok {
@@ -59,7 +74,262 @@ func GetDevfileJSONSchema(version string) (string, error) {
 	return schema, nil
 }
 
+// RegisterPreReleaseJSONSchema registers the devfile JSON schema of a pre-release schemaVersion, e.g. 2.2.0-alpha, of a
+// supported devfile API version. Devfiles of the pre-release schemaVersion are validated against the registered schema
+// when the parser preserves pre-release schemaVersions. Registering a schemaVersion again replaces its schema.
+func RegisterPreReleaseJSONSchema(version string, jsonSchema string) error {
+	stableVersion, preRelease, found := strings.Cut(version, "-")
+	if !found || preRelease == "" {
+		return fmt.Errorf("failed to register devfile schema, %q is not a pre-release schemaVersion", version)
+	}
+	if !IsApiVersionSupported(stableVersion) {
+		return fmt.Errorf("failed to register devfile schema for %q, apiVersion %q is not supported", version, stableVersion)
+	}
+
+	devfileJSONSchemaLock.Lock()
+	defer devfileJSONSchemaLock.Unlock()
+	devfileApiVersionToJSONSchema[supportedApiVersion(version)] = jsonSchema
+	return nil
+}
+
 // IsApiVersionSupported returns true if the API version is supported
 func IsApiVersionSupported(version string) bool {
 	return apiVersionToDevfileStruct[supportedApiVersion(version)] != nil
 }
+
+// GetCommandsByGroup returns the commands of the devfile in the group of the given kind, e.g. build or run, including composite commands.
+// The default command of the group, if any, is returned first, followed by the other commands in devfile order.
+func GetCommandsByGroup(data DevfileData, kind v1.CommandGroupKind) ([]v1.Command, error) {
+	commands, err := data.GetCommands(common.DevfileOptions{
+		CommandOptions: common.CommandOptions{
+			CommandGroupKind: kind,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(commands, func(i, j int) bool {
+		return isDefaultCommand(commands[i]) && !isDefaultCommand(commands[j])
+	})
+	return commands, nil
+}
+
+// isDefaultCommand checks if the command is the default command of its group
+func isDefaultCommand(command v1.Command) bool {
+	group := common.GetGroup(command)
+	return group != nil && group.IsDefault != nil && *group.IsDefault
+}
+
+// GetImageReferences returns the images referenced by the devfile, i.e. the images of its container components and the
+// names of the images built by its image components, in devfile order and without duplicates. The base images of the
+// Dockerfiles of image components are not returned, as they are only known from the content of the Dockerfiles.
+func GetImageReferences(data DevfileData) ([]string, error) {
+	components, err := data.GetComponents(common.DevfileOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var images []string
+	found := make(map[string]bool)
+	for _, component := range components {
+		var image string
+		switch {
+		case common.IsContainer(component):
+			image = component.Container.Image
+		case common.IsImage(component):
+			image = component.Image.ImageName
+		}
+		if image != "" && !found[image] {
+			found[image] = true
+			images = append(images, image)
+		}
+	}
+	return images, nil
+}
+
+// metadataVersionPattern is the pattern of the devfile metadata.version, a semantic version, as in the devfile JSON schema
+var metadataVersionPattern = regexp.MustCompile(`^([0-9]+)\.([0-9]+)\.([0-9]+)(\-[0-9a-z-]+(\.[0-9a-z-]+)*)?(\+[0-9A-Za-z-]+(\.[0-9A-Za-z-]+)*)?$`)
+
+// GetNameAndVersion returns the metadata.name and metadata.version of the devfile, e.g. to publish the devfile to a registry.
+// It returns an error if either is missing, or if the version is not a semantic version, e.g. 1.0.0 or 2.1.0-alpha.
+func GetNameAndVersion(data DevfileData) (name string, version string, err error) {
+	metadata := data.GetMetadata()
+	if metadata.Name == "" {
+		return "", "", fmt.Errorf("the devfile metadata.name is missing")
+	}
+	if metadata.Version == "" {
+		return "", "", fmt.Errorf("the devfile metadata.version of %s is missing", metadata.Name)
+	}
+	if !metadataVersionPattern.MatchString(metadata.Version) {
+		return "", "", fmt.Errorf("the devfile metadata.version %q of %s is not a semantic version, e.g. 1.0.0", metadata.Version, metadata.Name)
+	}
+	return metadata.Name, metadata.Version, nil
+}
+
+// Fingerprint returns a stable hash of the devfile data, the hex encoded SHA-256 of its canonical JSON encoding, e.g. as the
+// key of a content-addressed cache. The canonical encoding sorts the keys of every object, including the free-form
+// attributes, so the devfiles of the same content have the same fingerprint whatever the order of their keys and their
+// formatting. The order of the lists, e.g. of the components, is significant and changes the fingerprint.
+func Fingerprint(data DevfileData) (string, error) {
+	content, err := json.Marshal(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode the devfile for its fingerprint: %v", err)
+	}
+
+	// decoding into generic values then encoding again sorts the keys of the raw attributes, which are encoded as is
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	var canonical interface{}
+	if err = decoder.Decode(&canonical); err != nil {
+		return "", fmt.Errorf("failed to decode the devfile for its fingerprint: %v", err)
+	}
+	content, err = json.Marshal(canonical)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode the devfile for its fingerprint: %v", err)
+	}
+
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// FilterDevfile returns a copy of the devfile data keeping only the components, commands, projects and starter projects
+// matching the options, as returned by their getters. The copy is kept consistent with the dropped objects: commands
+// referencing a dropped component or command are dropped too, along with the events referencing a dropped command and the
+// volume mounts of a dropped volume component. The devfile data is not modified.
+func FilterDevfile(data DevfileData, options common.DevfileOptions) (DevfileData, error) {
+	components, err := data.GetComponents(options)
+	if err != nil {
+		return nil, err
+	}
+	commands, err := data.GetCommands(options)
+	if err != nil {
+		return nil, err
+	}
+	projects, err := data.GetProjects(options)
+	if err != nil {
+		return nil, err
+	}
+	starterProjects, err := data.GetStarterProjects(options)
+	if err != nil {
+		return nil, err
+	}
+
+	// every supported apiVersion has the same devfile struct
+	filtered, err := NewDevfileData(string(APIVersionAlpha2))
+	if err != nil {
+		return nil, err
+	}
+	filtered.SetSchemaVersion(data.GetSchemaVersion())
+	filtered.SetMetadata(data.GetMetadata())
+
+	spec := data.GetDevfileWorkspaceSpec().DeepCopy()
+	content := &spec.DevWorkspaceTemplateSpecContent
+	content.Components = nil
+	for i := range components {
+		content.Components = append(content.Components, *components[i].DeepCopy())
+	}
+	content.Commands = nil
+	for i := range commands {
+		content.Commands = append(content.Commands, *commands[i].DeepCopy())
+	}
+	content.Projects = nil
+	for i := range projects {
+		content.Projects = append(content.Projects, *projects[i].DeepCopy())
+	}
+	content.StarterProjects = nil
+	for i := range starterProjects {
+		content.StarterProjects = append(content.StarterProjects, *starterProjects[i].DeepCopy())
+	}
+	removeDanglingReferences(content)
+
+	filtered.SetDevfileWorkspaceSpec(*spec)
+	return filtered, nil
+}
+
+// removeDanglingReferences removes the commands, events and volume mounts of the content referencing components or
+// commands missing from the content
+func removeDanglingReferences(content *v1.DevWorkspaceTemplateSpecContent) {
+	componentNames := make(map[string]bool)
+	volumeNames := make(map[string]bool)
+	for _, component := range content.Components {
+		componentNames[component.Name] = true
+		if component.Volume != nil {
+			volumeNames[component.Name] = true
+		}
+	}
+
+	for i := range content.Components {
+		container := content.Components[i].Container
+		if container == nil {
+			continue
+		}
+		var volumeMounts []v1.VolumeMount
+		for _, volumeMount := range container.VolumeMounts {
+			if volumeNames[volumeMount.Name] {
+				volumeMounts = append(volumeMounts, volumeMount)
+			}
+		}
+		container.VolumeMounts = volumeMounts
+	}
+
+	// dropping a command can leave a composite command referencing it, so repeat until no command is dropped
+	for dropped := true; dropped; {
+		dropped = false
+		commandIds := make(map[string]bool)
+		for _, command := range content.Commands {
+			commandIds[strings.ToLower(command.Id)] = true
+		}
+
+		var commands []v1.Command
+		for _, command := range content.Commands {
+			if !commandReferencesExist(command, componentNames, commandIds) {
+				klog.V(4).Infof("dropping command %s referencing a filtered out component or command", command.Id)
+				dropped = true
+				continue
+			}
+			commands = append(commands, command)
+		}
+		content.Commands = commands
+	}
+
+	if content.Events != nil {
+		commandIds := make(map[string]bool)
+		for _, command := range content.Commands {
+			commandIds[strings.ToLower(command.Id)] = true
+		}
+		events := &content.Events.DevWorkspaceEvents
+		events.PreStart = existingCommandIds(events.PreStart, commandIds)
+		events.PostStart = existingCommandIds(events.PostStart, commandIds)
+		events.PreStop = existingCommandIds(events.PreStop, commandIds)
+		events.PostStop = existingCommandIds(events.PostStop, commandIds)
+	}
+}
+
+// commandReferencesExist checks if the component or sub-commands referenced by the command exist
+func commandReferencesExist(command v1.Command, componentNames map[string]bool, commandIds map[string]bool) bool {
+	switch {
+	case command.Exec != nil:
+		return componentNames[command.Exec.Component]
+	case command.Apply != nil:
+		return componentNames[command.Apply.Component]
+	case command.Composite != nil:
+		for _, id := range command.Composite.Commands {
+			if !commandIds[strings.ToLower(id)] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// existingCommandIds returns the ids of the existing commands
+func existingCommandIds(ids []string, commandIds map[string]bool) []string {
+	var existing []string
+	for _, id := range ids {
+		if commandIds[strings.ToLower(id)] {
+			existing = append(existing, id)
+		}
+	}
+	return existing
+}
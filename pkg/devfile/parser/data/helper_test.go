@@ -20,8 +20,13 @@ import (
 	"strings"
 	"testing"
 
+	v1 "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	"github.com/devfile/api/v2/pkg/attributes"
+	devfilepkg "github.com/devfile/api/v2/pkg/devfile"
 	v2 "github.com/devfile/library/v2/pkg/devfile/parser/data/v2"
 	v200 "github.com/devfile/library/v2/pkg/devfile/parser/data/v2/2.0.0"
+	"github.com/devfile/library/v2/pkg/devfile/parser/data/v2/common"
+	"sigs.k8s.io/yaml"
 )
 
 func TestNewDevfileData(t *testing.T) {
@@ -120,3 +125,593 @@ func TestIsApiVersionSupported(t *testing.T) {
 		}
 	})
 }
+
+func TestRegisterPreReleaseJSONSchema(t *testing.T) {
+
+	t.Run("pre-release of a supported apiVersion", func(t *testing.T) {
+
+		var (
+			version = "2.1.0-rc1"
+			want    = `{"type": "object"}`
+			err     = RegisterPreReleaseJSONSchema(version, want)
+		)
+		defer delete(devfileApiVersionToJSONSchema, supportedApiVersion(version))
+
+		if err != nil {
+			t.Errorf("did not expect an error '%v'", err)
+		}
+
+		got, err := GetDevfileJSONSchema(version)
+		if err != nil {
+			t.Errorf("did not expect an error '%v'", err)
+		}
+		if got != want {
+			t.Errorf("want: '%s', got: '%s'", want, got)
+		}
+	})
+
+	t.Run("stable apiVersion", func(t *testing.T) {
+
+		var (
+			version = string(APISchemaVersion210)
+			err     = RegisterPreReleaseJSONSchema(version, `{"type": "object"}`)
+		)
+
+		if err == nil {
+			t.Errorf("expected an error, didn't get one")
+		}
+		if got, _ := GetDevfileJSONSchema(version); got == `{"type": "object"}` {
+			t.Errorf("the schema of a stable apiVersion should not be replaced")
+		}
+	})
+
+	t.Run("pre-release of an unsupported apiVersion", func(t *testing.T) {
+
+		var (
+			version = "9.9.9-alpha"
+			err     = RegisterPreReleaseJSONSchema(version, `{"type": "object"}`)
+		)
+
+		if err == nil {
+			t.Errorf("expected an error, didn't get one")
+		}
+	})
+}
+
+func TestGetCommandsByGroup(t *testing.T) {
+	isTrue := true
+	isFalse := false
+	execCommand := func(id string, kind v1.CommandGroupKind, isDefault *bool) v1.Command {
+		return v1.Command{
+			Id: id,
+			CommandUnion: v1.CommandUnion{
+				Exec: &v1.ExecCommand{
+					LabeledCommand: v1.LabeledCommand{
+						BaseCommand: v1.BaseCommand{
+							Group: &v1.CommandGroup{Kind: kind, IsDefault: isDefault},
+						},
+					},
+					CommandLine: "echo " + id,
+					Component:   "runtime",
+				},
+			},
+		}
+	}
+
+	commands := []v1.Command{
+		execCommand("install", v1.BuildCommandGroupKind, nil),
+		execCommand("run", v1.RunCommandGroupKind, &isFalse),
+		execCommand("compile", v1.BuildCommandGroupKind, &isTrue),
+		{
+			Id: "build-all",
+			CommandUnion: v1.CommandUnion{
+				Composite: &v1.CompositeCommand{
+					LabeledCommand: v1.LabeledCommand{
+						BaseCommand: v1.BaseCommand{
+							Group: &v1.CommandGroup{Kind: v1.BuildCommandGroupKind},
+						},
+					},
+					Commands: []string{"install", "compile"},
+				},
+			},
+		},
+		execCommand("start", v1.RunCommandGroupKind, &isTrue),
+		{
+			Id: "no-group",
+			CommandUnion: v1.CommandUnion{
+				Exec: &v1.ExecCommand{CommandLine: "echo no-group", Component: "runtime"},
+			},
+		},
+	}
+	devfileData := &v2.DevfileV2{
+		Devfile: v1.Devfile{
+			DevWorkspaceTemplateSpec: v1.DevWorkspaceTemplateSpec{
+				DevWorkspaceTemplateSpecContent: v1.DevWorkspaceTemplateSpecContent{
+					Commands: commands,
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		kind    v1.CommandGroupKind
+		wantIds []string
+	}{
+		{
+			name:    "build commands, default first",
+			kind:    v1.BuildCommandGroupKind,
+			wantIds: []string{"compile", "install", "build-all"},
+		},
+		{
+			name:    "run commands, default first",
+			kind:    v1.RunCommandGroupKind,
+			wantIds: []string{"start", "run"},
+		},
+		{
+			name: "no commands in group",
+			kind: v1.DebugCommandGroupKind,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetCommandsByGroup(devfileData, tt.kind)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var gotIds []string
+			for _, command := range got {
+				gotIds = append(gotIds, command.Id)
+			}
+			if !reflect.DeepEqual(gotIds, tt.wantIds) {
+				t.Errorf("got: %v, want: %v", gotIds, tt.wantIds)
+			}
+		})
+	}
+}
+
+func TestFilterDevfile(t *testing.T) {
+	odoAttributes := attributes.Attributes{}.PutString("tool", "odo")
+	gitSource := v1.ProjectSource{
+		Git: &v1.GitProjectSource{
+			GitLikeProjectSource: v1.GitLikeProjectSource{Remotes: map[string]string{"origin": "https://github.com/devfile/library"}},
+		},
+	}
+	devfileData := &v2.DevfileV2{
+		Devfile: v1.Devfile{
+			DevfileHeader: devfilepkg.DevfileHeader{
+				SchemaVersion: "2.2.0",
+				Metadata:      devfilepkg.DevfileMetadata{Name: "nodejs"},
+			},
+			DevWorkspaceTemplateSpec: v1.DevWorkspaceTemplateSpec{
+				DevWorkspaceTemplateSpecContent: v1.DevWorkspaceTemplateSpecContent{
+					Components: []v1.Component{
+						{
+							Name:       "runtime",
+							Attributes: odoAttributes,
+							ComponentUnion: v1.ComponentUnion{
+								Container: &v1.ContainerComponent{
+									Container: v1.Container{
+										Image:        "node:18",
+										VolumeMounts: []v1.VolumeMount{{Name: "cache", Path: "/cache"}},
+									},
+								},
+							},
+						},
+						{
+							Name:           "cache",
+							ComponentUnion: v1.ComponentUnion{Volume: &v1.VolumeComponent{}},
+						},
+						{
+							Name: "deploy",
+							ComponentUnion: v1.ComponentUnion{
+								Kubernetes: &v1.KubernetesComponent{
+									K8sLikeComponent: v1.K8sLikeComponent{
+										K8sLikeComponentLocation: v1.K8sLikeComponentLocation{Uri: "deploy.yaml"},
+									},
+								},
+							},
+						},
+					},
+					Commands: []v1.Command{
+						{
+							Id:           "build",
+							Attributes:   odoAttributes,
+							CommandUnion: v1.CommandUnion{Exec: &v1.ExecCommand{CommandLine: "npm install", Component: "runtime"}},
+						},
+						{
+							Id:           "deploy-k8s",
+							Attributes:   odoAttributes,
+							CommandUnion: v1.CommandUnion{Apply: &v1.ApplyCommand{Component: "deploy"}},
+						},
+						{
+							Id:           "build-and-deploy",
+							Attributes:   odoAttributes,
+							CommandUnion: v1.CommandUnion{Composite: &v1.CompositeCommand{Commands: []string{"build", "deploy-k8s"}}},
+						},
+						{
+							Id:           "test",
+							CommandUnion: v1.CommandUnion{Exec: &v1.ExecCommand{CommandLine: "npm test", Component: "runtime"}},
+						},
+					},
+					Events: &v1.Events{
+						DevWorkspaceEvents: v1.DevWorkspaceEvents{
+							PostStart: []string{"build", "deploy-k8s"},
+						},
+					},
+					Projects: []v1.Project{
+						{Name: "odo-project", Attributes: odoAttributes, ProjectSource: gitSource},
+						{Name: "other-project", ProjectSource: gitSource},
+					},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name             string
+		options          common.DevfileOptions
+		wantComponents   []string
+		wantCommands     []string
+		wantPostStart    []string
+		wantProjects     []string
+		wantVolumeMounts int
+	}{
+		{
+			name:           "filter by attributes drops the commands referencing dropped objects",
+			options:        common.DevfileOptions{Filter: map[string]interface{}{"tool": "odo"}},
+			wantComponents: []string{"runtime"},
+			wantCommands:   []string{"build"},
+			wantPostStart:  []string{"build"},
+			wantProjects:   []string{"odo-project"},
+		},
+		{
+			name:           "filter by component type keeps the commands of the kept components",
+			options:        common.DevfileOptions{ComponentOptions: common.ComponentOptions{ComponentType: v1.ContainerComponentType}},
+			wantComponents: []string{"runtime"},
+			wantCommands:   []string{"build", "test"},
+			wantPostStart:  []string{"build"},
+			wantProjects:   []string{"odo-project", "other-project"},
+		},
+		{
+			name:             "no filter keeps the whole devfile",
+			wantComponents:   []string{"runtime", "cache", "deploy"},
+			wantCommands:     []string{"build", "deploy-k8s", "build-and-deploy", "test"},
+			wantPostStart:    []string{"build", "deploy-k8s"},
+			wantProjects:     []string{"odo-project", "other-project"},
+			wantVolumeMounts: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filtered, err := FilterDevfile(devfileData, tt.options)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if filtered.GetSchemaVersion() != "2.2.0" || filtered.GetMetadata().Name != "nodejs" {
+				t.Errorf("got header: %v %v, want the header of the devfile", filtered.GetSchemaVersion(), filtered.GetMetadata())
+			}
+
+			content := filtered.GetDevfileWorkspaceSpecContent()
+			var gotComponents, gotCommands, gotProjects []string
+			gotVolumeMounts := 0
+			for _, component := range content.Components {
+				gotComponents = append(gotComponents, component.Name)
+				if component.Container != nil {
+					gotVolumeMounts += len(component.Container.VolumeMounts)
+				}
+			}
+			for _, command := range content.Commands {
+				gotCommands = append(gotCommands, command.Id)
+			}
+			for _, project := range content.Projects {
+				gotProjects = append(gotProjects, project.Name)
+			}
+			if !reflect.DeepEqual(gotComponents, tt.wantComponents) {
+				t.Errorf("got components: %v, want: %v", gotComponents, tt.wantComponents)
+			}
+			if !reflect.DeepEqual(gotCommands, tt.wantCommands) {
+				t.Errorf("got commands: %v, want: %v", gotCommands, tt.wantCommands)
+			}
+			if !reflect.DeepEqual(content.Events.PostStart, tt.wantPostStart) {
+				t.Errorf("got postStart events: %v, want: %v", content.Events.PostStart, tt.wantPostStart)
+			}
+			if !reflect.DeepEqual(gotProjects, tt.wantProjects) {
+				t.Errorf("got projects: %v, want: %v", gotProjects, tt.wantProjects)
+			}
+			if gotVolumeMounts != tt.wantVolumeMounts {
+				t.Errorf("got volume mounts: %v, want: %v", gotVolumeMounts, tt.wantVolumeMounts)
+			}
+
+			// the devfile data should not be modified
+			original := devfileData.GetDevfileWorkspaceSpecContent()
+			if len(original.Components) != 3 || len(original.Commands) != 4 || len(original.Events.PostStart) != 2 ||
+				len(original.Components[0].Container.VolumeMounts) != 1 {
+				t.Errorf("the devfile data should not be modified, got: %v", original)
+			}
+		})
+	}
+}
+
+func TestGetImageReferences(t *testing.T) {
+	container := func(name, image string) v1.Component {
+		return v1.Component{
+			Name:           name,
+			ComponentUnion: v1.ComponentUnion{Container: &v1.ContainerComponent{Container: v1.Container{Image: image}}},
+		}
+	}
+	image := func(name, imageName string) v1.Component {
+		return v1.Component{
+			Name: name,
+			ComponentUnion: v1.ComponentUnion{
+				Image: &v1.ImageComponent{
+					Image: v1.Image{
+						ImageName: imageName,
+						ImageUnion: v1.ImageUnion{
+							Dockerfile: &v1.DockerfileImage{
+								DockerfileSrc: v1.DockerfileSrc{Uri: "docker/Dockerfile"},
+								Dockerfile:    v1.Dockerfile{BuildContext: "."},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		components []v1.Component
+		want       []string
+	}{
+		{
+			name: "images of container and image components, in devfile order",
+			components: []v1.Component{
+				container("runtime", "registry.access.redhat.com/ubi8/nodejs-18:latest"),
+				image("outerloop-build", "quay.io/devfile/nodejs-app:latest"),
+				{Name: "cache", ComponentUnion: v1.ComponentUnion{Volume: &v1.VolumeComponent{}}},
+				container("db", "postgres:15"),
+				image("debug-build", "quay.io/devfile/nodejs-app:debug"),
+			},
+			want: []string{
+				"registry.access.redhat.com/ubi8/nodejs-18:latest",
+				"quay.io/devfile/nodejs-app:latest",
+				"postgres:15",
+				"quay.io/devfile/nodejs-app:debug",
+			},
+		},
+		{
+			name: "images referenced by several components are returned once",
+			components: []v1.Component{
+				container("runtime", "postgres:15"),
+				container("db", "postgres:15"),
+			},
+			want: []string{"postgres:15"},
+		},
+		{
+			name: "no image component",
+			components: []v1.Component{
+				{Name: "cache", ComponentUnion: v1.ComponentUnion{Volume: &v1.VolumeComponent{}}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			devfileData := &v2.DevfileV2{
+				Devfile: v1.Devfile{
+					DevWorkspaceTemplateSpec: v1.DevWorkspaceTemplateSpec{
+						DevWorkspaceTemplateSpecContent: v1.DevWorkspaceTemplateSpecContent{
+							Components: tt.components,
+						},
+					},
+				},
+			}
+			got, err := GetImageReferences(devfileData)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetNameAndVersion(t *testing.T) {
+	tests := []struct {
+		name        string
+		metadata    devfilepkg.DevfileMetadata
+		wantName    string
+		wantVersion string
+		wantErr     string
+	}{
+		{
+			name:        "should return the name and version",
+			metadata:    devfilepkg.DevfileMetadata{Name: "nodejs", Version: "2.1.1"},
+			wantName:    "nodejs",
+			wantVersion: "2.1.1",
+		},
+		{
+			name:        "should return a pre-release version with build metadata",
+			metadata:    devfilepkg.DevfileMetadata{Name: "nodejs", Version: "2.2.0-alpha.1+build-42"},
+			wantName:    "nodejs",
+			wantVersion: "2.2.0-alpha.1+build-42",
+		},
+		{
+			name:     "should fail with a missing name",
+			metadata: devfilepkg.DevfileMetadata{Version: "2.1.1"},
+			wantErr:  "the devfile metadata.name is missing",
+		},
+		{
+			name:     "should fail with a missing version",
+			metadata: devfilepkg.DevfileMetadata{Name: "nodejs"},
+			wantErr:  "the devfile metadata.version of nodejs is missing",
+		},
+		{
+			name:     "should fail with a version missing its patch",
+			metadata: devfilepkg.DevfileMetadata{Name: "nodejs", Version: "2.1"},
+			wantErr:  `the devfile metadata.version "2.1" of nodejs is not a semantic version`,
+		},
+		{
+			name:     "should fail with a v prefixed version",
+			metadata: devfilepkg.DevfileMetadata{Name: "nodejs", Version: "v2.1.1"},
+			wantErr:  `the devfile metadata.version "v2.1.1" of nodejs is not a semantic version`,
+		},
+		{
+			name:     "should fail with a malformed version",
+			metadata: devfilepkg.DevfileMetadata{Name: "nodejs", Version: "latest"},
+			wantErr:  `the devfile metadata.version "latest" of nodejs is not a semantic version`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			devfileData := &v2.DevfileV2{
+				Devfile: v1.Devfile{
+					DevfileHeader: devfilepkg.DevfileHeader{Metadata: tt.metadata},
+				},
+			}
+			gotName, gotVersion, err := GetNameAndVersion(devfileData)
+			if (err != nil) != (tt.wantErr != "") {
+				t.Fatalf("unexpected error: %v, wantErr: %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("got error: %v, want: %v", err, tt.wantErr)
+				}
+				return
+			}
+			if gotName != tt.wantName || gotVersion != tt.wantVersion {
+				t.Errorf("got: %s %s, want: %s %s", gotName, gotVersion, tt.wantName, tt.wantVersion)
+			}
+		})
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	const devfileContent = `schemaVersion: 2.2.0
+metadata:
+  name: nodejs
+  version: 1.0.0
+attributes:
+  alpha.dockerimage-port: 8080
+  controller.devfile.io/storage-type: ephemeral
+components:
+- name: runtime
+  attributes:
+    tool: console-import
+    import: {strategy: Dockerfile, context: ./}
+  container:
+    image: nodejs
+    memoryLimit: 1024Mi
+- name: tools
+  container:
+    image: tools
+commands:
+- id: run
+  exec:
+    component: runtime
+    commandLine: npm start
+`
+	const reorderedContent = `metadata:
+  version: "1.0.0"
+  name: nodejs
+components:
+  - container:
+      memoryLimit: 1024Mi
+      image: nodejs
+    attributes:
+      import:
+        context: ./
+        strategy: Dockerfile
+      tool: console-import
+    name: runtime
+  - container: {image: tools}
+    name: tools
+commands:
+  - exec: {commandLine: npm start, component: runtime}
+    id: run
+attributes:
+  controller.devfile.io/storage-type: ephemeral
+  alpha.dockerimage-port: 8080
+schemaVersion: "2.2.0"
+`
+	const swappedComponentsContent = `schemaVersion: 2.2.0
+metadata:
+  name: nodejs
+  version: 1.0.0
+attributes:
+  alpha.dockerimage-port: 8080
+  controller.devfile.io/storage-type: ephemeral
+components:
+- name: tools
+  container:
+    image: tools
+- name: runtime
+  attributes:
+    tool: console-import
+    import: {strategy: Dockerfile, context: ./}
+  container:
+    image: nodejs
+    memoryLimit: 1024Mi
+commands:
+- id: run
+  exec:
+    component: runtime
+    commandLine: npm start
+`
+
+	fingerprint := func(t *testing.T, content string) string {
+		t.Helper()
+		devfileData := &v2.DevfileV2{}
+		if err := yaml.Unmarshal([]byte(content), devfileData); err != nil {
+			t.Fatalf("TestFingerprint() unexpected error: %v", err)
+		}
+		got, err := Fingerprint(devfileData)
+		if err != nil {
+			t.Fatalf("TestFingerprint() unexpected error: %v", err)
+		}
+		return got
+	}
+
+	want := fingerprint(t, devfileContent)
+	if len(want) != 64 {
+		t.Errorf("TestFingerprint() got fingerprint: %s, want a hex encoded SHA-256", want)
+	}
+
+	tests := []struct {
+		name     string
+		content  string
+		wantSame bool
+	}{
+		{
+			name:     "should have the same fingerprint for the same devfile",
+			content:  devfileContent,
+			wantSame: true,
+		},
+		{
+			name:     "should have the same fingerprint for a devfile of reordered keys and another formatting",
+			content:  reorderedContent,
+			wantSame: true,
+		},
+		{
+			name:    "should have another fingerprint for a devfile of reordered components",
+			content: swappedComponentsContent,
+		},
+		{
+			name:    "should have another fingerprint for a devfile of another content",
+			content: strings.Replace(devfileContent, "npm start", "npm run dev", 1),
+		},
+		{
+			name:    "should have another fingerprint for a devfile of another attribute value",
+			content: strings.Replace(devfileContent, "context: ./", "context: ./app", 1),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fingerprint(t, tt.content)
+			if (got == want) != tt.wantSame {
+				t.Errorf("TestFingerprint() got fingerprint: %s, devfile fingerprint: %s, want same: %v", got, want, tt.wantSame)
+			}
+		})
+	}
+}
@@ -19,23 +19,31 @@ import (
 	"fmt"
 	v1 "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
 	"github.com/devfile/library/v2/pkg/devfile/parser/data/v2/common"
+	"github.com/hashicorp/go-multierror"
 	"reflect"
 	"strings"
 )
 
-// GetCommands returns the slice of Command objects parsed from the Devfile
+// GetCommands returns the slice of Command objects parsed from the Devfile, in the order they are declared in the Devfile,
+// so the order is the same across calls
+// if options.ContinueOnError is set, malformed objects are skipped and their errors are returned along with the remaining objects
 func (d *DevfileV2) GetCommands(options common.DevfileOptions) ([]v1.Command, error) {
 
 	if reflect.DeepEqual(options, common.DevfileOptions{}) {
 		return d.Commands, nil
 	}
 
+	var itemErrs error
 	var commands []v1.Command
 	for _, command := range d.Commands {
 		// Filter Command Attributes
 		filterIn, err := common.FilterDevfileObject(command.Attributes, options)
 		if err != nil {
-			return nil, err
+			if !options.ContinueOnError {
+				return nil, err
+			}
+			itemErrs = multierror.Append(itemErrs, fmt.Errorf("command %s: %v", command.Id, err))
+			continue
 		} else if !filterIn {
 			continue
 		}
@@ -43,7 +51,11 @@ func (d *DevfileV2) GetCommands(options common.DevfileOptions) ([]v1.Command, er
 		// Filter Command Type - Exec, Composite, etc.
 		commandType, err := common.GetCommandType(command)
 		if err != nil {
-			return nil, err
+			if !options.ContinueOnError {
+				return nil, err
+			}
+			itemErrs = multierror.Append(itemErrs, fmt.Errorf("command %s: %v", command.Id, err))
+			continue
 		}
 		if options.CommandOptions.CommandType != "" && commandType != options.CommandOptions.CommandType {
 			continue
@@ -63,7 +75,7 @@ func (d *DevfileV2) GetCommands(options common.DevfileOptions) ([]v1.Command, er
 		}
 	}
 
-	return commands, nil
+	return commands, itemErrs
 }
 
 // AddCommands adds the slice of Command objects to the Devfile's commands
@@ -24,6 +24,7 @@ import (
 	v1 "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
 	"github.com/devfile/api/v2/pkg/attributes"
 	"github.com/devfile/library/v2/pkg/devfile/parser/data/v2/common"
+	"github.com/hashicorp/go-multierror"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -616,3 +617,81 @@ func TestDeleteCommands(t *testing.T) {
 	}
 
 }
+
+func TestDevfile200_GetCommands_ContinueOnError(t *testing.T) {
+	d := &DevfileV2{
+		v1.Devfile{
+			DevWorkspaceTemplateSpec: v1.DevWorkspaceTemplateSpec{
+				DevWorkspaceTemplateSpecContent: v1.DevWorkspaceTemplateSpecContent{
+					Commands: []v1.Command{
+						{
+							Id: "command1",
+							CommandUnion: v1.CommandUnion{
+								Exec: &v1.ExecCommand{},
+							},
+						},
+						{
+							Id:           "malformed",
+							CommandUnion: v1.CommandUnion{},
+						},
+						{
+							Id: "command2",
+							CommandUnion: v1.CommandUnion{
+								Composite: &v1.CompositeCommand{},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	commands, err := d.GetCommands(common.DevfileOptions{ContinueOnError: true})
+	var gotIds []string
+	for _, command := range commands {
+		gotIds = append(gotIds, command.Id)
+	}
+	assert.Equal(t, []string{"command1", "command2"}, gotIds, "TestDevfile200_GetCommands_ContinueOnError(): valid commands should be returned")
+
+	if merr, ok := err.(*multierror.Error); !ok || len(merr.Errors) != 1 {
+		t.Fatalf("TestDevfile200_GetCommands_ContinueOnError() error: expected an error for the malformed command, got: %v", err)
+	}
+	assert.Regexp(t, "command malformed: unknown command type", err.Error(), "TestDevfile200_GetCommands_ContinueOnError(): Error message should match")
+}
+
+func TestDevfile200_GetCommands_Order(t *testing.T) {
+	// ids in an order that neither sorting nor a map iteration would keep
+	ids := []string{"run", "build", "test", "debug", "deploy", "apply", "init", "clean"}
+	var commands []v1.Command
+	for _, id := range ids {
+		commands = append(commands, v1.Command{
+			Id: id,
+			CommandUnion: v1.CommandUnion{
+				Exec: &v1.ExecCommand{},
+			},
+		})
+	}
+	d := &DevfileV2{
+		v1.Devfile{
+			DevWorkspaceTemplateSpec: v1.DevWorkspaceTemplateSpec{
+				DevWorkspaceTemplateSpecContent: v1.DevWorkspaceTemplateSpecContent{
+					Commands: commands,
+				},
+			},
+		},
+	}
+
+	for _, options := range []common.DevfileOptions{{}, {CommandOptions: common.CommandOptions{CommandType: v1.ExecCommandType}}} {
+		for i := 0; i < 20; i++ {
+			commands, err := d.GetCommands(options)
+			if err != nil {
+				t.Fatalf("TestDevfile200_GetCommands_Order() unexpected error: %v", err)
+			}
+			var gotIds []string
+			for _, command := range commands {
+				gotIds = append(gotIds, command.Id)
+			}
+			assert.Equal(t, ids, gotIds, "TestDevfile200_GetCommands_Order(): commands should be returned in declaration order")
+		}
+	}
+}
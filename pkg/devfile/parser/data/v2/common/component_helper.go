@@ -31,6 +31,11 @@ func IsVolume(component v1.Component) bool {
 	return component.Volume != nil
 }
 
+// IsImage checks if the component is an image
+func IsImage(component v1.Component) bool {
+	return component.Image != nil
+}
+
 // GetComponentType returns the component type of a given component
 func GetComponentType(component v1.Component) (v1.ComponentType, error) {
 	switch {
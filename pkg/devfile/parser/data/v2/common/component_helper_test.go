@@ -104,6 +104,49 @@ func TestIsVolume(t *testing.T) {
 
 }
 
+func TestIsImage(t *testing.T) {
+
+	tests := []struct {
+		name            string
+		component       v1.Component
+		wantIsSupported bool
+	}{
+		{
+			name: "Image component",
+			component: v1.Component{
+				Name: "name",
+				ComponentUnion: v1.ComponentUnion{
+					Image: &v1.ImageComponent{
+						Image: v1.Image{
+							ImageName: "image",
+						},
+					},
+				},
+			},
+			wantIsSupported: true,
+		},
+		{
+			name: "Not an image component",
+			component: v1.Component{
+				Name: "name",
+				ComponentUnion: v1.ComponentUnion{
+					Container: &v1.ContainerComponent{},
+				},
+			},
+			wantIsSupported: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isSupported := IsImage(tt.component)
+			if isSupported != tt.wantIsSupported {
+				t.Errorf("TestIsImage error: component support mismatch, expected: %v got: %v", tt.wantIsSupported, isSupported)
+			}
+		})
+	}
+
+}
+
 func TestGetComponentType(t *testing.T) {
 	cmpTypeErr := "unknown component type"
 
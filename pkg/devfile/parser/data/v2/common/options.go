@@ -38,6 +38,10 @@ type DevfileOptions struct {
 
 	// FilterByName specifies the name for the particular devfile object that's been looking for
 	FilterByName string
+
+	// ContinueOnError returns the successfully retrieved devfile objects along with an error aggregating
+	// the failures of each malformed object, instead of aborting the whole retrieval on the first failure
+	ContinueOnError bool
 }
 
 // CommandOptions specifies the various options available to filter commands
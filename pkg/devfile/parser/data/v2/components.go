@@ -22,21 +22,29 @@ import (
 
 	v1 "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
 	"github.com/devfile/library/v2/pkg/devfile/parser/data/v2/common"
+	"github.com/hashicorp/go-multierror"
 )
 
-// GetComponents returns the slice of Component objects parsed from the Devfile
+// GetComponents returns the slice of Component objects parsed from the Devfile, in the order they are declared in the Devfile,
+// so the order is the same across calls
+// if options.ContinueOnError is set, malformed objects are skipped and their errors are returned along with the remaining objects
 func (d *DevfileV2) GetComponents(options common.DevfileOptions) ([]v1.Component, error) {
 
 	if reflect.DeepEqual(options, common.DevfileOptions{}) {
 		return d.Components, nil
 	}
 
+	var itemErrs error
 	var components []v1.Component
 	for _, component := range d.Components {
 		// Filter Component Attributes
 		filterIn, err := common.FilterDevfileObject(component.Attributes, options)
 		if err != nil {
-			return nil, err
+			if !options.ContinueOnError {
+				return nil, err
+			}
+			itemErrs = multierror.Append(itemErrs, fmt.Errorf("component %s: %v", component.Name, err))
+			continue
 		} else if !filterIn {
 			continue
 		}
@@ -44,7 +52,11 @@ func (d *DevfileV2) GetComponents(options common.DevfileOptions) ([]v1.Component
 		// Filter Component Type - Container, Volume, etc.
 		componentType, err := common.GetComponentType(component)
 		if err != nil {
-			return nil, err
+			if !options.ContinueOnError {
+				return nil, err
+			}
+			itemErrs = multierror.Append(itemErrs, fmt.Errorf("component %s: %v", component.Name, err))
+			continue
 		}
 		if options.ComponentOptions.ComponentType != "" && componentType != options.ComponentOptions.ComponentType {
 			continue
@@ -55,7 +67,7 @@ func (d *DevfileV2) GetComponents(options common.DevfileOptions) ([]v1.Component
 		}
 	}
 
-	return components, nil
+	return components, itemErrs
 }
 
 // GetDevfileContainerComponents iterates through the components in the devfile and returns a list of devfile container components.
@@ -25,6 +25,7 @@ import (
 	"github.com/devfile/api/v2/pkg/attributes"
 	"github.com/devfile/library/v2/pkg/devfile/parser/data/v2/common"
 	"github.com/devfile/library/v2/pkg/testingutil"
+	"github.com/hashicorp/go-multierror"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -838,3 +839,87 @@ func TestDeleteComponents(t *testing.T) {
 	}
 
 }
+
+func TestGetDevfileComponents_ContinueOnError(t *testing.T) {
+	d := &DevfileV2{
+		v1.Devfile{
+			DevWorkspaceTemplateSpec: v1.DevWorkspaceTemplateSpec{
+				DevWorkspaceTemplateSpecContent: v1.DevWorkspaceTemplateSpecContent{
+					Components: []v1.Component{
+						{
+							Name: "comp1",
+							ComponentUnion: v1.ComponentUnion{
+								Container: &v1.ContainerComponent{},
+							},
+						},
+						{
+							Name:           "malformed1",
+							ComponentUnion: v1.ComponentUnion{},
+						},
+						{
+							Name: "comp2",
+							ComponentUnion: v1.ComponentUnion{
+								Volume: &v1.VolumeComponent{},
+							},
+						},
+						{
+							Name:           "malformed2",
+							ComponentUnion: v1.ComponentUnion{},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	_, err := d.GetComponents(common.DevfileOptions{FilterByName: "comp1"})
+	assert.Regexp(t, "unknown component type", err.Error(), "TestGetDevfileComponents_ContinueOnError(): Error message should match")
+
+	components, err := d.GetComponents(common.DevfileOptions{ContinueOnError: true})
+	var gotNames []string
+	for _, component := range components {
+		gotNames = append(gotNames, component.Name)
+	}
+	assert.Equal(t, []string{"comp1", "comp2"}, gotNames, "TestGetDevfileComponents_ContinueOnError(): valid components should be returned")
+
+	if merr, ok := err.(*multierror.Error); !ok || len(merr.Errors) != 2 {
+		t.Fatalf("TestGetDevfileComponents_ContinueOnError() error: expected an error for each malformed component, got: %v", err)
+	}
+	assert.Regexp(t, "component malformed1: unknown component type", err.Error(), "TestGetDevfileComponents_ContinueOnError(): Error message should match")
+	assert.Regexp(t, "component malformed2: unknown component type", err.Error(), "TestGetDevfileComponents_ContinueOnError(): Error message should match")
+}
+
+func TestGetDevfileComponents_Order(t *testing.T) {
+	// names in an order that neither sorting nor a map iteration would keep
+	names := []string{"runtime", "db", "zookeeper", "api", "m2", "cache", "kafka", "broker"}
+	var components []v1.Component
+	for _, name := range names {
+		components = append(components, v1.Component{
+			Name:           name,
+			ComponentUnion: v1.ComponentUnion{Container: &v1.ContainerComponent{}},
+		})
+	}
+	d := &DevfileV2{
+		v1.Devfile{
+			DevWorkspaceTemplateSpec: v1.DevWorkspaceTemplateSpec{
+				DevWorkspaceTemplateSpecContent: v1.DevWorkspaceTemplateSpecContent{
+					Components: components,
+				},
+			},
+		},
+	}
+
+	for _, options := range []common.DevfileOptions{{}, {ComponentOptions: common.ComponentOptions{ComponentType: v1.ContainerComponentType}}} {
+		for i := 0; i < 20; i++ {
+			components, err := d.GetComponents(options)
+			if err != nil {
+				t.Fatalf("TestGetDevfileComponents_Order() unexpected error: %v", err)
+			}
+			var gotNames []string
+			for _, component := range components {
+				gotNames = append(gotNames, component.Name)
+			}
+			assert.Equal(t, names, gotNames, "TestGetDevfileComponents_Order(): components should be returned in declaration order")
+		}
+	}
+}
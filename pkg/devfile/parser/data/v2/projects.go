@@ -19,23 +19,30 @@ import (
 	"fmt"
 	v1 "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
 	"github.com/devfile/library/v2/pkg/devfile/parser/data/v2/common"
+	"github.com/hashicorp/go-multierror"
 	"reflect"
 	"strings"
 )
 
 // GetProjects returns the Project Object parsed from devfile
+// if options.ContinueOnError is set, malformed objects are skipped and their errors are returned along with the remaining objects
 func (d *DevfileV2) GetProjects(options common.DevfileOptions) ([]v1.Project, error) {
 
 	if reflect.DeepEqual(options, common.DevfileOptions{}) {
 		return d.Projects, nil
 	}
 
+	var itemErrs error
 	var projects []v1.Project
 	for _, project := range d.Projects {
 		// Filter Project Attributes
 		filterIn, err := common.FilterDevfileObject(project.Attributes, options)
 		if err != nil {
-			return nil, err
+			if !options.ContinueOnError {
+				return nil, err
+			}
+			itemErrs = multierror.Append(itemErrs, fmt.Errorf("project %s: %v", project.Name, err))
+			continue
 		} else if !filterIn {
 			continue
 		}
@@ -43,7 +50,11 @@ func (d *DevfileV2) GetProjects(options common.DevfileOptions) ([]v1.Project, er
 		// Filter Project Source Type - Git, Zip, etc.
 		projectSourceType, err := common.GetProjectSourceType(project.ProjectSource)
 		if err != nil {
-			return nil, err
+			if !options.ContinueOnError {
+				return nil, err
+			}
+			itemErrs = multierror.Append(itemErrs, fmt.Errorf("project %s: %v", project.Name, err))
+			continue
 		}
 		if options.ProjectOptions.ProjectSourceType != "" && projectSourceType != options.ProjectOptions.ProjectSourceType {
 			continue
@@ -53,7 +64,7 @@ func (d *DevfileV2) GetProjects(options common.DevfileOptions) ([]v1.Project, er
 		}
 	}
 
-	return projects, nil
+	return projects, itemErrs
 }
 
 // AddProjects adss the slice of Devfile projects to the Devfile's project list
@@ -109,18 +120,24 @@ func (d *DevfileV2) DeleteProject(name string) error {
 }
 
 // GetStarterProjects returns the DevfileStarterProject parsed from devfile
+// if options.ContinueOnError is set, malformed objects are skipped and their errors are returned along with the remaining objects
 func (d *DevfileV2) GetStarterProjects(options common.DevfileOptions) ([]v1.StarterProject, error) {
 
 	if reflect.DeepEqual(options, common.DevfileOptions{}) {
 		return d.StarterProjects, nil
 	}
 
+	var itemErrs error
 	var starterProjects []v1.StarterProject
 	for _, starterProject := range d.StarterProjects {
 		// Filter Starter Project Attributes
 		filterIn, err := common.FilterDevfileObject(starterProject.Attributes, options)
 		if err != nil {
-			return nil, err
+			if !options.ContinueOnError {
+				return nil, err
+			}
+			itemErrs = multierror.Append(itemErrs, fmt.Errorf("starter project %s: %v", starterProject.Name, err))
+			continue
 		} else if !filterIn {
 			continue
 		}
@@ -128,7 +145,11 @@ func (d *DevfileV2) GetStarterProjects(options common.DevfileOptions) ([]v1.Star
 		// Filter Starter Project Source Type - Git, Zip, etc.
 		starterProjectSourceType, err := common.GetProjectSourceType(starterProject.ProjectSource)
 		if err != nil {
-			return nil, err
+			if !options.ContinueOnError {
+				return nil, err
+			}
+			itemErrs = multierror.Append(itemErrs, fmt.Errorf("starter project %s: %v", starterProject.Name, err))
+			continue
 		}
 		if options.ProjectOptions.ProjectSourceType != "" && starterProjectSourceType != options.ProjectOptions.ProjectSourceType {
 			continue
@@ -139,7 +160,7 @@ func (d *DevfileV2) GetStarterProjects(options common.DevfileOptions) ([]v1.Star
 		}
 	}
 
-	return starterProjects, nil
+	return starterProjects, itemErrs
 }
 
 // AddStarterProjects adds the slice of Devfile starter projects to the Devfile's starter project list
@@ -16,6 +16,10 @@
 package parser
 
 import (
+	"encoding/json"
+	"fmt"
+
+	v1 "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
 	devfileCtx "github.com/devfile/library/v2/pkg/devfile/parser/context"
 	"github.com/devfile/library/v2/pkg/devfile/parser/data"
 )
@@ -34,4 +38,52 @@ type DevfileObj struct {
 
 	// Data has the devfile data
 	Data data.DevfileData
+
+	// resolvedParents are the parent devfiles resolved when flattening the devfile
+	resolvedParents []v1.ImportReference
+}
+
+// GetResolvedParents returns the references of the parent devfiles resolved when flattening the devfile with ParseDevfile,
+// the direct parent first, then its parents. Uris are resolved to the absolute url or path of the parent devfile.
+func (d DevfileObj) GetResolvedParents() []v1.ImportReference {
+	return d.resolvedParents
+}
+
+// GetResolvedVariables returns the variable values used for the substitution of the devfile variables,
+// i.e. the variables defined in the devfile and its parents, overridden by the external variables
+// if the devfile is parsed with devfile.ParseDevfileAndValidate. Modifying the returned map does not modify the devfile.
+func (d DevfileObj) GetResolvedVariables() map[string]string {
+	if d.Data == nil || d.Data.GetDevfileWorkspaceSpec() == nil {
+		return nil
+	}
+	resolvedVariables := make(map[string]string, len(d.Data.GetDevfileWorkspaceSpec().Variables))
+	for key, val := range d.Data.GetDevfileWorkspaceSpec().Variables {
+		resolvedVariables[key] = val
+	}
+	return resolvedVariables
+}
+
+// Revalidate validates the devfile data against the JSON schema of its schemaVersion, e.g. after components are added or
+// removed programmatically. The devfile data is encoded as JSON and validated in memory, without fetching its parents or
+// resources again. The schema options of the devfile context, such as the pre-release schema, are kept.
+func (d DevfileObj) Revalidate() error {
+	if d.Data == nil {
+		return fmt.Errorf("failed to revalidate the devfile, it has no data")
+	}
+	content, err := json.Marshal(d.Data)
+	if err != nil {
+		return fmt.Errorf("failed to encode the devfile data: %v", err)
+	}
+
+	ctx, err := devfileCtx.NewByteContentDevfileCtx(content)
+	if err != nil {
+		return err
+	}
+	ctx.SetAllowMissingSchema(d.Ctx.GetAllowMissingSchema())
+	ctx.SetUsePreReleaseSchema(d.Ctx.GetUsePreReleaseSchema())
+	ctx.SetMaxSchemaVersion(d.Ctx.GetMaxSchemaVersion())
+	if err = ctx.PopulateFromRaw(); err != nil {
+		return err
+	}
+	return ctx.Validate()
 }
@@ -0,0 +1,90 @@
+//
+// Copyright 2023 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"testing"
+
+	v1 "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDevfileObj_Revalidate(t *testing.T) {
+	const devfileContent = `schemaVersion: 2.2.0
+metadata:
+  name: nodejs
+components:
+- name: runtime
+  container:
+    image: nodejs
+`
+
+	tests := []struct {
+		name       string
+		components []v1.Component
+		wantErr    string
+	}{
+		{
+			name: "should pass after adding a valid component",
+			components: []v1.Component{
+				{
+					Name:           "db",
+					ComponentUnion: v1.ComponentUnion{Container: &v1.ContainerComponent{Container: v1.Container{Image: "postgres"}}},
+				},
+			},
+		},
+		{
+			name: "should report the schema error of a component without a type",
+			components: []v1.Component{
+				{Name: "untyped"},
+			},
+			wantErr: "invalid devfile schema. errors :\n- components.1: Must validate one and only one schema.*",
+		},
+		{
+			name: "should report the schema error of a component with an invalid name",
+			components: []v1.Component{
+				{
+					Name:           "Invalid_Name",
+					ComponentUnion: v1.ComponentUnion{Container: &v1.ContainerComponent{Container: v1.Container{Image: "postgres"}}},
+				},
+			},
+			wantErr: "invalid devfile schema. errors :\n- components.1.name: Does not match pattern.*",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isFalse := false
+			d, err := ParseDevfile(ParserArgs{Data: []byte(devfileContent), FlattenedDevfile: &isFalse})
+			if err != nil {
+				t.Fatalf("TestDevfileObj_Revalidate() unexpected error: %v", err)
+			}
+			if err = d.Revalidate(); err != nil {
+				t.Fatalf("TestDevfileObj_Revalidate() unexpected error of the parsed devfile: %v", err)
+			}
+
+			if err = d.Data.AddComponents(tt.components); err != nil {
+				t.Fatalf("TestDevfileObj_Revalidate() unexpected error: %v", err)
+			}
+			err = d.Revalidate()
+			if (err != nil) != (tt.wantErr != "") {
+				t.Fatalf("TestDevfileObj_Revalidate() unexpected error: %v, wantErr: %v", err, tt.wantErr)
+			}
+			if err != nil {
+				assert.Regexp(t, tt.wantErr, err.Error(), "Error message should match")
+			}
+		})
+	}
+}
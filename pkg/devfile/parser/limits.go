@@ -0,0 +1,81 @@
+//
+// Copyright 2023 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"fmt"
+
+	"github.com/devfile/library/v2/pkg/devfile/parser/data/v2/common"
+)
+
+// checkLimits checks the parsed devfile against the maximums of the parser args, e.g. to protect a server from pathological
+// devfiles. A maximum of zero is unlimited. The error names the collection exceeding its maximum.
+func checkLimits(d DevfileObj, args ParserArgs) error {
+	components, err := d.Data.GetComponents(common.DevfileOptions{})
+	if err != nil {
+		return err
+	}
+	if err = checkCountLimit("components", len(components), args.MaxComponents); err != nil {
+		return err
+	}
+
+	commands, err := d.Data.GetCommands(common.DevfileOptions{})
+	if err != nil {
+		return err
+	}
+	if err = checkCountLimit("commands", len(commands), args.MaxCommands); err != nil {
+		return err
+	}
+
+	projects, err := d.Data.GetProjects(common.DevfileOptions{})
+	if err != nil {
+		return err
+	}
+	if err = checkCountLimit("projects", len(projects), args.MaxProjects); err != nil {
+		return err
+	}
+	starterProjects, err := d.Data.GetStarterProjects(common.DevfileOptions{})
+	if err != nil {
+		return err
+	}
+	if err = checkCountLimit("starterProjects", len(starterProjects), args.MaxProjects); err != nil {
+		return err
+	}
+
+	if args.MaxInlinedResourceBytes > 0 {
+		for _, component := range components {
+			var inlined string
+			switch {
+			case component.Kubernetes != nil:
+				inlined = component.Kubernetes.Inlined
+			case component.Openshift != nil:
+				inlined = component.Openshift.Inlined
+			}
+			if len(inlined) > args.MaxInlinedResourceBytes {
+				return fmt.Errorf("the inlined resource of component %s exceeds the limit of %d bytes, it has %d bytes", component.Name, args.MaxInlinedResourceBytes, len(inlined))
+			}
+		}
+	}
+	return nil
+}
+
+// checkCountLimit checks that the number of elements of the devfile collection does not exceed its maximum, zero for unlimited
+func checkCountLimit(collection string, count int, limit int) error {
+	if limit > 0 && count > limit {
+		return fmt.Errorf("the devfile exceeds the limit of %d %s, it has %d %s", limit, collection, count, collection)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+//
+// Copyright 2023 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDevfile_Limits(t *testing.T) {
+	const devfile = `schemaVersion: 2.2.0
+metadata:
+  name: limits
+components:
+- name: runtime
+  container:
+    image: nodejs
+- name: tools
+  container:
+    image: golang
+- name: deploy
+  kubernetes:
+    inlined: |
+      apiVersion: v1
+      kind: Service
+      metadata:
+        name: my-app
+commands:
+- id: run
+  exec:
+    component: runtime
+    commandLine: npm start
+- id: debug
+  exec:
+    component: runtime
+    commandLine: npm run debug
+projects:
+- name: app
+  git:
+    remotes:
+      origin: https://github.com/devfile/library.git
+`
+
+	tests := []struct {
+		name    string
+		args    ParserArgs
+		wantErr string
+	}{
+		{
+			name: "should parse a devfile without limits",
+		},
+		{
+			name: "should parse a devfile within the limits",
+			args: ParserArgs{MaxComponents: 3, MaxCommands: 2, MaxProjects: 1, MaxInlinedResourceBytes: 1024},
+		},
+		{
+			name:    "should fail with a devfile exceeding the component limit",
+			args:    ParserArgs{MaxComponents: 2},
+			wantErr: "the devfile exceeds the limit of 2 components, it has 3 components",
+		},
+		{
+			name:    "should fail with a devfile exceeding the command limit",
+			args:    ParserArgs{MaxCommands: 1},
+			wantErr: "the devfile exceeds the limit of 1 commands, it has 2 commands",
+		},
+		{
+			name:    "should fail with a devfile exceeding the inlined resource limit",
+			args:    ParserArgs{MaxInlinedResourceBytes: 16},
+			wantErr: "the inlined resource of component deploy exceeds the limit of 16 bytes, it has .* bytes",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := tt.args
+			args.Data = []byte(devfile)
+			_, err := ParseDevfile(args)
+			if (err != nil) != (tt.wantErr != "") {
+				t.Fatalf("TestParseDevfile_Limits() unexpected error: %v, wantErr: %v", err, tt.wantErr)
+			}
+			if err != nil {
+				assert.Regexp(t, tt.wantErr, err.Error(), "Error message should match")
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+//
+// Copyright 2023 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	devfilepkg "github.com/devfile/api/v2/pkg/devfile"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+// metadataOnlyDevfile is used to decode only the metadata block of a devfile
+type metadataOnlyDevfile struct {
+	Metadata devfilepkg.DevfileMetadata `json:"metadata,omitempty"`
+}
+
+// ParseMetadata extracts the metadata block from the devfile content in YAML or JSON format.
+// The remaining sections of the devfile are not decoded, and neither parent resolution nor schema validation is performed.
+func ParseMetadata(data []byte) (devfilepkg.DevfileMetadata, error) {
+	var d metadataOnlyDevfile
+	err := yaml.Unmarshal(data, &d)
+	if err != nil {
+		return devfilepkg.DevfileMetadata{}, errors.Wrapf(err, "failed to decode devfile metadata")
+	}
+	return d.Metadata, nil
+}
@@ -0,0 +1,114 @@
+//
+// Copyright 2023 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+const testDevfile220Path = "../../../tests/v2/devfiles/samples/Test_220.yaml"
+
+func TestParseMetadata(t *testing.T) {
+	devfileContent, err := os.ReadFile(testDevfile220Path)
+	if err != nil {
+		t.Fatalf("failed to read test devfile: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		data        []byte
+		wantName    string
+		wantVersion string
+		wantTags    []string
+		wantErr     bool
+	}{
+		{
+			name:        "should extract metadata from a realistic devfile",
+			data:        devfileContent,
+			wantName:    "nodejs-defect-pcbx",
+			wantVersion: "1.0.1",
+			wantTags:    []string{"NodeJS", "Express", "ubi8"},
+		},
+		{
+			name:        "should extract metadata from json content",
+			data:        []byte(`{"schemaVersion": "2.2.0", "metadata": {"name": "nodejs", "version": "2.0.0", "tags": ["NodeJS"]}}`),
+			wantName:    "nodejs",
+			wantVersion: "2.0.0",
+			wantTags:    []string{"NodeJS"},
+		},
+		{
+			name: "should return empty metadata if the devfile has no metadata",
+			data: []byte("schemaVersion: 2.2.0"),
+		},
+		{
+			name:    "should fail with invalid content",
+			data:    []byte(":: invalid :: content"),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metadata, err := ParseMetadata(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("unexpected error: %v, wantErr: %v", err, tt.wantErr)
+				return
+			}
+			if metadata.Name != tt.wantName {
+				t.Errorf("Got name: %s, want: %s", metadata.Name, tt.wantName)
+			}
+			if metadata.Version != tt.wantVersion {
+				t.Errorf("Got version: %s, want: %s", metadata.Version, tt.wantVersion)
+			}
+			if !reflect.DeepEqual(metadata.Tags, tt.wantTags) {
+				t.Errorf("Got tags: %v, want: %v", metadata.Tags, tt.wantTags)
+			}
+		})
+	}
+}
+
+func BenchmarkParseMetadata(b *testing.B) {
+	devfileContent, err := os.ReadFile(testDevfile220Path)
+	if err != nil {
+		b.Fatalf("failed to read test devfile: %v", err)
+	}
+	for i := 0; i < b.N; i++ {
+		if _, err := ParseMetadata(devfileContent); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseDevfileFull(b *testing.B) {
+	devfileContent, err := os.ReadFile(testDevfile220Path)
+	if err != nil {
+		b.Fatalf("failed to read test devfile: %v", err)
+	}
+	flattenedDevfile := false
+	convertUriToInlined := false
+	for i := 0; i < b.N; i++ {
+		_, err := ParseDevfile(ParserArgs{
+			Data:                          devfileContent,
+			FlattenedDevfile:              &flattenedDevfile,
+			ConvertKubernetesContentInUri: &convertUriToInlined,
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
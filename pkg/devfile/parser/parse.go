@@ -26,12 +26,17 @@ import (
 	"os"
 	"path"
 	"reflect"
+	"regexp"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/devfile/api/v2/pkg/attributes"
 	devfileCtx "github.com/devfile/library/v2/pkg/devfile/parser/context"
 	"github.com/devfile/library/v2/pkg/devfile/parser/data"
 	"github.com/devfile/library/v2/pkg/devfile/parser/data/v2/common"
+	"github.com/devfile/library/v2/pkg/telemetry"
+	"github.com/devfile/library/v2/pkg/testingutil/filesystem"
 	"github.com/devfile/library/v2/pkg/util"
 	registryLibrary "github.com/devfile/registry-support/registry-library/library"
 	"k8s.io/apimachinery/pkg/types"
@@ -46,21 +51,55 @@ import (
 	"github.com/pkg/errors"
 )
 
-// downloadGitRepoResources is exposed as a global variable for the purpose of running mock tests
-var downloadGitRepoResources = func(url string, destDir string, httpTimeout *int, token string) error {
+const defaultGitResourcesDirPrefix = "git-resources"
+
+var (
+	gitResourcesDirPrefixLock sync.RWMutex
+	gitResourcesDirPrefix     = defaultGitResourcesDirPrefix
+)
+
+// SetGitResourcesDirPrefix sets the name prefix of the temporary directories the git repos of parent and plugin devfiles
+// are cloned to. The directories are named after the prefix, the repo name and a random suffix avoiding collisions,
+// e.g. git-resources-library-123456. An empty prefix restores the default prefix git-resources.
+func SetGitResourcesDirPrefix(prefix string) {
+	if prefix == "" {
+		prefix = defaultGitResourcesDirPrefix
+	}
+	gitResourcesDirPrefixLock.Lock()
+	defer gitResourcesDirPrefixLock.Unlock()
+	gitResourcesDirPrefix = prefix
+}
+
+// newGitResourcesDir creates a temporary directory to clone the git repo to
+func newGitResourcesDir(repo string) (string, error) {
+	gitResourcesDirPrefixLock.RLock()
+	pattern := gitResourcesDirPrefix
+	gitResourcesDirPrefixLock.RUnlock()
+	if repo != "" {
+		pattern = fmt.Sprintf("%s-%s", pattern, repo)
+	}
+	return os.MkdirTemp("", pattern+"-")
+}
+
+// downloadGitRepoResources is exposed as a global variable for the purpose of running mock tests.
+// The repo is cloned to the OS filesystem, and its resources copied to destDir of the destination filesystem.
+var downloadGitRepoResources = func(ctx context.Context, url string, destDir string, httpTimeout *int, token string, destFs filesystem.Filesystem) error {
 	var returnedErr error
 
 	gitUrl, err := git.NewGitUrlWithURL(url)
 	if err != nil {
 		return err
 	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
 
 	if gitUrl.IsGitProviderRepo() {
 		if !gitUrl.IsFile || gitUrl.Revision == "" || !strings.Contains(gitUrl.Path, OutputDevfileYamlPath) {
 			return fmt.Errorf("error getting devfile from url: failed to retrieve %s", url)
 		}
 
-		stackDir, err := os.MkdirTemp("", fmt.Sprintf("git-resources"))
+		stackDir, err := newGitResourcesDir(gitUrl.Repo)
 		if err != nil {
 			return fmt.Errorf("failed to create dir: %s, error: %v", stackDir, err)
 		}
@@ -72,22 +111,22 @@ var downloadGitRepoResources = func(url string, destDir string, httpTimeout *int
 			}
 		}(stackDir)
 
-		if !gitUrl.IsPublic(httpTimeout) {
-			err = gitUrl.SetToken(token, httpTimeout)
+		if !gitUrl.IsPublicWithContext(ctx, httpTimeout) {
+			err = gitUrl.SetTokenWithContext(ctx, token, httpTimeout)
 			if err != nil {
 				returnedErr = multierror.Append(returnedErr, err)
 				return returnedErr
 			}
 		}
 
-		err = gitUrl.CloneGitRepo(stackDir)
+		_, err = gitUrl.CloneGitRepoWithContext(ctx, stackDir, git.CloneOptions{})
 		if err != nil {
 			returnedErr = multierror.Append(returnedErr, err)
 			return returnedErr
 		}
 
 		dir := path.Dir(path.Join(stackDir, gitUrl.Path))
-		err = git.CopyAllDirFiles(dir, destDir)
+		err = git.CopyAllDirFilesToFs(dir, destDir, destFs)
 		if err != nil {
 			returnedErr = multierror.Append(returnedErr, err)
 			return returnedErr
@@ -109,6 +148,10 @@ func parseDevfile(d DevfileObj, resolveCtx *resolutionContextTree, tool resolver
 
 	// Create a new devfile data object
 	d.Data, err = data.NewDevfileData(d.Ctx.GetApiVersion())
+	if err != nil && d.Ctx.GetSchemaWarning() != "" {
+		// devfiles without a bundled schema are decoded with the devfile struct of the latest supported version
+		d.Data, err = data.NewDevfileData(data.APISchemaVersion220.String())
+	}
 	if err != nil {
 		return d, err
 	}
@@ -143,8 +186,12 @@ type ParserArgs struct {
 	// The value is default to be true.
 	FlattenedDevfile *bool
 	// ConvertKubernetesContentInUri defines if the kubernetes resources definition from uri will be converted to inlined in devObj(true) or not (false).
-	// The value is default to be true.
+	// The value is default to be true. When converted, the devfile context is flagged with convertUriToInlined and the original uri
+	// is kept as a component attribute, so that WriteYamlDevfile restores the uri instead of writing the inlined content.
 	ConvertKubernetesContentInUri *bool
+	// ConvertKubernetesContentInMemory requests the kubernetes resources definition from uri to be converted to inlined in devObj,
+	// even if ConvertKubernetesContentInUri is false. It is a plain bool for tools that only ever want the inlined content.
+	ConvertKubernetesContentInMemory bool
 	// RegistryURLs is a list of registry hosts which parser should pull parent devfile from.
 	// If registryUrl is defined in devfile, this list will be ignored.
 	RegistryURLs []string
@@ -157,7 +204,9 @@ type ParserArgs struct {
 	Context context.Context
 	// K8sClient is the Kubernetes client instance used for interacting with a cluster
 	K8sClient client.Client
-	// ExternalVariables override variables defined in the Devfile
+	// ExternalVariables override variables defined in the Devfile, taking precedence over the devfile defined values.
+	// External variables not referenced in the Devfile are ignored during substitution.
+	// The variables are substituted by devfile.ParseDevfileAndValidate.
 	ExternalVariables map[string]string
 	// HTTPTimeout overrides the request and response timeout values for reading a parent devfile reference from the registry.  If a negative value is specified, the default timeout will be used.
 	HTTPTimeout *int
@@ -167,6 +216,92 @@ type ParserArgs struct {
 	// ImageNamesAsSelector sets the information that will be used to handle image names as selectors when parsing the Devfile.
 	// Not setting this field or setting it to nil disables the logic of handling image names as selectors.
 	ImageNamesAsSelector *ImageSelectorArgs
+	// PluginParallelism is the maximum number of plugin devfiles of a devfile resolved concurrently.
+	// The value is default to 1, which resolves plugins sequentially.
+	PluginParallelism int
+	// MaxSchemaVersion is the highest devfile schemaVersion accepted by the parser, e.g. "2.2.0".
+	// Devfiles declaring a higher schemaVersion are rejected. Not setting this field accepts any schemaVersion.
+	MaxSchemaVersion string
+	// StrictYAML rejects devfiles with duplicate YAML keys, which are otherwise silently collapsed into one before schema validation.
+	StrictYAML bool
+	// MaxConcurrentDownloads is the maximum number of parent, plugin, registry and kubernetes uri resources downloaded
+	// concurrently during a parse, shared by all the devfiles resolved in the parse. Kubernetes and openshift component uris
+	// are downloaded concurrently when set to a value greater than 1.
+	// The value is default to 0, which keeps the downloads sequential, unless plugins are resolved concurrently.
+	MaxConcurrentDownloads int
+	// ParentSchemaVersionPolicy is the policy checking that the schemaVersion of a parent devfile is compatible with the
+	// schemaVersion of its child before merging them. The value is default to ParentSchemaVersionSameMajor.
+	ParentSchemaVersionPolicy ParentSchemaVersionPolicy
+	// AllowMissingSchema continues parsing the devfile without schema validation, instead of failing, when the JSON schema of
+	// the devfile schemaVersion is not bundled with the parser, e.g. for a newer schemaVersion. The devfile is then decoded with
+	// the devfile struct of the latest supported schemaVersion, and the reason is reported by DevfileCtx.GetSchemaWarning.
+	// Applies to the main devfile only; schema validation failures are still errors.
+	AllowMissingSchema bool
+	// StrictWarnings makes devfile.ParseDevfileAndValidate return an error listing the warnings of the devfile, if any:
+	// references to undefined variables, schema validation skipped with AllowMissingSchema, and relative uris that cannot
+	// be resolved. The variable warning is still returned alongside the error.
+	StrictWarnings bool
+	// UsePreReleaseSchema validates a devfile of a pre-release schemaVersion, e.g. 2.2.0-alpha, against the schema registered
+	// for that schemaVersion with data.RegisterPreReleaseJSONSchema. Without a registered schema, or when not set, the devfile
+	// is validated against the schema of the stable schemaVersion, e.g. 2.2.0. Applies to the main devfile only.
+	UsePreReleaseSchema bool
+	// TrackSourceDevfile records, on each component, command, project and starter project of the flattened devfile, the devfile it
+	// originated from with the SourceDevfileAttribute attribute. An element overridden by a devfile originates from the overriding devfile.
+	TrackSourceDevfile bool
+	// CaseInsensitiveDevfileName matches the devfile.yaml and .devfile.yaml filenames ignoring case when Path is a directory,
+	// e.g. to find a Devfile.yaml on a case-sensitive filesystem. Exact filenames are still preferred.
+	CaseInsensitiveDevfileName bool
+	// MaxComponents, MaxCommands and MaxProjects are the maximum numbers of components, commands, and projects or starter projects,
+	// of the parsed devfile, e.g. to protect a server from pathological devfiles. The parsing fails when a devfile exceeds a maximum.
+	// The values are default to 0, which is unlimited.
+	MaxComponents int
+	MaxCommands   int
+	MaxProjects   int
+	// MaxInlinedResourceBytes is the maximum size in bytes of the inlined resource of each Kubernetes and OpenShift component of the
+	// parsed devfile, including the resources inlined from their uri. The value is default to 0, which is unlimited.
+	MaxInlinedResourceBytes int
+	// ParseTimeout bounds the whole parse, including the parents, plugins and resources it resolves. The downloads and clones
+	// in flight are cancelled when the timeout is exceeded, and the parse fails. The registry pulls, which cannot be cancelled,
+	// are left to complete in the background. Context, if set, also cancels the parse.
+	// The value is default to 0, which does not bound the parse.
+	ParseTimeout time.Duration
+	// CommitSHA pins the devfile of URL, the url of a GitHub, GitLab or Bitbucket repo, directory or file, to the full commit id,
+	// so that the parsed devfile cannot change. The devfile, devfile.yaml for a repo or directory url, and the resources it
+	// references relative to its url are fetched at the commit instead of the revision of URL.
+	CommitSHA string
+	// MaxImportDepth is the maximum depth of the chains of parents and plugins imported by the devfile, e.g. 1 for a devfile whose
+	// parent and plugins import no devfile themselves. The parsing fails when a chain exceeds it. Reference cycles fail the parsing
+	// whatever the maximum. The value is default to 0, which is unlimited.
+	MaxImportDepth int
+	// WarnNonPersistentPaths adds a warning, reported like the other warnings of StrictWarnings, for each exec command whose
+	// workingDir is not backed by a volume mount or the project sources of its container, and for each volume mount of a relative
+	// path, see validate.ValidatePersistentPaths. Files written out of the volumes are lost when the container restarts.
+	WarnNonPersistentPaths bool
+	// LenientUnknownFields ignores the top-level fields of the devfile unknown to its JSON schema, e.g. left over from a migration,
+	// instead of failing the schema validation. The fields are removed before the validation, and reported like the other warnings
+	// of StrictWarnings. Unknown fields nested in known fields still fail the validation. Applies to the main devfile only.
+	LenientUnknownFields bool
+}
+
+// commitSHAPattern matches the full SHA-1 or SHA-256 id of a git commit
+var commitSHAPattern = regexp.MustCompile(`^([0-9a-f]{40}|[0-9a-f]{64})$`)
+
+// pinGitURL returns the url of the devfile of the git repo, directory or file url at the commit. A repo or directory url is
+// the url of its devfile.yaml.
+func pinGitURL(rawURL string, commitSHA string) (string, error) {
+	if !commitSHAPattern.MatchString(commitSHA) {
+		return "", fmt.Errorf("invalid commit SHA %q, should be a full commit id", commitSHA)
+	}
+	g, err := git.ParseGitUrl(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to pin the devfile url %s to a commit: %v", rawURL, err)
+	}
+	g.Revision = commitSHA
+	if !g.IsFile {
+		g.Path = path.Join(g.Path, "devfile.yaml")
+		g.IsFile = true
+	}
+	return g.FileURL(), nil
 }
 
 // ImageSelectorArgs defines the structure to leverage for using image names as selectors after parsing the Devfile.
@@ -190,15 +325,78 @@ type ImageSelectorArgs struct {
 	Tag string
 }
 
+// ParentSchemaVersionPolicy defines which parent devfile schemaVersions are compatible with the schemaVersion of the child devfile.
+// A parent schemaVersion greater than the child schemaVersion is never compatible.
+type ParentSchemaVersionPolicy string
+
+const (
+	// ParentSchemaVersionSameMajor accepts parents with the same major schemaVersion as the child
+	ParentSchemaVersionSameMajor ParentSchemaVersionPolicy = "SameMajor"
+	// ParentSchemaVersionSameMinor accepts parents with the same major and minor schemaVersion as the child
+	ParentSchemaVersionSameMinor ParentSchemaVersionPolicy = "SameMinor"
+	// ParentSchemaVersionAny accepts parents with any schemaVersion not greater than the child schemaVersion
+	ParentSchemaVersionAny ParentSchemaVersionPolicy = "Any"
+)
+
+// checkCompatibility returns an error if the parent schemaVersion is not compatible with the child schemaVersion under the policy
+func (p ParentSchemaVersionPolicy) checkCompatibility(parentVersion, childVersion *versionpkg.Version) error {
+	parentSegments, childSegments := parentVersion.Segments(), childVersion.Segments()
+	switch p {
+	case ParentSchemaVersionAny:
+		return nil
+	case "", ParentSchemaVersionSameMajor:
+		if parentSegments[0] != childSegments[0] {
+			return fmt.Errorf("major versions %d and %d differ", parentSegments[0], childSegments[0])
+		}
+	case ParentSchemaVersionSameMinor:
+		if parentSegments[0] != childSegments[0] || parentSegments[1] != childSegments[1] {
+			return fmt.Errorf("minor versions %d.%d and %d.%d differ", parentSegments[0], parentSegments[1], childSegments[0], childSegments[1])
+		}
+	default:
+		return fmt.Errorf("unknown parent schemaVersion policy %s", p)
+	}
+	return nil
+}
+
+// parserArgsSource returns the kind of source of the devfile parsed with the parser args, reported in telemetry events
+func parserArgsSource(args ParserArgs) string {
+	switch {
+	case args.Data != nil:
+		return "data"
+	case args.Path != "":
+		return "path"
+	case args.URL != "":
+		return "url"
+	default:
+		return "none"
+	}
+}
+
 // ParseDevfile func populates the devfile data, parses and validates the devfile integrity.
-// Creates devfile context and runtime objects
+// Creates devfile context and runtime objects. Concurrent calls are safe, each parse creating its own devfile contexts.
 func ParseDevfile(args ParserArgs) (d DevfileObj, err error) {
+	properties := map[string]string{"source": parserArgsSource(args)}
+	telemetry.Emit(telemetry.Event{Type: telemetry.ParseStarted, Properties: properties})
+	defer func(start time.Time) {
+		telemetry.EmitResult(telemetry.ParseSucceeded, telemetry.ParseFailed, start, properties, err)
+	}(time.Now())
+
 	if args.ImageNamesAsSelector != nil && strings.TrimSpace(args.ImageNamesAsSelector.Registry) == "" {
 		return DevfileObj{}, errors.New("registry is mandatory when setting ImageNamesAsSelector in the parser args")
 	}
 
+	if args.CommitSHA != "" {
+		if args.URL == "" {
+			return d, errors.New("a commit SHA requires the URL of the devfile repo in the parser args")
+		}
+		if args.URL, err = pinGitURL(args.URL, args.CommitSHA); err != nil {
+			return d, err
+		}
+	}
+
 	if args.Data != nil {
-		d.Ctx, err = devfileCtx.NewByteContentDevfileCtx(args.Data)
+		d.Ctx.SetStrictYAML(args.StrictYAML)
+		err = d.Ctx.SetDevfileContentFromBytes(args.Data)
 		if err != nil {
 			return d, errors.Wrap(err, "failed to set devfile content from bytes")
 		}
@@ -209,17 +407,47 @@ func ParseDevfile(args ParserArgs) (d DevfileObj, err error) {
 	} else {
 		return d, errors.Wrap(err, "the devfile source is not provided")
 	}
+	d.Ctx.SetStrictYAML(args.StrictYAML)
+	d.Ctx.SetAllowMissingSchema(args.AllowMissingSchema)
+	d.Ctx.SetLenientUnknownFields(args.LenientUnknownFields)
+	d.Ctx.SetUsePreReleaseSchema(args.UsePreReleaseSchema)
+	d.Ctx.SetCaseInsensitiveDevfileName(args.CaseInsensitiveDevfileName)
 
 	if args.Token != "" {
 		d.Ctx.SetToken(args.Token)
 	}
 
+	if args.MaxSchemaVersion != "" {
+		d.Ctx.SetMaxSchemaVersion(args.MaxSchemaVersion)
+	}
+
+	parseContext := args.Context
+	if args.ParseTimeout > 0 {
+		if parseContext == nil {
+			parseContext = context.Background()
+		}
+		var cancel context.CancelFunc
+		parseContext, cancel = context.WithTimeout(parseContext, args.ParseTimeout)
+		defer cancel()
+		defer func() {
+			if err != nil && errors.Is(parseContext.Err(), context.DeadlineExceeded) {
+				err = errors.Wrapf(err, "failed to parse the devfile within the parse timeout of %s", args.ParseTimeout)
+			}
+		}()
+	}
+
 	tool := resolverTools{
-		defaultNamespace: args.DefaultNamespace,
-		registryURLs:     args.RegistryURLs,
-		context:          args.Context,
-		k8sClient:        args.K8sClient,
-		httpTimeout:      args.HTTPTimeout,
+		defaultNamespace:  args.DefaultNamespace,
+		registryURLs:      args.RegistryURLs,
+		context:           parseContext,
+		k8sClient:         args.K8sClient,
+		httpTimeout:       args.HTTPTimeout,
+		pluginParallelism: args.PluginParallelism,
+		downloadLimiter:   newDownloadLimiter(args.MaxConcurrentDownloads),
+		parentPolicy:      args.ParentSchemaVersionPolicy,
+		parents:           &parentRecorder{},
+		trackSource:       args.TrackSourceDevfile,
+		maxImportDepth:    args.MaxImportDepth,
 	}
 
 	flattenedDevfile := true
@@ -231,6 +459,14 @@ func ParseDevfile(args ParserArgs) (d DevfileObj, err error) {
 	if err != nil {
 		return d, errors.Wrap(err, "failed to populateAndParseDevfile")
 	}
+	d.resolvedParents = tool.parents.parents
+	if flattenedDevfile && tool.trackSource {
+		// the elements without a source devfile are defined in the main devfile
+		err = addSourceDevfileAttributes(v1.ImportReference{}, d.Data.GetDevfileWorkspaceSpecContent())
+		if err != nil {
+			return d, err
+		}
+	}
 
 	setBooleanDefaults := true
 	if args.SetBooleanDefaults != nil {
@@ -248,18 +484,48 @@ func ParseDevfile(args ParserArgs) (d DevfileObj, err error) {
 	if args.ConvertKubernetesContentInUri != nil {
 		convertUriToInlined = *args.ConvertKubernetesContentInUri
 	}
+	if args.ConvertKubernetesContentInMemory {
+		convertUriToInlined = true
+	}
 
 	if convertUriToInlined {
 		d.Ctx.SetConvertUriToInlined(true)
-		err = parseKubeResourceFromURI(d)
+		err = parseKubeResourceFromURI(d, tool.downloadLimiter)
 		if err != nil {
 			return d, err
 		}
 	}
 
+	if err = checkLimits(d, args); err != nil {
+		return d, err
+	}
+
 	return d, err
 }
 
+// Flatten parses the devfile and produces a single self-contained devfile: the parents and plugins are
+// resolved and merged, and the Kubernetes and Openshift component uris are replaced by their inlined content.
+// Unlike ParseDevfile, the original uris and the attributes referencing the imported devfiles are not kept,
+// so writing the devfile does not restore the uris.
+// The FlattenedDevfile, ConvertKubernetesContentInUri and ConvertKubernetesContentInMemory parser args are ignored.
+func Flatten(args ParserArgs) (DevfileObj, error) {
+	flattenedDevfile := true
+	convertUriToInlined := true
+	args.FlattenedDevfile = &flattenedDevfile
+	args.ConvertKubernetesContentInUri = &convertUriToInlined
+
+	d, err := ParseDevfile(args)
+	if err != nil {
+		return d, err
+	}
+
+	// drop the references to the imported devfiles and original uris
+	removeSourceAttributes(d.Data.GetDevfileWorkspaceSpecContent())
+	d.Ctx.SetConvertUriToInlined(false)
+
+	return d, nil
+}
+
 // resolverTools contains required structs and data for resolving remote components of a devfile (plugins and parents)
 type resolverTools struct {
 	// DefaultNamespace is the default namespace to use for resolving Kubernetes ImportReferences that do not include one
@@ -273,6 +539,64 @@ type resolverTools struct {
 	k8sClient client.Client
 	// httpTimeout is the timeout value in seconds passed in from the client.
 	httpTimeout *int
+	// pluginParallelism is the maximum number of plugin devfiles resolved concurrently
+	pluginParallelism int
+	// downloadLimiter bounds the number of resources downloaded concurrently across the parse
+	downloadLimiter downloadLimiter
+	// parentPolicy is the policy checking the compatibility of the parent and child devfile schemaVersions
+	parentPolicy ParentSchemaVersionPolicy
+	// parents records the parent devfiles resolved across the parse
+	parents *parentRecorder
+	// trackSource records the source devfile of the elements of the flattened devfile
+	trackSource bool
+	// maxImportDepth is the maximum depth of the chains of imported parents and plugins, 0 for unlimited
+	maxImportDepth int
+}
+
+// parentRecorder records the references of the parent devfiles resolved during a parse. A nil parentRecorder records nothing.
+type parentRecorder struct {
+	lock    sync.Mutex
+	parents []v1.ImportReference
+}
+
+// record records a resolved parent. Parents are resolved depth first, so the parent is recorded before its own parents.
+func (r *parentRecorder) record(parent v1.ImportReference) {
+	if r == nil {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.parents = append([]v1.ImportReference{parent}, r.parents...)
+}
+
+// downloadLimiter is a semaphore bounding the number of concurrent downloads. A nil downloadLimiter does not bound downloads.
+type downloadLimiter chan struct{}
+
+func newDownloadLimiter(maxConcurrentDownloads int) downloadLimiter {
+	if maxConcurrentDownloads <= 0 {
+		return nil
+	}
+	return make(downloadLimiter, maxConcurrentDownloads)
+}
+
+// do runs the download once a download slot is available, unless the context, if not nil, is done first
+func (l downloadLimiter) do(ctx context.Context, download func() error) error {
+	if l != nil {
+		var done <-chan struct{}
+		if ctx != nil {
+			done = ctx.Done()
+		}
+		select {
+		case l <- struct{}{}:
+		case <-done:
+			return ctx.Err()
+		}
+		defer func() { <-l }()
+	}
+	if ctx != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return download()
 }
 
 func populateAndParseDevfile(d DevfileObj, resolveCtx *resolutionContextTree, tool resolverTools, flattenedDevfile bool) (DevfileObj, error) {
@@ -280,9 +604,13 @@ func populateAndParseDevfile(d DevfileObj, resolveCtx *resolutionContextTree, to
 	if err = resolveCtx.hasCycle(); err != nil {
 		return DevfileObj{}, err
 	}
+	if tool.maxImportDepth > 0 && resolveCtx.depth() > tool.maxImportDepth {
+		return DevfileObj{}, fmt.Errorf("the import of %s exceeds the maximum import depth of %d", resolveImportReference(resolveCtx.importReference), tool.maxImportDepth)
+	}
+	d.Ctx.SetRequestContext(tool.context)
 	// Fill the fields of DevfileCtx struct
 	if d.Ctx.GetURL() != "" {
-		err = d.Ctx.PopulateFromURL()
+		err = tool.downloadLimiter.do(tool.context, d.Ctx.PopulateFromURL)
 	} else if d.Ctx.GetDevfileContent() != nil {
 		err = d.Ctx.PopulateFromRaw()
 	} else {
@@ -364,6 +692,16 @@ func parseParentAndPlugin(d DevfileObj, resolveCtx *resolutionContextTree, tool
 			if err != nil {
 				return err
 			}
+			resolvedParent := parent.ImportReference
+			if resolvedParent.Uri != "" {
+				if parentDevfileObj.Ctx.GetURL() != "" {
+					resolvedParent.Uri = parentDevfileObj.Ctx.GetURL()
+				} else if parentDevfileObj.Ctx.GetAbsPath() != "" {
+					resolvedParent.Uri = parentDevfileObj.Ctx.GetAbsPath()
+				}
+			}
+			tool.parents.record(resolvedParent)
+
 			var devfileVersion string
 			if devfileVersion = parentDevfileObj.Ctx.GetApiVersion(); devfileVersion == "" {
 				devfileVersion = parentDevfileObj.Data.GetSchemaVersion()
@@ -377,6 +715,10 @@ func parseParentAndPlugin(d DevfileObj, resolveCtx *resolutionContextTree, tool
 				if parentDevfileVerson.GreaterThan(mainDevfileVersion) {
 					return fmt.Errorf("the parent devfile version from %v is greater than the child devfile version from %v", resolveImportReference(parent.ImportReference), resolveImportReference(resolveCtx.importReference))
 				}
+				if err = tool.parentPolicy.checkCompatibility(parentDevfileVerson, mainDevfileVersion); err != nil {
+					return fmt.Errorf("the parent devfile version %s from %v is not compatible with the child devfile version %s from %v: %v", parentDevfileVerson, resolveImportReference(parent.ImportReference),
+						mainDevfileVersion, resolveImportReference(resolveCtx.importReference), err)
+				}
 			}
 			parentWorkspaceContent := parentDevfileObj.Data.GetDevfileWorkspaceSpecContent()
 			// add attribute to parent elements
@@ -384,6 +726,12 @@ func parseParentAndPlugin(d DevfileObj, resolveCtx *resolutionContextTree, tool
 			if err != nil {
 				return err
 			}
+			if tool.trackSource {
+				err = addSourceDevfileAttributes(parent.ImportReference, parentWorkspaceContent)
+				if err != nil {
+					return err
+				}
+			}
 			if !reflect.DeepEqual(parent.ParentOverrides, v1.ParentOverrides{}) {
 				// add attribute to parentOverrides elements
 				curNodeImportReference := resolveCtx.importReference
@@ -391,6 +739,12 @@ func parseParentAndPlugin(d DevfileObj, resolveCtx *resolutionContextTree, tool
 				if err != nil {
 					return err
 				}
+				if tool.trackSource {
+					err = addSourceDevfileAttributes(curNodeImportReference, &parent.ParentOverrides)
+					if err != nil {
+						return err
+					}
+				}
 				flattenedParent, err = apiOverride.OverrideDevWorkspaceTemplateSpec(parentWorkspaceContent, parent.ParentOverrides)
 				if err != nil {
 					return err
@@ -408,58 +762,65 @@ func parseParentAndPlugin(d DevfileObj, resolveCtx *resolutionContextTree, tool
 	if err != nil {
 		return err
 	}
+	var pluginComponents []v1.Component
 	for _, component := range components {
 		if component.Plugin != nil && !reflect.DeepEqual(component.Plugin, &v1.PluginComponent{}) {
-			plugin := component.Plugin
-			var pluginDevfileObj DevfileObj
-			switch {
-			case plugin.Uri != "":
-				pluginDevfileObj, err = parseFromURI(plugin.ImportReference, d.Ctx, resolveCtx, tool)
-			case plugin.Id != "":
-				pluginDevfileObj, err = parseFromRegistry(plugin.ImportReference, resolveCtx, tool)
-			case plugin.Kubernetes != nil:
-				pluginDevfileObj, err = parseFromKubeCRD(plugin.ImportReference, resolveCtx, tool)
-			default:
-				return fmt.Errorf("plugin %s does not define any resources", component.Name)
-			}
+			pluginComponents = append(pluginComponents, component)
+		}
+	}
+	pluginDevfileObjs, err := parsePlugins(pluginComponents, d.Ctx, resolveCtx, tool)
+	if err != nil {
+		return err
+	}
+	for i, component := range pluginComponents {
+		plugin := component.Plugin
+		pluginDevfileObj := pluginDevfileObjs[i]
+		var devfileVersion string
+		if devfileVersion = pluginDevfileObj.Ctx.GetApiVersion(); devfileVersion == "" {
+			devfileVersion = pluginDevfileObj.Data.GetSchemaVersion()
+		}
+
+		if devfileVersion != "" {
+			pluginDevfileVerson, err = versionpkg.NewVersion(devfileVersion)
 			if err != nil {
-				return err
+				return fmt.Errorf("fail to parse version of plugin devfile from: %v", resolveImportReference(component.Plugin.ImportReference))
 			}
-			var devfileVersion string
-			if devfileVersion = pluginDevfileObj.Ctx.GetApiVersion(); devfileVersion == "" {
-				devfileVersion = pluginDevfileObj.Data.GetSchemaVersion()
+			if pluginDevfileVerson.GreaterThan(mainDevfileVersion) {
+				return fmt.Errorf("the plugin devfile version from %v is greater than the child devfile version from %v", resolveImportReference(component.Plugin.ImportReference), resolveImportReference(resolveCtx.importReference))
 			}
-
-			if devfileVersion != "" {
-				pluginDevfileVerson, err = versionpkg.NewVersion(devfileVersion)
-				if err != nil {
-					return fmt.Errorf("fail to parse version of plugin devfile from: %v", resolveImportReference(component.Plugin.ImportReference))
-				}
-				if pluginDevfileVerson.GreaterThan(mainDevfileVersion) {
-					return fmt.Errorf("the plugin devfile version from %v is greater than the child devfile version from %v", resolveImportReference(component.Plugin.ImportReference), resolveImportReference(resolveCtx.importReference))
-				}
+		}
+		pluginWorkspaceContent := pluginDevfileObj.Data.GetDevfileWorkspaceSpecContent()
+		// add attribute to plugin elements
+		err = addSourceAttributesForOverrideAndMerge(plugin.ImportReference, pluginWorkspaceContent)
+		if err != nil {
+			return err
+		}
+		if tool.trackSource {
+			err = addSourceDevfileAttributes(plugin.ImportReference, pluginWorkspaceContent)
+			if err != nil {
+				return err
 			}
-			pluginWorkspaceContent := pluginDevfileObj.Data.GetDevfileWorkspaceSpecContent()
-			// add attribute to plugin elements
-			err = addSourceAttributesForOverrideAndMerge(plugin.ImportReference, pluginWorkspaceContent)
+		}
+		flattenedPlugin := pluginWorkspaceContent
+		if !reflect.DeepEqual(plugin.PluginOverrides, v1.PluginOverrides{}) {
+			// add attribute to pluginOverrides elements
+			curNodeImportReference := resolveCtx.importReference
+			err = addSourceAttributesForOverrideAndMerge(curNodeImportReference, &plugin.PluginOverrides)
 			if err != nil {
 				return err
 			}
-			flattenedPlugin := pluginWorkspaceContent
-			if !reflect.DeepEqual(plugin.PluginOverrides, v1.PluginOverrides{}) {
-				// add attribute to pluginOverrides elements
-				curNodeImportReference := resolveCtx.importReference
-				err = addSourceAttributesForOverrideAndMerge(curNodeImportReference, &plugin.PluginOverrides)
-				if err != nil {
-					return err
-				}
-				flattenedPlugin, err = apiOverride.OverrideDevWorkspaceTemplateSpec(pluginWorkspaceContent, plugin.PluginOverrides)
+			if tool.trackSource {
+				err = addSourceDevfileAttributes(curNodeImportReference, &plugin.PluginOverrides)
 				if err != nil {
 					return err
 				}
 			}
-			flattenedPlugins = append(flattenedPlugins, flattenedPlugin)
+			flattenedPlugin, err = apiOverride.OverrideDevWorkspaceTemplateSpec(pluginWorkspaceContent, plugin.PluginOverrides)
+			if err != nil {
+				return err
+			}
 		}
+		flattenedPlugins = append(flattenedPlugins, flattenedPlugin)
 	}
 
 	mergedContent, err := apiOverride.MergeDevWorkspaceTemplateSpec(d.Data.GetDevfileWorkspaceSpecContent(), flattenedParent, flattenedPlugins...)
@@ -473,6 +834,68 @@ func parseParentAndPlugin(d DevfileObj, resolveCtx *resolutionContextTree, tool
 	return nil
 }
 
+// parsePlugins resolves the devfiles of the plugin components, returned in the order of the components.
+// Up to tool.pluginParallelism plugins are resolved concurrently, in which case the errors of all
+// plugins that failed are aggregated in the order of the components.
+func parsePlugins(components []v1.Component, curDevfileCtx devfileCtx.DevfileCtx, resolveCtx *resolutionContextTree, tool resolverTools) ([]DevfileObj, error) {
+	pluginDevfileObjs := make([]DevfileObj, len(components))
+
+	if tool.pluginParallelism <= 1 {
+		for i, component := range components {
+			pluginDevfileObj, err := parsePlugin(component, curDevfileCtx, resolveCtx, tool)
+			if err != nil {
+				return nil, err
+			}
+			pluginDevfileObjs[i] = pluginDevfileObj
+		}
+		return pluginDevfileObjs, nil
+	}
+
+	pluginErrs := make([]error, len(components))
+	semaphore := make(chan struct{}, tool.pluginParallelism)
+	var wg sync.WaitGroup
+	for i := range components {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+			pluginDevfileObjs[i], pluginErrs[i] = parsePlugin(components[i], curDevfileCtx, resolveCtx, tool)
+		}(i)
+	}
+	wg.Wait()
+
+	var returnedErr *multierror.Error
+	for _, err := range pluginErrs {
+		if err != nil {
+			returnedErr = multierror.Append(returnedErr, err)
+		}
+	}
+	if returnedErr != nil {
+		return nil, returnedErr
+	}
+	return pluginDevfileObjs, nil
+}
+
+// parsePlugin resolves the devfile of a plugin component
+func parsePlugin(component v1.Component, curDevfileCtx devfileCtx.DevfileCtx, resolveCtx *resolutionContextTree, tool resolverTools) (DevfileObj, error) {
+	plugin := component.Plugin
+	switch {
+	case plugin.Uri != "":
+		return parseFromURI(plugin.ImportReference, curDevfileCtx, resolveCtx, tool)
+	case plugin.Id != "":
+		return parseFromRegistry(plugin.ImportReference, resolveCtx, tool)
+	case plugin.Kubernetes != nil:
+		return parseFromKubeCRD(plugin.ImportReference, resolveCtx, tool)
+	default:
+		return DevfileObj{}, fmt.Errorf("plugin %s does not define any resources", component.Name)
+	}
+}
+
+// parseFromURI parses the devfile of the uri of the import reference. A relative uri is resolved against the directory of the
+// devfile importing it, its absolute path on disk or its url, so that each parent of a chain is resolved relative to its child.
 func parseFromURI(importReference v1.ImportReference, curDevfileCtx devfileCtx.DevfileCtx, resolveCtx *resolutionContextTree, tool resolverTools) (DevfileObj, error) {
 	uri := importReference.Uri
 	// validate URI
@@ -523,7 +946,13 @@ func parseFromURI(importReference v1.ImportReference, curDevfileCtx devfileCtx.D
 		}
 
 		destDir := path.Dir(curDevfileCtx.GetAbsPath())
-		err = downloadGitRepoResources(newUri, destDir, tool.httpTimeout, token)
+		destFs := curDevfileCtx.GetFs()
+		if destFs == nil {
+			destFs = filesystem.DefaultFs{}
+		}
+		err = tool.downloadLimiter.do(tool.context, func() error {
+			return downloadGitRepoResources(tool.context, newUri, destDir, tool.httpTimeout, token, destFs)
+		})
 		if err != nil {
 			return DevfileObj{}, err
 		}
@@ -540,7 +969,7 @@ func parseFromRegistry(importReference v1.ImportReference, resolveCtx *resolutio
 	destDir := path.Dir(d.Ctx.GetAbsPath())
 
 	if registryURL != "" {
-		devfileContent, err := getDevfileFromRegistry(id, registryURL, importReference.Version, tool.httpTimeout)
+		devfileContent, err := getDevfileFromRegistry(id, registryURL, importReference.Version, tool)
 		if err != nil {
 			return DevfileObj{}, err
 		}
@@ -550,7 +979,9 @@ func parseFromRegistry(importReference v1.ImportReference, resolveCtx *resolutio
 		}
 		newResolveCtx := resolveCtx.appendNode(importReference)
 
-		err = getResourcesFromRegistry(id, registryURL, destDir)
+		err = tool.downloadLimiter.do(tool.context, func() error {
+			return getResourcesFromRegistry(tool.context, id, registryURL, destDir)
+		})
 		if err != nil {
 			return DevfileObj{}, err
 		}
@@ -559,7 +990,7 @@ func parseFromRegistry(importReference v1.ImportReference, resolveCtx *resolutio
 
 	} else if tool.registryURLs != nil {
 		for _, registryURL := range tool.registryURLs {
-			devfileContent, err := getDevfileFromRegistry(id, registryURL, importReference.Version, tool.httpTimeout)
+			devfileContent, err := getDevfileFromRegistry(id, registryURL, importReference.Version, tool)
 			if devfileContent != nil && err == nil {
 				d.Ctx, err = devfileCtx.NewByteContentDevfileCtx(devfileContent)
 				if err != nil {
@@ -568,7 +999,9 @@ func parseFromRegistry(importReference v1.ImportReference, resolveCtx *resolutio
 				importReference.RegistryUrl = registryURL
 				newResolveCtx := resolveCtx.appendNode(importReference)
 
-				err := getResourcesFromRegistry(id, registryURL, destDir)
+				err := tool.downloadLimiter.do(tool.context, func() error {
+					return getResourcesFromRegistry(tool.context, id, registryURL, destDir)
+				})
 				if err != nil {
 					return DevfileObj{}, err
 				}
@@ -583,7 +1016,7 @@ func parseFromRegistry(importReference v1.ImportReference, resolveCtx *resolutio
 	return DevfileObj{}, fmt.Errorf("failed to get id: %s from registry URLs provided", id)
 }
 
-func getDevfileFromRegistry(id, registryURL, version string, httpTimeout *int) ([]byte, error) {
+func getDevfileFromRegistry(id, registryURL, version string, tool resolverTools) (devfileContent []byte, err error) {
 	if !strings.HasPrefix(registryURL, "http://") && !strings.HasPrefix(registryURL, "https://") {
 		return nil, fmt.Errorf("the provided registryURL: %s is not a valid URL", registryURL)
 	}
@@ -591,20 +1024,45 @@ func getDevfileFromRegistry(id, registryURL, version string, httpTimeout *int) (
 		URL: fmt.Sprintf("%s/devfiles/%s/%s", registryURL, id, version),
 	}
 
-	param.Timeout = httpTimeout
+	param.Timeout = tool.httpTimeout
+	param.Context = tool.context
 	//suppress telemetry for parent uri references
 	param.TelemetryClientName = util.TelemetryIndirectDevfileCall
-	return util.HTTPGetRequest(param, 0)
+	err = tool.downloadLimiter.do(tool.context, func() error {
+		devfileContent, err = util.HTTPGetRequest(param, 0)
+		return err
+	})
+	return devfileContent, err
 }
 
-func getResourcesFromRegistry(id, registryURL, destDir string) error {
+// getResourcesFromRegistry pulls the stack of the registry and copies its resources into destDir, unless the context, if not nil,
+// is done first
+var getResourcesFromRegistry = func(ctx context.Context, id, registryURL, destDir string) error {
 	stackDir, err := ioutil.TempDir(os.TempDir(), fmt.Sprintf("registry-resources-%s", id))
 	if err != nil {
 		return fmt.Errorf("failed to create dir: %s, error: %v", stackDir, err)
 	}
-	defer os.RemoveAll(stackDir)
-	//suppress telemetry for downloading resources from parent reference
-	err = registryLibrary.PullStackFromRegistry(registryURL, id, stackDir, registryLibrary.RegistryOptions{Telemetry: registryLibrary.TelemetryData{Client: util.TelemetryIndirectDevfileCall}})
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	// the registry library cannot cancel a pull, so a pull outliving the context completes in the background before its
+	// stack dir is removed
+	pulled := make(chan error, 1)
+	go func() {
+		//suppress telemetry for downloading resources from parent reference
+		pulled <- registryLibrary.PullStackFromRegistry(registryURL, id, stackDir, registryLibrary.RegistryOptions{Telemetry: registryLibrary.TelemetryData{Client: util.TelemetryIndirectDevfileCall}})
+	}()
+	select {
+	case err = <-pulled:
+		defer os.RemoveAll(stackDir)
+	case <-ctx.Done():
+		go func() {
+			<-pulled
+			_ = os.RemoveAll(stackDir)
+		}()
+		return ctx.Err()
+	}
 	if err != nil {
 		return fmt.Errorf("failed to pull stack from registry %s", registryURL)
 	}
@@ -793,8 +1251,9 @@ func setEndpoints(endpoints []v1.Endpoint) {
 	}
 }
 
-// parseKubeResourceFromURI iterate through all kubernetes & openshift components, and parse from uri and update the content to inlined field in devfileObj
-func parseKubeResourceFromURI(devObj DevfileObj) error {
+// parseKubeResourceFromURI iterate through all kubernetes & openshift components, and parse from uri and update the content to inlined field in devfileObj.
+// The uris are downloaded concurrently when the download limiter allows more than one download at a time.
+func parseKubeResourceFromURI(devObj DevfileObj, limiter downloadLimiter) error {
 	getKubeCompOptions := common.DevfileOptions{
 		ComponentOptions: common.ComponentOptions{
 			ComponentType: v1.KubernetesComponentType,
@@ -813,30 +1272,53 @@ func parseKubeResourceFromURI(devObj DevfileObj) error {
 	if err != nil {
 		return err
 	}
+	var uriComponents []v1.Component
 	for _, kubeComp := range kubeComponents {
 		if kubeComp.Kubernetes != nil && kubeComp.Kubernetes.Uri != "" {
-			/* #nosec G601 -- not an issue, kubeComp is de-referenced in sequence*/
-			err := convertK8sLikeCompUriToInlined(&kubeComp, devObj.Ctx)
-			if err != nil {
-				return errors.Wrapf(err, "failed to convert kubernetes uri to inlined for component '%s'", kubeComp.Name)
-			}
-			err = devObj.Data.UpdateComponent(kubeComp)
-			if err != nil {
-				return err
-			}
+			uriComponents = append(uriComponents, kubeComp)
 		}
 	}
 	for _, openshiftComp := range openshiftComponents {
 		if openshiftComp.Openshift != nil && openshiftComp.Openshift.Uri != "" {
-			/* #nosec G601 -- not an issue, openshiftComp is de-referenced in sequence*/
-			err := convertK8sLikeCompUriToInlined(&openshiftComp, devObj.Ctx)
-			if err != nil {
-				return errors.Wrapf(err, "failed to convert openshift uri to inlined for component '%s'", openshiftComp.Name)
+			uriComponents = append(uriComponents, openshiftComp)
+		}
+	}
+
+	convertErrs := make([]error, len(uriComponents))
+	convert := func(i int) {
+		convertErrs[i] = limiter.do(devObj.Ctx.GetRequestContext(), func() error {
+			return convertK8sLikeCompUriToInlined(&uriComponents[i], devObj.Ctx)
+		})
+	}
+	if cap(limiter) > 1 {
+		var wg sync.WaitGroup
+		for i := range uriComponents {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				convert(i)
+			}(i)
+		}
+		wg.Wait()
+	} else {
+		for i := range uriComponents {
+			if convert(i); convertErrs[i] != nil {
+				break
 			}
-			err = devObj.Data.UpdateComponent(openshiftComp)
-			if err != nil {
-				return err
+		}
+	}
+
+	for i, component := range uriComponents {
+		if convertErrs[i] != nil {
+			componentType := "kubernetes"
+			if component.Openshift != nil {
+				componentType = "openshift"
 			}
+			return errors.Wrapf(convertErrs[i], "failed to convert %s uri to inlined for component '%s'", componentType, component.Name)
+		}
+		err = devObj.Data.UpdateComponent(component)
+		if err != nil {
+			return err
 		}
 	}
 	return nil
@@ -869,6 +1351,39 @@ func convertK8sLikeCompUriToInlined(component *v1.Component, d devfileCtx.Devfil
 	return nil
 }
 
+// selfReferenceError is the error of a kubernetes or openshift component uri resolving to the devfile defining the component,
+// which would inline the devfile itself as the kubernetes resources definition of the component
+func selfReferenceError(uri string, resolvedUri string) error {
+	return fmt.Errorf("failed to get kubernetes resources definition from uri %s, the uri resolves to the devfile itself: %s", uri, resolvedUri)
+}
+
+// isSameURL checks if the urls are the same, once their paths are cleaned
+func isSameURL(first string, second string) bool {
+	firstURL, err := url.Parse(first)
+	if err != nil {
+		return false
+	}
+	secondURL, err := url.Parse(second)
+	if err != nil {
+		return false
+	}
+	firstURL.Path, secondURL.Path = path.Clean(firstURL.Path), path.Clean(secondURL.Path)
+	return firstURL.String() == secondURL.String()
+}
+
+// isSameHost checks if the urls have the same scheme and host
+func isSameHost(first string, second string) bool {
+	firstURL, err := url.Parse(first)
+	if err != nil {
+		return false
+	}
+	secondURL, err := url.Parse(second)
+	if err != nil {
+		return false
+	}
+	return firstURL.Scheme == secondURL.Scheme && firstURL.Host == secondURL.Host
+}
+
 // getKubernetesDefinitionFromUri read in kubernetes resources definition from uri and returns the raw content
 func getKubernetesDefinitionFromUri(uri string, d devfileCtx.DevfileCtx) ([]byte, error) {
 	// validate URI
@@ -883,6 +1398,9 @@ func getKubernetesDefinitionFromUri(uri string, d devfileCtx.DevfileCtx) ([]byte
 	// relative path on disk
 	if !absoluteURL && d.GetAbsPath() != "" {
 		newUri = path.Join(path.Dir(d.GetAbsPath()), uri)
+		if path.Clean(newUri) == path.Clean(d.GetAbsPath()) {
+			return nil, selfReferenceError(uri, newUri)
+		}
 		fs := d.GetFs()
 		data, err = fs.ReadFile(newUri)
 		if err != nil {
@@ -901,10 +1419,17 @@ func getKubernetesDefinitionFromUri(uri string, d devfileCtx.DevfileCtx) ([]byte
 			// absolute URL address
 			newUri = uri
 		}
-		params := util.HTTPRequestParams{URL: newUri}
+		if d.GetURL() != "" && isSameURL(newUri, d.GetURL()) {
+			return nil, selfReferenceError(uri, newUri)
+		}
+		params := util.HTTPRequestParams{URL: newUri, Context: d.GetRequestContext()}
 		if d.GetToken() != "" {
 			params.Token = d.GetToken()
 		}
+		// the basic auth credentials of the devfile url are only sent back to the host of the devfile
+		if d.GetURLCredentials() != nil && isSameHost(newUri, d.GetURL()) {
+			params.BasicAuth = d.GetURLCredentials()
+		}
 		data, err = util.DownloadInMemory(params)
 		if err != nil {
 			return nil, errors.Wrapf(err, "error getting kubernetes resources definition information")
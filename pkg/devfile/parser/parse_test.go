@@ -18,6 +18,7 @@ package parser
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net"
@@ -28,7 +29,9 @@ import (
 	"path/filepath"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	v1 "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
 	"github.com/devfile/library/v2/pkg/git"
@@ -39,7 +42,10 @@ import (
 	"github.com/devfile/library/v2/pkg/devfile/parser/data"
 	v2 "github.com/devfile/library/v2/pkg/devfile/parser/data/v2"
 	"github.com/devfile/library/v2/pkg/devfile/parser/data/v2/common"
+	"github.com/devfile/library/v2/pkg/telemetry"
 	"github.com/devfile/library/v2/pkg/testingutil"
+	"github.com/devfile/library/v2/pkg/testingutil/filesystem"
+	versionpkg "github.com/hashicorp/go-version"
 	"github.com/kylelemons/godebug/pretty"
 	"github.com/stretchr/testify/assert"
 	kubev1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -4422,8 +4428,8 @@ func validateGitResourceFunctions(t *testing.T, wantFiles []string, wantResource
 	}
 }
 
-func mockDownloadGitRepoResources(gURL *git.GitUrl, mockToken string) func(url string, destDir string, httpTimeout *int, token string) error {
-	return func(url string, destDir string, httpTimeout *int, token string) error {
+func mockDownloadGitRepoResources(gURL *git.GitUrl, mockToken string) func(ctx context.Context, url string, destDir string, httpTimeout *int, token string, destFs filesystem.Filesystem) error {
+	return func(ctx context.Context, url string, destDir string, httpTimeout *int, token string, destFs filesystem.Filesystem) error {
 		// this converts the real git URL to a mock URL
 		mockGitUrl := git.MockGitUrl{
 			Protocol: gURL.Protocol,
@@ -4440,7 +4446,7 @@ func mockDownloadGitRepoResources(gURL *git.GitUrl, mockToken string) func(url s
 				return fmt.Errorf("error getting devfile from url: failed to retrieve %s", url+"/"+mockGitUrl.Path)
 			}
 
-			stackDir, err := os.MkdirTemp("", fmt.Sprintf("git-resources"))
+			stackDir, err := newGitResourcesDir(mockGitUrl.Repo)
 			if err != nil {
 				return fmt.Errorf("failed to create dir: %s, error: %v", stackDir, err)
 			}
@@ -4462,7 +4468,7 @@ func mockDownloadGitRepoResources(gURL *git.GitUrl, mockToken string) func(url s
 				return err
 			}
 
-			err = git.CopyAllDirFiles(stackDir, destDir)
+			err = git.CopyAllDirFilesToFs(stackDir, destDir, destFs)
 			if err != nil {
 				return err
 			}
@@ -4863,7 +4869,7 @@ func Test_DownloadGitRepoResources(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			destDir := t.TempDir()
 			downloadGitRepoResources = mockDownloadGitRepoResources(&tt.gitUrl, tt.token)
-			err := downloadGitRepoResources(tt.url, destDir, &httpTimeout, tt.token)
+			err := downloadGitRepoResources(context.Background(), tt.url, destDir, &httpTimeout, tt.token, filesystem.DefaultFs{})
 			if (err != nil) && (tt.wantErr != true) {
 				t.Errorf("Unexpected error = %v", err)
 			} else if tt.wantErr == true {
@@ -4997,6 +5003,7 @@ spec:
 	}
 
 	URLDevfileCtx := devfileCtx.NewURLDevfileCtx(httpPrefix + uri1)
+	devfileURLDevfileCtx := devfileCtx.NewURLDevfileCtx(httpPrefix + uri1 + "/dir/devfile.yaml")
 
 	rawContent := `
 schemaVersion: 2.2.0
@@ -5058,6 +5065,7 @@ commands:
 	notAbleToResolveURIErr := "error getting kubernetes resources definition information, unable to resolve the file uri.*"
 	invalidPathErr := "failed to read kubernetes resources definition from path.*"
 	invalidURLErr := "error getting kubernetes resources definition information"
+	selfReferenceErr := "failed to get kubernetes resources definition from uri .*, the uri resolves to the devfile itself.*"
 
 	tests := []struct {
 		name        string
@@ -5120,6 +5128,24 @@ commands:
 			uri:        httpPrefix + uri1 + "/notexist/deploy.yaml",
 			wantErr:    &invalidURLErr,
 		},
+		{
+			name:       "should fail with relative uri resolving to the local devfile itself",
+			devfileCtx: localDevfileCtx,
+			uri:        "./devfile.yaml",
+			wantErr:    &selfReferenceErr,
+		},
+		{
+			name:       "should fail with relative uri resolving to the remote devfile itself",
+			devfileCtx: devfileURLDevfileCtx,
+			uri:        "../dir/devfile.yaml",
+			wantErr:    &selfReferenceErr,
+		},
+		{
+			name:        "should be able to parse from relative uri next to the remote devfile",
+			devfileCtx:  devfileURLDevfileCtx,
+			uri:         "deploy.yaml",
+			wantContent: deployContent,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -5135,6 +5161,60 @@ commands:
 	}
 }
 
+func Test_getKubernetesDefinitionFromUri_BasicAuth(t *testing.T) {
+	const deployContent = "kind: Deployment\napiVersion: apps/v1\n"
+	newServer := func(wantBasicAuth bool) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+				t.Errorf("Test_getKubernetesDefinitionFromUri_BasicAuth() got a bearer token: %s", r.Header.Get("Authorization"))
+			}
+			username, password, ok := r.BasicAuth()
+			if wantBasicAuth && (!ok || username != "user" || password != "pass") {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if !wantBasicAuth && r.Header.Get("Authorization") != "" {
+				t.Errorf("Test_getKubernetesDefinitionFromUri_BasicAuth() got credentials sent to another host: %s", r.Header.Get("Authorization"))
+			}
+			if _, err := w.Write([]byte(deployContent)); err != nil {
+				t.Error(err)
+			}
+		}))
+	}
+	devfileServer := newServer(true)
+	defer devfileServer.Close()
+	otherServer := newServer(false)
+	defer otherServer.Close()
+
+	devfileURL := strings.Replace(devfileServer.URL, "http://", "http://user:pass@", 1) + "/dir/devfile.yaml"
+	tests := []struct {
+		name string
+		uri  string
+	}{
+		{
+			name: "should send the basic auth of the devfile url with a relative uri",
+			uri:  "deploy.yaml",
+		},
+		{
+			name: "should send the basic auth of the devfile url with an absolute uri of the devfile host",
+			uri:  devfileServer.URL + "/dir/deploy.yaml",
+		},
+		{
+			name: "should not send the basic auth of the devfile url to another host",
+			uri:  otherServer.URL + "/deploy.yaml",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getKubernetesDefinitionFromUri(tt.uri, devfileCtx.NewURLDevfileCtx(devfileURL))
+			if err != nil {
+				t.Fatalf("Test_getKubernetesDefinitionFromUri_BasicAuth() unexpected error: %v", err)
+			}
+			assert.Equal(t, deployContent, string(got), "Test_getKubernetesDefinitionFromUri_BasicAuth() error: the deploy content should matched")
+		})
+	}
+}
+
 // getUnsetBooleanDevfileObj returns a DevfileData object that contains unset boolean properties
 func getUnsetBooleanDevfileTestData(apiVersion string) (devfileData data.DevfileData, err error) {
 	devfileData = &v2.DevfileV2{
@@ -5409,3 +5489,1567 @@ func getBooleanDevfileTestData(apiVersion string, setDefault bool) (devfileData
 
 	return devfileData, err
 }
+
+func Test_parseParentAndPlugin_PluginParallelism(t *testing.T) {
+	const pluginCount = 3
+
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+
+		// hold the request so that concurrent plugin fetches overlap
+		time.Sleep(200 * time.Millisecond)
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+
+		pluginName := strings.TrimPrefix(r.URL.Path, "/")
+		_, err := w.Write([]byte(fmt.Sprintf("schemaVersion: 2.2.0\ncomponents:\n- name: %s-container\n  container:\n    image: %s:latest\n", pluginName, pluginName)))
+		if err != nil {
+			t.Errorf("Test_parseParentAndPlugin_PluginParallelism() unexpected error while writing data: %v", err)
+		}
+	}))
+	defer testServer.Close()
+
+	// newDevfileObj returns a devfile with a plugin component for each of the plugin uris
+	newDevfileObj := func(pluginUris ...string) DevfileObj {
+		var components []v1.Component
+		for i, uri := range pluginUris {
+			components = append(components, v1.Component{
+				Name: fmt.Sprintf("plugin%d", i+1),
+				ComponentUnion: v1.ComponentUnion{
+					Plugin: &v1.PluginComponent{
+						ImportReference: v1.ImportReference{
+							ImportReferenceUnion: v1.ImportReferenceUnion{
+								Uri: uri,
+							},
+						},
+					},
+				},
+			})
+		}
+		return DevfileObj{
+			Ctx: devfileCtx.NewDevfileCtx(OutputDevfileYamlPath),
+			Data: &v2.DevfileV2{
+				Devfile: v1.Devfile{
+					DevfileHeader: devfilepkg.DevfileHeader{
+						SchemaVersion: schemaVersion,
+					},
+					DevWorkspaceTemplateSpec: v1.DevWorkspaceTemplateSpec{
+						DevWorkspaceTemplateSpecContent: v1.DevWorkspaceTemplateSpecContent{
+							Components: components,
+						},
+					},
+				},
+			},
+		}
+	}
+
+	originalDownloadGitRepoResources := downloadGitRepoResources
+	defer func() { downloadGitRepoResources = originalDownloadGitRepoResources }()
+	downloadGitRepoResources = mockDownloadGitRepoResources(&git.GitUrl{}, "")
+
+	var pluginUris []string
+	for i := 1; i <= pluginCount; i++ {
+		pluginUris = append(pluginUris, fmt.Sprintf("%s/plugin%d", testServer.URL, i))
+	}
+
+	tests := []struct {
+		name              string
+		pluginParallelism int
+		wantMaxInFlight   int
+	}{
+		{
+			name:            "should resolve plugins sequentially by default",
+			wantMaxInFlight: 1,
+		},
+		{
+			name:              "should resolve plugins concurrently",
+			pluginParallelism: pluginCount,
+			wantMaxInFlight:   pluginCount,
+		},
+		{
+			name:              "should bound the number of plugins resolved concurrently",
+			pluginParallelism: 2,
+			wantMaxInFlight:   2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			maxInFlight = 0
+			d := newDevfileObj(pluginUris...)
+			err := parseParentAndPlugin(d, &resolutionContextTree{}, resolverTools{pluginParallelism: tt.pluginParallelism})
+			if err != nil {
+				t.Fatalf("Test_parseParentAndPlugin_PluginParallelism() unexpected error: %v", err)
+			}
+			if maxInFlight != tt.wantMaxInFlight {
+				t.Errorf("Test_parseParentAndPlugin_PluginParallelism() error: wanted %d concurrent plugin fetches, got %d", tt.wantMaxInFlight, maxInFlight)
+			}
+
+			containers, err := d.Data.GetComponents(common.DevfileOptions{ComponentOptions: common.ComponentOptions{ComponentType: v1.ContainerComponentType}})
+			if err != nil {
+				t.Fatalf("Test_parseParentAndPlugin_PluginParallelism() unexpected error: %v", err)
+			}
+			var gotNames []string
+			for _, container := range containers {
+				gotNames = append(gotNames, container.Name)
+			}
+			assert.Equal(t, []string{"plugin1-container", "plugin2-container", "plugin3-container"}, gotNames, "plugin components should be merged in order")
+		})
+	}
+
+	t.Run("should aggregate the errors of failed plugins in order", func(t *testing.T) {
+		d := newDevfileObj(pluginUris[0], "http://127.0.0.1:0/missing1", "http://127.0.0.1:0/missing2")
+		err := parseParentAndPlugin(d, &resolutionContextTree{}, resolverTools{pluginParallelism: pluginCount})
+		if err == nil {
+			t.Fatalf("Test_parseParentAndPlugin_PluginParallelism() expected an error, didn't get one")
+		}
+		assert.Regexp(t, "2 errors occurred:\n\t\\* .*missing1.*\n\t\\* .*missing2", err.Error(), "Error message should match")
+	})
+}
+
+func Test_ParseDevfile_MaxSchemaVersion(t *testing.T) {
+	devfileWithVersion := func(version string) []byte {
+		return []byte(fmt.Sprintf("schemaVersion: %s\nmetadata:\n  name: max-schema-version\n", version))
+	}
+
+	exceedsMaxErr := "devfile schemaVersion 2.2.0 exceeds maximum supported 2.1.0"
+	exceedsMaxUnknownErr := "devfile schemaVersion 2.9.0 exceeds maximum supported 2.2.0"
+	invalidMaxErr := "fail to parse maximum supported schemaVersion invalid"
+
+	tests := []struct {
+		name             string
+		devfileData      []byte
+		maxSchemaVersion string
+		wantErr          *string
+	}{
+		{
+			name:        "should accept any schemaVersion if the maximum is not set",
+			devfileData: devfileWithVersion("2.2.0"),
+		},
+		{
+			name:             "should accept a schemaVersion equal to the maximum",
+			devfileData:      devfileWithVersion("2.2.0"),
+			maxSchemaVersion: "2.2.0",
+		},
+		{
+			name:             "should accept a schemaVersion lower than the maximum",
+			devfileData:      devfileWithVersion("2.1.0"),
+			maxSchemaVersion: "2.2.0",
+		},
+		{
+			name:             "should reject a schemaVersion higher than the maximum",
+			devfileData:      devfileWithVersion("2.2.0"),
+			maxSchemaVersion: "2.1.0",
+			wantErr:          &exceedsMaxErr,
+		},
+		{
+			name:             "should reject an unknown schemaVersion higher than the maximum before schema validation",
+			devfileData:      devfileWithVersion("2.9.0"),
+			maxSchemaVersion: "2.2.0",
+			wantErr:          &exceedsMaxUnknownErr,
+		},
+		{
+			name:             "should fail with an invalid maximum schemaVersion",
+			devfileData:      devfileWithVersion("2.2.0"),
+			maxSchemaVersion: "invalid",
+			wantErr:          &invalidMaxErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseDevfile(ParserArgs{
+				Data:             tt.devfileData,
+				MaxSchemaVersion: tt.maxSchemaVersion,
+			})
+			if (err != nil) != (tt.wantErr != nil) {
+				t.Errorf("Test_ParseDevfile_MaxSchemaVersion() unexpected error: %v, wantErr %v", err, tt.wantErr)
+			} else if err != nil {
+				assert.Regexp(t, *tt.wantErr, err.Error(), "Error message should match")
+			}
+		})
+	}
+}
+
+func Test_ParseDevfile_StrictYAML(t *testing.T) {
+	devfileData := []byte(`schemaVersion: 2.2.0
+metadata:
+  name: strict-yaml
+components:
+- name: runtime
+  container:
+    image: nodejs
+components:
+- name: tools
+  container:
+    image: golang
+`)
+
+	duplicateKeyErr := "failed to set devfile content from bytes: failed to convert devfile yaml to json(.|\n)*key \"components\" already set in map"
+
+	tests := []struct {
+		name           string
+		strictYAML     bool
+		wantComponents []string
+		wantErr        *string
+	}{
+		{
+			name:           "should keep the last duplicate key by default",
+			wantComponents: []string{"tools"},
+		},
+		{
+			name:       "should reject duplicate keys with StrictYAML",
+			strictYAML: true,
+			wantErr:    &duplicateKeyErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := ParseDevfile(ParserArgs{Data: devfileData, StrictYAML: tt.strictYAML})
+			if (err != nil) != (tt.wantErr != nil) {
+				t.Fatalf("Test_ParseDevfile_StrictYAML() unexpected error: %v, wantErr %v", err, tt.wantErr)
+			} else if err != nil {
+				assert.Regexp(t, *tt.wantErr, err.Error(), "Error message should match")
+				return
+			}
+			components, err := d.Data.GetComponents(common.DevfileOptions{})
+			if err != nil {
+				t.Fatalf("Test_ParseDevfile_StrictYAML() unexpected error: %v", err)
+			}
+			var gotComponents []string
+			for _, component := range components {
+				gotComponents = append(gotComponents, component.Name)
+			}
+			if !reflect.DeepEqual(gotComponents, tt.wantComponents) {
+				t.Errorf("Got: %v, want: %v", gotComponents, tt.wantComponents)
+			}
+		})
+	}
+}
+
+func Test_Flatten(t *testing.T) {
+	const deployment = `kind: Deployment
+apiVersion: apps/v1
+metadata:
+  name: flattened
+`
+
+	var parentDevfile string
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var data string
+		switch r.URL.Path {
+		case "/parent/devfile.yaml":
+			data = parentDevfile
+		case "/deploy.yaml":
+			data = deployment
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, err := w.Write([]byte(data))
+		if err != nil {
+			t.Errorf("Test_Flatten() unexpected error while writing data: %v", err)
+		}
+	}))
+	defer testServer.Close()
+
+	parentDevfile = fmt.Sprintf(`schemaVersion: 2.2.0
+components:
+- name: parent-runtime
+  container:
+    image: parent-image:latest
+- name: deploy
+  kubernetes:
+    uri: %s/deploy.yaml
+`, testServer.URL)
+
+	devfileData := fmt.Sprintf(`schemaVersion: 2.2.0
+metadata:
+  name: flatten
+parent:
+  uri: %s/parent/devfile.yaml
+components:
+- name: runtime
+  container:
+    image: runtime-image:latest
+`, testServer.URL)
+
+	originalDownloadGitRepoResources := downloadGitRepoResources
+	defer func() { downloadGitRepoResources = originalDownloadGitRepoResources }()
+	downloadGitRepoResources = mockDownloadGitRepoResources(&git.GitUrl{}, "")
+
+	// requesting a raw devfile should not prevent flattening
+	falseValue := false
+	d, err := Flatten(ParserArgs{
+		Data:                          []byte(devfileData),
+		FlattenedDevfile:              &falseValue,
+		ConvertKubernetesContentInUri: &falseValue,
+	})
+	if err != nil {
+		t.Fatalf("Test_Flatten() unexpected error: %v", err)
+	}
+
+	if d.Data.GetParent() != nil {
+		t.Errorf("Test_Flatten() error: expected the parent to be removed, got: %v", d.Data.GetParent())
+	}
+	components, err := d.Data.GetComponents(common.DevfileOptions{})
+	if err != nil {
+		t.Fatalf("Test_Flatten() unexpected error: %v", err)
+	}
+	var gotNames []string
+	for _, component := range components {
+		gotNames = append(gotNames, component.Name)
+		if component.Kubernetes != nil {
+			if component.Kubernetes.Uri != "" {
+				t.Errorf("Test_Flatten() error: expected no uri for component %s, got: %s", component.Name, component.Kubernetes.Uri)
+			}
+			if component.Kubernetes.Inlined != deployment {
+				t.Errorf("Test_Flatten() error: expected inlined content %q for component %s, got: %q", deployment, component.Name, component.Kubernetes.Inlined)
+			}
+			if _, ok := component.Attributes[K8sLikeComponentOriginalURIKey]; ok {
+				t.Errorf("Test_Flatten() error: expected no original uri attribute for component %s", component.Name)
+			}
+		}
+	}
+	assert.ElementsMatch(t, []string{"parent-runtime", "deploy", "runtime"}, gotNames, "flattened components should match")
+
+	yamlData, err := d.ToYAML()
+	if err != nil {
+		t.Fatalf("Test_Flatten() unexpected error: %v", err)
+	}
+	for _, ref := range []string{"parent:", "uri:", testServer.URL} {
+		if strings.Contains(string(yamlData), ref) {
+			t.Errorf("Test_Flatten() error: expected no external reference %q in the flattened devfile:\n%s", ref, yamlData)
+		}
+	}
+}
+
+func Test_ParseDevfile_ConvertKubernetesContentInUri(t *testing.T) {
+	const deployment = `kind: Deployment
+apiVersion: apps/v1
+metadata:
+  name: in-memory
+`
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte(deployment))
+		if err != nil {
+			t.Errorf("Test_ParseDevfile_ConvertKubernetesContentInUri() unexpected error while writing data: %v", err)
+		}
+	}))
+	defer testServer.Close()
+
+	uri := testServer.URL + "/deploy.yaml"
+	devfileData := []byte(fmt.Sprintf(`schemaVersion: 2.2.0
+metadata:
+  name: convert-uri
+components:
+- name: deploy
+  kubernetes:
+    uri: %s
+`, uri))
+
+	trueValue, falseValue := true, false
+	tests := []struct {
+		name                          string
+		convertKubernetesContentInUri *bool
+		convertInMemory               bool
+		wantInlined                   string
+		wantUri                       string
+		wantConvertUriToInlined       bool
+	}{
+		{
+			name:                    "should inline the kubernetes content by default",
+			wantInlined:             deployment,
+			wantConvertUriToInlined: true,
+		},
+		{
+			name:                          "should inline the kubernetes content when enabled",
+			convertKubernetesContentInUri: &trueValue,
+			wantInlined:                   deployment,
+			wantConvertUriToInlined:       true,
+		},
+		{
+			name:                          "should keep the kubernetes uri when disabled",
+			convertKubernetesContentInUri: &falseValue,
+			wantUri:                       uri,
+		},
+		{
+			name:                    "should inline the kubernetes content when converted in memory",
+			convertInMemory:         true,
+			wantInlined:             deployment,
+			wantConvertUriToInlined: true,
+		},
+		{
+			name:                          "should inline the kubernetes content when converted in memory even if disabled in uri",
+			convertKubernetesContentInUri: &falseValue,
+			convertInMemory:               true,
+			wantInlined:                   deployment,
+			wantConvertUriToInlined:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := ParseDevfile(ParserArgs{
+				Data:                             devfileData,
+				ConvertKubernetesContentInUri:    tt.convertKubernetesContentInUri,
+				ConvertKubernetesContentInMemory: tt.convertInMemory,
+			})
+			if err != nil {
+				t.Fatalf("Test_ParseDevfile_ConvertKubernetesContentInUri() unexpected error: %v", err)
+			}
+			components, err := d.Data.GetComponents(common.DevfileOptions{})
+			if err != nil {
+				t.Fatalf("Test_ParseDevfile_ConvertKubernetesContentInUri() unexpected error: %v", err)
+			}
+			kubernetes := components[0].Kubernetes
+			if kubernetes.Inlined != tt.wantInlined {
+				t.Errorf("Got: %q, want: %q", kubernetes.Inlined, tt.wantInlined)
+			}
+			if kubernetes.Uri != tt.wantUri {
+				t.Errorf("Got: %v, want: %v", kubernetes.Uri, tt.wantUri)
+			}
+			if d.Ctx.GetConvertUriToInlined() != tt.wantConvertUriToInlined {
+				t.Errorf("Got: %v, want: %v", d.Ctx.GetConvertUriToInlined(), tt.wantConvertUriToInlined)
+			}
+			if tt.wantConvertUriToInlined {
+				var attrErr error
+				gotOriginalUri := components[0].Attributes.GetString(K8sLikeComponentOriginalURIKey, &attrErr)
+				if attrErr != nil || gotOriginalUri != uri {
+					t.Errorf("Got: %v, want: %v, error: %v", gotOriginalUri, uri, attrErr)
+				}
+			}
+		})
+	}
+}
+
+func Test_ParseDevfile_MaxConcurrentDownloads(t *testing.T) {
+	const deployment = `kind: Deployment
+apiVersion: apps/v1
+metadata:
+  name: concurrent
+`
+	const parentDevfile = `schemaVersion: 2.2.0
+metadata:
+  name: parent
+components:
+- name: parent-runtime
+  container:
+    image: quay.io/nodejs-12
+`
+	// the counting gate records the highest number of requests served at the same time
+	var lock sync.Mutex
+	var inFlight, maxInFlight int
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		lock.Unlock()
+		defer func() {
+			lock.Lock()
+			inFlight--
+			lock.Unlock()
+		}()
+		time.Sleep(50 * time.Millisecond)
+
+		data := deployment
+		if r.URL.Path == "/devfile.yaml" {
+			data = parentDevfile
+		}
+		_, err := w.Write([]byte(data))
+		if err != nil {
+			t.Errorf("Test_ParseDevfile_MaxConcurrentDownloads() unexpected error while writing data: %v", err)
+		}
+	}))
+	defer testServer.Close()
+
+	originalDownloadGitRepoResources := downloadGitRepoResources
+	defer func() { downloadGitRepoResources = originalDownloadGitRepoResources }()
+	downloadGitRepoResources = mockDownloadGitRepoResources(&git.GitUrl{}, "")
+
+	const numComponents = 6
+	devfileData := fmt.Sprintf(`schemaVersion: 2.2.0
+metadata:
+  name: concurrent-downloads
+parent:
+  uri: %s/devfile.yaml
+components:
+`, testServer.URL)
+	for i := 0; i < numComponents; i++ {
+		devfileData += fmt.Sprintf(`- name: deploy-%d
+  kubernetes:
+    uri: %s/deploy-%d.yaml
+`, i, testServer.URL, i)
+	}
+
+	tests := []struct {
+		name                   string
+		maxConcurrentDownloads int
+		wantMaxInFlight        int
+	}{
+		{
+			name:            "should download sequentially by default",
+			wantMaxInFlight: 1,
+		},
+		{
+			name:                   "should download sequentially with a limit of 1",
+			maxConcurrentDownloads: 1,
+			wantMaxInFlight:        1,
+		},
+		{
+			name:                   "should not exceed a limit of 2",
+			maxConcurrentDownloads: 2,
+			wantMaxInFlight:        2,
+		},
+		{
+			name:                   "should not exceed a limit of 4",
+			maxConcurrentDownloads: 4,
+			wantMaxInFlight:        4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lock.Lock()
+			maxInFlight = 0
+			lock.Unlock()
+
+			d, err := ParseDevfile(ParserArgs{
+				Data:                   []byte(devfileData),
+				MaxConcurrentDownloads: tt.maxConcurrentDownloads,
+			})
+			if err != nil {
+				t.Fatalf("Test_ParseDevfile_MaxConcurrentDownloads() unexpected error: %v", err)
+			}
+
+			lock.Lock()
+			gotMaxInFlight := maxInFlight
+			lock.Unlock()
+			if gotMaxInFlight > tt.wantMaxInFlight {
+				t.Errorf("Got: %v concurrent downloads, want at most: %v", gotMaxInFlight, tt.wantMaxInFlight)
+			}
+
+			components, err := d.Data.GetComponents(common.DevfileOptions{})
+			if err != nil {
+				t.Fatalf("Test_ParseDevfile_MaxConcurrentDownloads() unexpected error: %v", err)
+			}
+			if len(components) != numComponents+1 {
+				t.Fatalf("Got: %v components, want: %v", len(components), numComponents+1)
+			}
+			for _, component := range components {
+				if component.Kubernetes != nil && component.Kubernetes.Inlined != deployment {
+					t.Errorf("Got: %q, want: %q", component.Kubernetes.Inlined, deployment)
+				}
+			}
+		})
+	}
+}
+
+func TestParentSchemaVersionPolicy_checkCompatibility(t *testing.T) {
+	majorVersionErr := "major versions .* differ"
+	minorVersionErr := "minor versions 2.1 and 2.2 differ"
+	unknownPolicyErr := "unknown parent schemaVersion policy Latest"
+
+	tests := []struct {
+		name          string
+		policy        ParentSchemaVersionPolicy
+		parentVersion string
+		childVersion  string
+		wantErr       *string
+	}{
+		{
+			name:          "default policy should accept a parent with the same major version",
+			parentVersion: "2.1.0",
+			childVersion:  "2.2.0",
+		},
+		{
+			name:          "default policy should reject a parent with a different major version",
+			parentVersion: "1.0.0",
+			childVersion:  "2.2.0",
+			wantErr:       &majorVersionErr,
+		},
+		{
+			name:          "same major policy should reject a parent with a different major version",
+			policy:        ParentSchemaVersionSameMajor,
+			parentVersion: "2.2.0",
+			childVersion:  "3.0.0",
+			wantErr:       &majorVersionErr,
+		},
+		{
+			name:          "same minor policy should accept a parent with a different patch version",
+			policy:        ParentSchemaVersionSameMinor,
+			parentVersion: "2.2.0",
+			childVersion:  "2.2.1",
+		},
+		{
+			name:          "same minor policy should reject a parent with a different minor version",
+			policy:        ParentSchemaVersionSameMinor,
+			parentVersion: "2.1.0",
+			childVersion:  "2.2.0",
+			wantErr:       &minorVersionErr,
+		},
+		{
+			name:          "any policy should accept a parent with a different major version",
+			policy:        ParentSchemaVersionAny,
+			parentVersion: "1.0.0",
+			childVersion:  "2.2.0",
+		},
+		{
+			name:          "should fail with an unknown policy",
+			policy:        "Latest",
+			parentVersion: "2.2.0",
+			childVersion:  "2.2.0",
+			wantErr:       &unknownPolicyErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.checkCompatibility(versionpkg.Must(versionpkg.NewVersion(tt.parentVersion)), versionpkg.Must(versionpkg.NewVersion(tt.childVersion)))
+			if (err != nil) != (tt.wantErr != nil) {
+				t.Errorf("TestParentSchemaVersionPolicy_checkCompatibility() unexpected error: %v, wantErr %v", err, tt.wantErr)
+			} else if err != nil {
+				assert.Regexp(t, *tt.wantErr, err.Error(), "Error message should match")
+			}
+		})
+	}
+}
+
+func Test_ParseDevfile_ParentSchemaVersionPolicy(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the parent schemaVersion is the name of the requested devfile, e.g. /2.1.0.yaml
+		parentVersion := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/"), ".yaml")
+		_, err := w.Write([]byte(fmt.Sprintf("schemaVersion: %s\ncomponents:\n- name: parent-runtime\n  container:\n    image: quay.io/nodejs-12\n", parentVersion)))
+		if err != nil {
+			t.Errorf("Test_ParseDevfile_ParentSchemaVersionPolicy() unexpected error while writing data: %v", err)
+		}
+	}))
+	defer testServer.Close()
+
+	originalDownloadGitRepoResources := downloadGitRepoResources
+	defer func() { downloadGitRepoResources = originalDownloadGitRepoResources }()
+	downloadGitRepoResources = mockDownloadGitRepoResources(&git.GitUrl{}, "")
+
+	incompatibleParentErr := "the parent devfile version 2.1.0 from .* is not compatible with the child devfile version 2.2.0 from main devfile: minor versions 2.1 and 2.2 differ"
+	greaterParentErr := "the parent devfile version from .* is greater than the child devfile version from main devfile"
+
+	tests := []struct {
+		name          string
+		policy        ParentSchemaVersionPolicy
+		parentVersion string
+		childVersion  string
+		wantErr       *string
+	}{
+		{
+			name:          "should merge a parent with an older minor version by default",
+			parentVersion: "2.1.0",
+			childVersion:  "2.2.0",
+		},
+		{
+			name:          "should merge a parent with the same version",
+			policy:        ParentSchemaVersionSameMinor,
+			parentVersion: "2.2.0",
+			childVersion:  "2.2.0",
+		},
+		{
+			name:          "should reject a parent with an older minor version with the same minor policy",
+			policy:        ParentSchemaVersionSameMinor,
+			parentVersion: "2.1.0",
+			childVersion:  "2.2.0",
+			wantErr:       &incompatibleParentErr,
+		},
+		{
+			name:          "should reject a parent with a greater version with any policy",
+			policy:        ParentSchemaVersionAny,
+			parentVersion: "2.2.0",
+			childVersion:  "2.1.0",
+			wantErr:       &greaterParentErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			devfileData := fmt.Sprintf("schemaVersion: %s\nmetadata:\n  name: child\nparent:\n  uri: %s/%s.yaml\n", tt.childVersion, testServer.URL, tt.parentVersion)
+			d, err := ParseDevfile(ParserArgs{
+				Data:                      []byte(devfileData),
+				ParentSchemaVersionPolicy: tt.policy,
+			})
+			if (err != nil) != (tt.wantErr != nil) {
+				t.Errorf("Test_ParseDevfile_ParentSchemaVersionPolicy() unexpected error: %v, wantErr %v", err, tt.wantErr)
+			} else if err != nil {
+				assert.Regexp(t, *tt.wantErr, err.Error(), "Error message should match")
+			} else {
+				components, err := d.Data.GetComponents(common.DevfileOptions{})
+				if err != nil {
+					t.Fatalf("Test_ParseDevfile_ParentSchemaVersionPolicy() unexpected error: %v", err)
+				}
+				if len(components) != 1 || components[0].Name != "parent-runtime" {
+					t.Errorf("Got: %v, want the parent-runtime component", components)
+				}
+			}
+		})
+	}
+}
+
+func Test_newGitResourcesDir(t *testing.T) {
+	defer SetGitResourcesDirPrefix("")
+
+	tests := []struct {
+		name       string
+		prefix     string
+		repo       string
+		wantPrefix string
+	}{
+		{
+			name:       "should use the default prefix",
+			repo:       "library",
+			wantPrefix: "git-resources-library-",
+		},
+		{
+			name:       "should use the configured prefix",
+			prefix:     "devfile-parent",
+			repo:       "library",
+			wantPrefix: "devfile-parent-library-",
+		},
+		{
+			name:       "should use the configured prefix without a repo",
+			prefix:     "devfile-parent",
+			wantPrefix: "devfile-parent-",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetGitResourcesDirPrefix(tt.prefix)
+
+			var dirs []string
+			for i := 0; i < 2; i++ {
+				dir, err := newGitResourcesDir(tt.repo)
+				if err != nil {
+					t.Fatalf("Test_newGitResourcesDir() unexpected error: %v", err)
+				}
+				defer os.RemoveAll(dir)
+				if !strings.HasPrefix(filepath.Base(dir), tt.wantPrefix) {
+					t.Errorf("Got: %v, want prefix: %v", dir, tt.wantPrefix)
+				}
+				dirs = append(dirs, dir)
+			}
+			if dirs[0] == dirs[1] {
+				t.Errorf("Got the same directory %v twice, want distinct directories", dirs[0])
+			}
+		})
+	}
+}
+
+func Test_ParseDevfile_TrackSourceDevfile(t *testing.T) {
+	const grandparentDevfile = `schemaVersion: 2.2.0
+metadata:
+  name: grandparent
+components:
+- name: db
+  container:
+    image: postgres
+`
+	const parentDevfile = `schemaVersion: 2.2.0
+metadata:
+  name: parent
+parent:
+  uri: grandparent.yaml
+components:
+- name: runtime
+  container:
+    image: nodejs
+- name: tools
+  container:
+    image: golang
+commands:
+- id: build
+  exec:
+    component: runtime
+    commandLine: npm install
+`
+	const mainDevfile = `schemaVersion: 2.2.0
+metadata:
+  name: main
+parent:
+  uri: parent.yaml
+  components:
+  - name: runtime
+    container:
+      image: nodejs:18
+components:
+- name: debug
+  container:
+    image: busybox
+`
+
+	devfileDir := t.TempDir()
+	for name, content := range map[string]string{
+		"devfile.yaml":     mainDevfile,
+		"parent.yaml":      parentDevfile,
+		"grandparent.yaml": grandparentDevfile,
+	} {
+		if err := os.WriteFile(filepath.Join(devfileDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Test_ParseDevfile_TrackSourceDevfile() unexpected error: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		track       bool
+		wantSources map[string]string
+	}{
+		{
+			name:  "should record the source devfile of each element",
+			track: true,
+			wantSources: map[string]string{
+				"component runtime": "main devfile",
+				"component debug":   "main devfile",
+				"component tools":   "uri: parent.yaml",
+				"component db":      "uri: grandparent.yaml",
+				"command build":     "uri: parent.yaml",
+			},
+		},
+		{
+			name: "should not record the source devfile by default",
+			wantSources: map[string]string{
+				"component runtime": "",
+				"component debug":   "",
+				"component tools":   "",
+				"component db":      "",
+				"command build":     "",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := ParseDevfile(ParserArgs{
+				Path:               filepath.Join(devfileDir, "devfile.yaml"),
+				TrackSourceDevfile: tt.track,
+			})
+			if err != nil {
+				t.Fatalf("Test_ParseDevfile_TrackSourceDevfile() unexpected error: %v", err)
+			}
+
+			gotSources := map[string]string{}
+			components, err := d.Data.GetComponents(common.DevfileOptions{})
+			if err != nil {
+				t.Fatalf("Test_ParseDevfile_TrackSourceDevfile() unexpected error: %v", err)
+			}
+			for _, component := range components {
+				gotSources["component "+component.Name] = component.Attributes.GetString(SourceDevfileAttribute, nil)
+			}
+			commands, err := d.Data.GetCommands(common.DevfileOptions{})
+			if err != nil {
+				t.Fatalf("Test_ParseDevfile_TrackSourceDevfile() unexpected error: %v", err)
+			}
+			for _, command := range commands {
+				gotSources["command "+command.Id] = command.Attributes.GetString(SourceDevfileAttribute, nil)
+			}
+			if !reflect.DeepEqual(gotSources, tt.wantSources) {
+				t.Errorf("Got: %v, want: %v", gotSources, tt.wantSources)
+			}
+			if runtime, _ := d.Data.GetComponents(common.DevfileOptions{FilterByName: "runtime"}); len(runtime) != 1 || runtime[0].Container.Image != "nodejs:18" {
+				t.Errorf("Got: %v, want the runtime component overridden by the main devfile", runtime)
+			}
+		})
+	}
+}
+
+func Test_ParseDevfile_RelativeParentPath(t *testing.T) {
+	const baseDevfile = `schemaVersion: 2.2.0
+metadata:
+  name: base
+components:
+- name: db
+  container:
+    image: postgres
+`
+	const parentDevfile = `schemaVersion: 2.2.0
+metadata:
+  name: parent
+parent:
+  uri: ../base/devfile.yaml
+components:
+- name: runtime
+  container:
+    image: nodejs
+`
+	const childDevfile = `schemaVersion: 2.2.0
+metadata:
+  name: child
+parent:
+  uri: ../parent/devfile.yaml
+components:
+- name: debug
+  container:
+    image: busybox
+`
+
+	// each devfile is in its own directory, and references its parent relative to that directory
+	devfileDir := t.TempDir()
+	for name, content := range map[string]string{
+		"child/devfile.yaml":  childDevfile,
+		"parent/devfile.yaml": parentDevfile,
+		"base/devfile.yaml":   baseDevfile,
+	} {
+		filePath := filepath.Join(devfileDir, name)
+		if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+			t.Fatalf("Test_ParseDevfile_RelativeParentPath() unexpected error: %v", err)
+		}
+		if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+			t.Fatalf("Test_ParseDevfile_RelativeParentPath() unexpected error: %v", err)
+		}
+	}
+
+	d, err := ParseDevfile(ParserArgs{Path: filepath.Join(devfileDir, "child", "devfile.yaml")})
+	if err != nil {
+		t.Fatalf("Test_ParseDevfile_RelativeParentPath() unexpected error: %v", err)
+	}
+	components, err := d.Data.GetComponents(common.DevfileOptions{})
+	if err != nil {
+		t.Fatalf("Test_ParseDevfile_RelativeParentPath() unexpected error: %v", err)
+	}
+	var gotComponents []string
+	for _, component := range components {
+		gotComponents = append(gotComponents, component.Name)
+	}
+	wantComponents := []string{"db", "runtime", "debug"}
+	if !reflect.DeepEqual(gotComponents, wantComponents) {
+		t.Errorf("Got: %v, want: %v", gotComponents, wantComponents)
+	}
+}
+
+// capturingTelemetrySink records the types and properties of the telemetry events it receives
+type capturingTelemetrySink struct {
+	lock   sync.Mutex
+	events []telemetry.Event
+}
+
+func (c *capturingTelemetrySink) Emit(event telemetry.Event) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.events = append(c.events, event)
+}
+
+func Test_ParseDevfile_Telemetry(t *testing.T) {
+	const devfileContent = `schemaVersion: 2.2.0
+metadata:
+  name: nodejs
+components:
+- name: runtime
+  container:
+    image: nodejs
+`
+
+	tests := []struct {
+		name      string
+		args      ParserArgs
+		wantTypes []telemetry.EventType
+		wantErr   bool
+	}{
+		{
+			name:      "should emit start and success events of a parse",
+			args:      ParserArgs{Data: []byte(devfileContent)},
+			wantTypes: []telemetry.EventType{telemetry.ParseStarted, telemetry.ParseSucceeded},
+		},
+		{
+			name:      "should emit start and failure events of a failed parse",
+			args:      ParserArgs{Data: []byte("schemaVersion: 9.9.9\n")},
+			wantTypes: []telemetry.EventType{telemetry.ParseStarted, telemetry.ParseFailed},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := &capturingTelemetrySink{}
+			telemetry.SetTelemetrySink(sink)
+			defer telemetry.SetTelemetrySink(nil)
+
+			_, err := ParseDevfile(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Test_ParseDevfile_Telemetry() unexpected error: %v, wantErr: %v", err, tt.wantErr)
+			}
+
+			var gotTypes []telemetry.EventType
+			for _, event := range sink.events {
+				gotTypes = append(gotTypes, event.Type)
+				if event.Properties["source"] != "data" {
+					t.Errorf("Got source: %v, want: data", event.Properties["source"])
+				}
+			}
+			if !reflect.DeepEqual(gotTypes, tt.wantTypes) {
+				t.Errorf("Got: %v, want: %v", gotTypes, tt.wantTypes)
+			}
+			if last := sink.events[len(sink.events)-1]; (last.Err != nil) != tt.wantErr {
+				t.Errorf("Got error: %v, wantErr: %v", last.Err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_ParseDevfile_MultipleDocuments(t *testing.T) {
+	const content = `schemaVersion: 2.2.0
+metadata:
+  name: nodejs
+components:
+- name: runtime
+  container:
+    image: nodejs
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nodejs
+`
+
+	d, err := ParseDevfile(ParserArgs{Data: []byte(content)})
+	if err != nil {
+		t.Fatalf("Test_ParseDevfile_MultipleDocuments() unexpected error: %v", err)
+	}
+	if d.Data.GetMetadata().Name != "nodejs" {
+		t.Errorf("Got: %v, want: %v", d.Data.GetMetadata().Name, "nodejs")
+	}
+	components, err := d.Data.GetComponents(common.DevfileOptions{})
+	if err != nil {
+		t.Fatalf("Test_ParseDevfile_MultipleDocuments() unexpected error: %v", err)
+	}
+	if len(components) != 1 || components[0].Name != "runtime" {
+		t.Errorf("Got: %v, want the runtime component", components)
+	}
+}
+
+func Test_ParseDevfile_CommitSHA(t *testing.T) {
+	const commitSHA = "0123456789abcdef0123456789abcdef01234567"
+	devfileContent := func(name string) string {
+		return fmt.Sprintf("schemaVersion: 2.2.0\nmetadata:\n  name: %s\n", name)
+	}
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v3/repos/owner/repo":
+			_, _ = w.Write([]byte(`{"name":"repo"}`))
+		// GitHub Enterprise raw files
+		case "/raw/owner/repo/" + commitSHA + "/devfile.yaml":
+			_, _ = w.Write([]byte(devfileContent("pinned")))
+		case "/raw/owner/repo/" + commitSHA + "/stacks/nodejs/devfile.yaml":
+			_, _ = w.Write([]byte(devfileContent("pinned-nodejs")))
+		case "/raw/owner/repo/main/devfile.yaml":
+			_, _ = w.Write([]byte(devfileContent("moved")))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer testServer.Close()
+	host := strings.TrimPrefix(testServer.URL, "http://")
+	err := git.RegisterGitHost(git.GitHost{Host: host, Provider: git.GitHubHost, APIBaseURL: testServer.URL + "/api/v3"})
+	if err != nil {
+		t.Fatalf("Test_ParseDevfile_CommitSHA() unexpected error: %v", err)
+	}
+	defer git.UnregisterGitHost(host)
+
+	tests := []struct {
+		name      string
+		url       string
+		commitSHA string
+		wantName  string
+		wantErr   string
+	}{
+		{
+			name:      "should fetch the devfile of a repo at the commit",
+			url:       testServer.URL + "/owner/repo",
+			commitSHA: commitSHA,
+			wantName:  "pinned",
+		},
+		{
+			name:      "should fetch the devfile of a file url at the commit instead of its branch",
+			url:       testServer.URL + "/owner/repo/blob/main/devfile.yaml",
+			commitSHA: commitSHA,
+			wantName:  "pinned",
+		},
+		{
+			name:      "should fetch the devfile of a directory url at the commit",
+			url:       testServer.URL + "/owner/repo/tree/main/stacks/nodejs",
+			commitSHA: commitSHA,
+			wantName:  "pinned-nodejs",
+		},
+		{
+			name:     "should fetch the devfile of a file url at its branch without a commit",
+			url:      testServer.URL + "/owner/repo/blob/main/devfile.yaml",
+			wantName: "moved",
+		},
+		{
+			name:      "should fail with an abbreviated commit SHA",
+			url:       testServer.URL + "/owner/repo",
+			commitSHA: "0123456",
+			wantErr:   "invalid commit SHA \"0123456\", should be a full commit id",
+		},
+		{
+			name:      "should fail without a url",
+			commitSHA: commitSHA,
+			wantErr:   "a commit SHA requires the URL of the devfile repo in the parser args",
+		},
+		{
+			name:      "should fail with a url that is not a git repo url",
+			url:       "http://example.com/devfile.yaml",
+			commitSHA: commitSHA,
+			wantErr:   "failed to pin the devfile url http://example.com/devfile.yaml to a commit: .*",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := ParseDevfile(ParserArgs{URL: tt.url, CommitSHA: tt.commitSHA})
+			if (err != nil) != (tt.wantErr != "") {
+				t.Fatalf("Test_ParseDevfile_CommitSHA() unexpected error: %v, want: %v", err, tt.wantErr)
+			}
+			if err != nil {
+				assert.Regexp(t, tt.wantErr, err.Error(), "Error message should match")
+			} else if d.Data.GetMetadata().Name != tt.wantName {
+				t.Errorf("Got: %v, want: %v", d.Data.GetMetadata().Name, tt.wantName)
+			}
+		})
+	}
+}
+
+func Test_ParseDevfile_FileURL(t *testing.T) {
+	const parentContent = `schemaVersion: 2.2.0
+metadata:
+  name: parent
+components:
+- name: runtime
+  container:
+    image: nodejs
+`
+	const devfileContent = `schemaVersion: 2.2.0
+metadata:
+  name: nodejs
+parent:
+  uri: parent/devfile.yaml
+`
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "parent"), 0755); err != nil {
+		t.Fatalf("Test_ParseDevfile_FileURL() unexpected error: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "parent", "devfile.yaml"), []byte(parentContent), 0644); err != nil {
+		t.Fatalf("Test_ParseDevfile_FileURL() unexpected error: %v", err)
+	}
+	devfilePath := filepath.Join(dir, "devfile.yaml")
+	if err := os.WriteFile(devfilePath, []byte(devfileContent), 0644); err != nil {
+		t.Fatalf("Test_ParseDevfile_FileURL() unexpected error: %v", err)
+	}
+
+	d, err := ParseDevfile(ParserArgs{URL: "file://" + filepath.ToSlash(devfilePath)})
+	if err != nil {
+		t.Fatalf("Test_ParseDevfile_FileURL() unexpected error: %v", err)
+	}
+	if d.Ctx.GetAbsPath() != devfilePath {
+		t.Errorf("Got: %v, want: %v", d.Ctx.GetAbsPath(), devfilePath)
+	}
+	if d.Data.GetMetadata().Name != "nodejs" {
+		t.Errorf("Got: %v, want: %v", d.Data.GetMetadata().Name, "nodejs")
+	}
+	// the parent uri is resolved relative to the directory of the devfile
+	components, err := d.Data.GetComponents(common.DevfileOptions{})
+	if err != nil {
+		t.Fatalf("Test_ParseDevfile_FileURL() unexpected error: %v", err)
+	}
+	if len(components) != 1 || components[0].Name != "runtime" {
+		t.Errorf("Got: %v, want the runtime component of the parent", components)
+	}
+}
+
+func Test_ParseDevfile_ParseTimeout(t *testing.T) {
+	originalDownloadGitRepoResources := downloadGitRepoResources
+	defer func() { downloadGitRepoResources = originalDownloadGitRepoResources }()
+	downloadGitRepoResources = mockDownloadGitRepoResources(&git.GitUrl{}, "")
+
+	// the parent devfile is served after the parse timeout, unless the request is cancelled
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(10 * time.Second):
+			_, _ = w.Write([]byte("schemaVersion: 2.2.0\nmetadata:\n  name: parent\n"))
+		}
+	}))
+	defer testServer.Close()
+
+	devfileContent := fmt.Sprintf("schemaVersion: 2.2.0\nmetadata:\n  name: nodejs\nparent:\n  uri: %s/devfile.yaml\n", testServer.URL)
+	start := time.Now()
+	_, err := ParseDevfile(ParserArgs{Data: []byte(devfileContent), ParseTimeout: 200 * time.Millisecond})
+	if err == nil {
+		t.Fatalf("Test_ParseDevfile_ParseTimeout() expected an error for a parse exceeding the timeout")
+	}
+	assert.Regexp(t, "failed to parse the devfile within the parse timeout of 200ms: .*context deadline exceeded", err.Error(), "Error message should match")
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Got: the parse aborted after %v, want: the parse aborted at the deadline", elapsed)
+	}
+}
+
+func Test_downloadLimiter_ContextDone(t *testing.T) {
+	limiter := newDownloadLimiter(1)
+	// every download slot is taken
+	limiter <- struct{}{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	downloaded := false
+	err := limiter.do(ctx, func() error {
+		downloaded = true
+		return nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Test_downloadLimiter_ContextDone() got error: %v, want: %v", err, context.DeadlineExceeded)
+	}
+	if downloaded {
+		t.Errorf("Test_downloadLimiter_ContextDone() got a download without a download slot")
+	}
+}
+
+func Test_getResourcesFromRegistry_ContextDone(t *testing.T) {
+	// the registry responds after the context is done, unless the test ends first
+	stop := make(chan struct{})
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-stop:
+		case <-time.After(10 * time.Second):
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer testServer.Close()
+	defer close(stop)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := getResourcesFromRegistry(ctx, "nodejs", testServer.URL, t.TempDir())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Test_getResourcesFromRegistry_ContextDone() got error: %v, want: %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Test_getResourcesFromRegistry_ContextDone() got: the pull aborted after %v, want: the pull aborted at the deadline", elapsed)
+	}
+}
+
+func Test_ParseDevfile_SchemaVersionErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr error
+	}{
+		{
+			name:    "devfile without a schemaVersion",
+			content: "metadata:\n  name: nodejs\n",
+			wantErr: devfileCtx.ErrSchemaVersionMissing,
+		},
+		{
+			name:    "devfile with an empty schemaVersion",
+			content: "schemaVersion: \"\"\nmetadata:\n  name: nodejs\n",
+			wantErr: devfileCtx.ErrSchemaVersionEmpty,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseDevfile(ParserArgs{Data: []byte(tt.content)})
+			if err == nil {
+				t.Fatalf("Test_ParseDevfile_SchemaVersionErrors() expected an error, got nil")
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Test_ParseDevfile_SchemaVersionErrors() error: '%v' is not '%v'", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_parseParentAndPlugin_PluginFromRegistry(t *testing.T) {
+	const pluginDevfile = `schemaVersion: 2.2.0
+components:
+- name: nodejs-tools
+  container:
+    image: nodejs-tools
+commands:
+- id: install
+  exec:
+    component: nodejs-tools
+    commandLine: npm install
+`
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/devfiles/nodejs-plugin/") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if _, err := w.Write([]byte(pluginDevfile)); err != nil {
+			t.Errorf("Test_parseParentAndPlugin_PluginFromRegistry() unexpected error while writing data: %v", err)
+		}
+	}))
+	defer testServer.Close()
+
+	originalGetResourcesFromRegistry := getResourcesFromRegistry
+	defer func() { getResourcesFromRegistry = originalGetResourcesFromRegistry }()
+	getResourcesFromRegistry = func(ctx context.Context, id, registryURL, destDir string) error {
+		return nil
+	}
+
+	// newDevfileObj returns a devfile with a container component and a plugin component of the registry id
+	newDevfileObj := func(id string) DevfileObj {
+		return DevfileObj{
+			Ctx: devfileCtx.NewDevfileCtx(OutputDevfileYamlPath),
+			Data: &v2.DevfileV2{
+				Devfile: v1.Devfile{
+					DevfileHeader: devfilepkg.DevfileHeader{
+						SchemaVersion: schemaVersion,
+					},
+					DevWorkspaceTemplateSpec: v1.DevWorkspaceTemplateSpec{
+						DevWorkspaceTemplateSpecContent: v1.DevWorkspaceTemplateSpecContent{
+							Components: []v1.Component{
+								{
+									Name: "runtime",
+									ComponentUnion: v1.ComponentUnion{
+										Container: &v1.ContainerComponent{
+											Container: v1.Container{Image: "nodejs"},
+										},
+									},
+								},
+								{
+									Name: "plugin",
+									ComponentUnion: v1.ComponentUnion{
+										Plugin: &v1.PluginComponent{
+											ImportReference: v1.ImportReference{
+												ImportReferenceUnion: v1.ImportReferenceUnion{
+													Id: id,
+												},
+												RegistryUrl: testServer.URL,
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	t.Run("should merge the components and commands of a plugin of a registry", func(t *testing.T) {
+		d := newDevfileObj("nodejs-plugin")
+		err := parseParentAndPlugin(d, &resolutionContextTree{}, resolverTools{})
+		if err != nil {
+			t.Fatalf("Test_parseParentAndPlugin_PluginFromRegistry() unexpected error: %v", err)
+		}
+
+		containers, err := d.Data.GetComponents(common.DevfileOptions{ComponentOptions: common.ComponentOptions{ComponentType: v1.ContainerComponentType}})
+		if err != nil {
+			t.Fatalf("Test_parseParentAndPlugin_PluginFromRegistry() unexpected error: %v", err)
+		}
+		var gotComponents []string
+		for _, container := range containers {
+			gotComponents = append(gotComponents, container.Name)
+		}
+		assert.Equal(t, []string{"nodejs-tools", "runtime"}, gotComponents, "plugin components should be merged")
+
+		commands, err := d.Data.GetCommands(common.DevfileOptions{})
+		if err != nil {
+			t.Fatalf("Test_parseParentAndPlugin_PluginFromRegistry() unexpected error: %v", err)
+		}
+		var gotCommands []string
+		for _, command := range commands {
+			gotCommands = append(gotCommands, command.Id)
+		}
+		assert.Equal(t, []string{"install"}, gotCommands, "plugin commands should be merged")
+	})
+
+	t.Run("should fail with a plugin missing from the registry", func(t *testing.T) {
+		err := parseParentAndPlugin(newDevfileObj("not-exist"), &resolutionContextTree{}, resolverTools{})
+		if err == nil {
+			t.Fatalf("Test_parseParentAndPlugin_PluginFromRegistry() expected an error, didn't get one")
+		}
+		assert.Regexp(t, "/devfiles/not-exist/", err.Error(), "Error message should match")
+	})
+}
+
+func Test_ParseDevfile_MaxImportDepth(t *testing.T) {
+	// the devfiles of the registry stacks by id, referencing the registry as {{registryUrl}}
+	stacks := map[string]string{
+		"base": `schemaVersion: 2.2.0
+components:
+- name: base
+  container:
+    image: base
+`,
+		"middle": `schemaVersion: 2.2.0
+parent:
+  id: base
+  registryUrl: {{registryUrl}}
+components:
+- name: middle
+  container:
+    image: middle
+`,
+		"cycle": `schemaVersion: 2.2.0
+parent:
+  id: cycle
+  registryUrl: {{registryUrl}}
+`,
+	}
+	const devfileTemplate = `schemaVersion: 2.2.0
+metadata:
+  name: nodejs
+parent:
+  id: %s
+  registryUrl: %s
+components:
+- name: runtime
+  container:
+    image: nodejs
+`
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.Split(strings.TrimPrefix(r.URL.Path, "/devfiles/"), "/")[0]
+		stack, ok := stacks[id]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if _, err := w.Write([]byte(strings.ReplaceAll(stack, "{{registryUrl}}", "http://"+r.Host))); err != nil {
+			t.Errorf("Test_ParseDevfile_MaxImportDepth() unexpected error while writing data: %v", err)
+		}
+	}))
+	defer testServer.Close()
+
+	originalGetResourcesFromRegistry := getResourcesFromRegistry
+	defer func() { getResourcesFromRegistry = originalGetResourcesFromRegistry }()
+	getResourcesFromRegistry = func(ctx context.Context, id, registryURL, destDir string) error {
+		return nil
+	}
+
+	tests := []struct {
+		name           string
+		parentId       string
+		maxImportDepth int
+		wantComponents []string
+		wantErr        string
+	}{
+		{
+			name:           "should import parents without a maximum import depth",
+			parentId:       "middle",
+			wantComponents: []string{"base", "middle", "runtime"},
+		},
+		{
+			name:           "should import parents within the maximum import depth",
+			parentId:       "middle",
+			maxImportDepth: 2,
+			wantComponents: []string{"base", "middle", "runtime"},
+		},
+		{
+			name:           "should fail with a parent imported beyond the maximum import depth",
+			parentId:       "middle",
+			maxImportDepth: 1,
+			wantErr:        "the import of id: base, registryURL: .* exceeds the maximum import depth of 1",
+		},
+		{
+			name:           "should fail with a parent importing itself",
+			parentId:       "cycle",
+			maxImportDepth: 10,
+			wantErr:        "devfile has an cycle in references: main devfile -> id: cycle, .* -> id: cycle",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := ParseDevfile(ParserArgs{
+				Data:           []byte(fmt.Sprintf(devfileTemplate, tt.parentId, testServer.URL)),
+				MaxImportDepth: tt.maxImportDepth,
+			})
+			if (err != nil) != (tt.wantErr != "") {
+				t.Fatalf("Test_ParseDevfile_MaxImportDepth() unexpected error: %v, wantErr: %v", err, tt.wantErr)
+			}
+			if err != nil {
+				assert.Regexp(t, tt.wantErr, err.Error(), "Error message should match")
+				return
+			}
+
+			components, err := d.Data.GetComponents(common.DevfileOptions{})
+			if err != nil {
+				t.Fatalf("Test_ParseDevfile_MaxImportDepth() unexpected error: %v", err)
+			}
+			var gotComponents []string
+			for _, component := range components {
+				gotComponents = append(gotComponents, component.Name)
+			}
+			assert.Equal(t, tt.wantComponents, gotComponents, "parent components should be merged")
+		})
+	}
+}
+
+func Test_ParseDevfile_ParentURIAllowedHosts(t *testing.T) {
+	const parentDevfile = `schemaVersion: 2.2.0
+metadata:
+  name: parent
+components:
+- name: runtime
+  container:
+    image: nodejs
+`
+	otherServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(parentDevfile))
+	}))
+	defer otherServer.Close()
+	allowedServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect.yaml" {
+			http.Redirect(w, r, otherServer.URL+"/devfile.yaml", http.StatusFound)
+			return
+		}
+		_, _ = w.Write([]byte(parentDevfile))
+	}))
+	defer allowedServer.Close()
+
+	git.SetAllowedHosts([]string{strings.TrimPrefix(allowedServer.URL, "http://")})
+	defer git.SetAllowedHosts(nil)
+
+	originalDownloadGitRepoResources := downloadGitRepoResources
+	defer func() { downloadGitRepoResources = originalDownloadGitRepoResources }()
+	downloadGitRepoResources = mockDownloadGitRepoResources(&git.GitUrl{}, "")
+
+	tests := []struct {
+		name      string
+		parentURI string
+		wantErr   string
+	}{
+		{
+			name:      "should parse a parent uri of an allowed host",
+			parentURI: allowedServer.URL + "/devfile.yaml",
+		},
+		{
+			name:      "should fail on a parent uri of a host not allowed",
+			parentURI: otherServer.URL + "/devfile.yaml",
+			wantErr:   "host not allowed: " + strings.TrimPrefix(otherServer.URL, "http://"),
+		},
+		{
+			name:      "should fail on a parent uri redirecting to a host not allowed",
+			parentURI: allowedServer.URL + "/redirect.yaml",
+			wantErr:   "host not allowed: " + strings.TrimPrefix(otherServer.URL, "http://"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := "schemaVersion: 2.2.0\nmetadata:\n  name: child\nparent:\n  uri: " + tt.parentURI + "\n"
+			d, err := ParseDevfile(ParserArgs{Data: []byte(content), FlattenedDevfile: &isTrue})
+			if (err != nil) != (tt.wantErr != "") {
+				t.Fatalf("Test_ParseDevfile_ParentURIAllowedHosts() unexpected error: %v, wantErr: %v", err, tt.wantErr)
+			}
+			if err != nil {
+				assert.Contains(t, err.Error(), tt.wantErr, "Error message should match")
+				return
+			}
+			components, err := d.Data.GetComponents(common.DevfileOptions{})
+			if err != nil {
+				t.Fatalf("Test_ParseDevfile_ParentURIAllowedHosts() unexpected error: %v", err)
+			}
+			if len(components) != 1 || components[0].Name != "runtime" {
+				t.Errorf("Test_ParseDevfile_ParentURIAllowedHosts() got components: %v, want the runtime component of the parent", components)
+			}
+		})
+	}
+}
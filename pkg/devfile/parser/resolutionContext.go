@@ -38,6 +38,15 @@ func (t *resolutionContextTree) appendNode(importReference v1.ImportReference) *
 	return newNode
 }
 
+// depth returns the number of devfiles imported to reach the current node, 0 for the main devfile
+func (t *resolutionContextTree) depth() int {
+	depth := 0
+	for currNode := t; currNode.parentNode != nil; currNode = currNode.parentNode {
+		depth++
+	}
+	return depth
+}
+
 // hasCycle checks if the current resolutionContextTree has a cycle
 func (t *resolutionContextTree) hasCycle() error {
 	var seenRefs []v1.ImportReference
@@ -27,6 +27,12 @@ const (
 	importSourceAttribute   = validation.ImportSourceAttribute
 	parentOverrideAttribute = validation.ParentOverrideAttribute
 	pluginOverrideAttribute = validation.PluginOverrideAttribute
+
+	// SourceDevfileAttribute is the attribute recording the devfile that an element of a flattened devfile originated from,
+	// e.g. 'api.devfile.io/source-devfile=uri: https://example.com/devfile.yaml', set when parsing with ParserArgs.TrackSourceDevfile.
+	// Elements of the main devfile are recorded as 'main devfile'. Unlike 'api.devfile.io/imported-from', which records the
+	// direct parent, it records the devfile defining the element down the parent chain.
+	SourceDevfileAttribute = "api.devfile.io/source-devfile"
 )
 
 // addSourceAttributesForParentOverride adds an attribute 'api.devfile.io/imported-from=<source reference>'
@@ -129,3 +135,85 @@ func addSourceAttributesForOverrideAndMerge(sourceImportReference v1.ImportRefer
 
 	return nil
 }
+
+// addSourceDevfileAttributes adds an attribute 'api.devfile.io/source-devfile=<source reference>' to all elements of template that support attributes.
+// Elements of template spec content keep the source devfile recorded when flattening their own parents and plugins, while elements of
+// overrides always get the source reference, as the overriding devfile replaces the source of the overridden element.
+func addSourceDevfileAttributes(sourceImportReference v1.ImportReference, template interface{}) error {
+	source := resolveImportReference(sourceImportReference)
+	putSource := func(attrs attributes.Attributes, keepExisting bool) attributes.Attributes {
+		if attrs == nil {
+			attrs = attributes.Attributes{}
+		}
+		if _, exists := attrs[SourceDevfileAttribute]; exists && keepExisting {
+			return attrs
+		}
+		return attrs.PutString(SourceDevfileAttribute, source)
+	}
+
+	switch template := template.(type) {
+	case *v1.DevWorkspaceTemplateSpecContent:
+		for idx := range template.Components {
+			template.Components[idx].Attributes = putSource(template.Components[idx].Attributes, true)
+		}
+		for idx := range template.Commands {
+			template.Commands[idx].Attributes = putSource(template.Commands[idx].Attributes, true)
+		}
+		for idx := range template.Projects {
+			template.Projects[idx].Attributes = putSource(template.Projects[idx].Attributes, true)
+		}
+		for idx := range template.StarterProjects {
+			template.StarterProjects[idx].Attributes = putSource(template.StarterProjects[idx].Attributes, true)
+		}
+	case *v1.ParentOverrides:
+		for idx := range template.Components {
+			template.Components[idx].Attributes = putSource(template.Components[idx].Attributes, false)
+		}
+		for idx := range template.Commands {
+			template.Commands[idx].Attributes = putSource(template.Commands[idx].Attributes, false)
+		}
+		for idx := range template.Projects {
+			template.Projects[idx].Attributes = putSource(template.Projects[idx].Attributes, false)
+		}
+		for idx := range template.StarterProjects {
+			template.StarterProjects[idx].Attributes = putSource(template.StarterProjects[idx].Attributes, false)
+		}
+	case *v1.PluginOverrides:
+		for idx := range template.Components {
+			template.Components[idx].Attributes = putSource(template.Components[idx].Attributes, false)
+		}
+		for idx := range template.Commands {
+			template.Commands[idx].Attributes = putSource(template.Commands[idx].Attributes, false)
+		}
+	default:
+		return fmt.Errorf("unknown template type")
+	}
+
+	return nil
+}
+
+// removeSourceAttributes removes the source and original uri attributes added while parsing
+// from all elements of template spec content that support attributes.
+func removeSourceAttributes(template *v1.DevWorkspaceTemplateSpecContent) {
+	removeAttributes := func(attrs attributes.Attributes) attributes.Attributes {
+		for _, key := range []string{importSourceAttribute, parentOverrideAttribute, pluginOverrideAttribute, SourceDevfileAttribute, K8sLikeComponentOriginalURIKey} {
+			delete(attrs, key)
+		}
+		if len(attrs) == 0 {
+			return nil
+		}
+		return attrs
+	}
+	for idx := range template.Components {
+		template.Components[idx].Attributes = removeAttributes(template.Components[idx].Attributes)
+	}
+	for idx := range template.Commands {
+		template.Commands[idx].Attributes = removeAttributes(template.Commands[idx].Attributes)
+	}
+	for idx := range template.Projects {
+		template.Projects[idx].Attributes = removeAttributes(template.Projects[idx].Attributes)
+	}
+	for idx := range template.StarterProjects {
+		template.StarterProjects[idx].Attributes = removeAttributes(template.StarterProjects[idx].Attributes)
+	}
+}
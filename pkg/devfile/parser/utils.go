@@ -16,12 +16,21 @@
 package parser
 
 import (
+	"archive/zip"
+	"bytes"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 
 	devfilev1 "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
 	"github.com/devfile/library/v2/pkg/devfile/parser/data"
 	"github.com/devfile/library/v2/pkg/devfile/parser/data/v2/common"
+	"github.com/devfile/library/v2/pkg/git"
+	"github.com/devfile/library/v2/pkg/util"
+	"k8s.io/klog"
 )
 
 // GetDeployComponents gets the default deploy command associated components
@@ -110,3 +119,132 @@ func GetImageBuildComponent(devfileData data.DevfileData, deployAssociatedCompon
 
 	return imageBuildComponent, nil
 }
+
+// GetStarterProjectUrls returns the git repos of the git starter projects of the devfile, so that tools can clone a starter
+// project of a stack. The revision of each repo is the checkout revision of its starter project, if any, and the remote
+// of a starter project with several remotes is its checkout remote. Zip and custom starter projects are skipped.
+func GetStarterProjectUrls(devfileData data.DevfileData) ([]*git.GitUrl, error) {
+	starterProjects, err := devfileData.GetStarterProjects(common.DevfileOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var urls []*git.GitUrl
+	for _, starterProject := range starterProjects {
+		if starterProject.Git == nil {
+			klog.V(4).Infof("skipping starter project %s, it is not a git starter project", starterProject.Name)
+			continue
+		}
+
+		_, remoteURL, revision, err := common.GetDefaultSource(starterProject.Git.GitLikeProjectSource)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the remote of starter project %s: %v", starterProject.Name, err)
+		}
+		gitUrl, err := git.ParseGitUrl(remoteURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the remote %s of starter project %s: %v", remoteURL, starterProject.Name, err)
+		}
+		// remotes are clone urls, e.g. https://github.com/devfile-samples/nodejs-basic.git
+		gitUrl.Repo = strings.TrimSuffix(gitUrl.Repo, ".git")
+		if revision != "" {
+			gitUrl.Revision = revision
+		}
+		urls = append(urls, &gitUrl)
+	}
+	return urls, nil
+}
+
+// maxZipEntryBytes is the maximum size in bytes of a file extracted from the zip archive of a project, the GitHub file size limit
+var maxZipEntryBytes int64 = 100 * 1024 * 1024
+
+// maxZipArchiveBytes is the maximum size in bytes of the zip archive of a project, which is downloaded in memory
+var maxZipArchiveBytes int64 = 512 * 1024 * 1024
+
+// DownloadZipProject downloads the zip archive at the location of the zip project, authenticated with the token if not empty,
+// and extracts it into destDir. The download fails if the archive is not reachable or is not a valid zip archive, and the
+// extraction fails without writing outside destDir if an entry of the archive has a path escaping destDir (zip slip).
+// The timeout of the download is httpTimeout seconds, or the default HTTP timeout if nil.
+func DownloadZipProject(project devfilev1.Project, destDir string, httpTimeout *int, token string) error {
+	if project.Zip == nil {
+		return fmt.Errorf("project %s is not a zip project", project.Name)
+	}
+	if project.Zip.Location == "" {
+		return fmt.Errorf("zip project %s has no location", project.Name)
+	}
+
+	params := util.HTTPRequestParams{URL: project.Zip.Location, Token: token, Timeout: httpTimeout, MaxBytes: maxZipArchiveBytes}
+	content, err := util.HTTPGetRequest(params, 0)
+	if err != nil {
+		return fmt.Errorf("failed to download zip project %s: %v", project.Name, err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return fmt.Errorf("failed to read zip project %s, %s is not a valid zip archive: %v", project.Name, project.Zip.Location, err)
+	}
+	if err = extractZip(archive, destDir); err != nil {
+		return fmt.Errorf("failed to extract zip project %s: %v", project.Name, err)
+	}
+	return nil
+}
+
+// extractZip extracts the files of the zip archive into destDir. All the entry paths and sizes are checked before any file is
+// written, so that an archive with an entry escaping destDir or larger than maxZipEntryBytes is rejected as a whole.
+func extractZip(archive *zip.Reader, destDir string) error {
+	destDir = filepath.Clean(destDir)
+	paths := make([]string, len(archive.File))
+	for i, f := range archive.File {
+		// Check for ZipSlip. More Info: http://bit.ly/2MsjAWE
+		paths[i] = filepath.Join(destDir, filepath.FromSlash(f.Name))
+		escapes := paths[i] != destDir && !strings.HasPrefix(paths[i], destDir+string(os.PathSeparator))
+		if escapes || filepath.IsAbs(filepath.FromSlash(f.Name)) {
+			return fmt.Errorf("illegal file path %s in the zip archive", f.Name)
+		}
+		if f.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("illegal symbolic link %s in the zip archive", f.Name)
+		}
+		if f.UncompressedSize64 > uint64(maxZipEntryBytes) {
+			return fmt.Errorf("file %s of the zip archive exceeds the size limit of %d bytes", f.Name, maxZipEntryBytes)
+		}
+	}
+
+	for i, f := range archive.File {
+		if f.FileInfo().IsDir() {
+			if err := os.MkdirAll(paths[i], os.ModePerm); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(paths[i]), os.ModePerm); err != nil {
+			return err
+		}
+		if err := extractZipFile(f, paths[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// extractZipFile writes the content of the file of the zip archive to path. The file is removed if its content exceeds
+// maxZipEntryBytes, whatever the size declared by the archive.
+func extractZipFile(f *zip.File, path string) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	outFile, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode().Perm()|util.ModeReadWriteFile)
+	if err != nil {
+		return err
+	}
+	// copy one byte past the limit so an oversized file can be detected
+	written, err := io.Copy(outFile, io.LimitReader(rc, maxZipEntryBytes+1))
+	if err == nil && written > maxZipEntryBytes {
+		err = fmt.Errorf("file %s of the zip archive exceeds the size limit of %d bytes", f.Name, maxZipEntryBytes)
+	}
+	if err != nil {
+		_ = outFile.Close()
+		_ = os.Remove(path)
+		return err
+	}
+	return outFile.Close()
+}
@@ -16,14 +16,23 @@
 package parser
 
 import (
+	"archive/zip"
+	"bytes"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 
 	v1 "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
 	"github.com/devfile/library/v2/pkg/devfile/parser/data"
+	v2 "github.com/devfile/library/v2/pkg/devfile/parser/data/v2"
 	"github.com/devfile/library/v2/pkg/devfile/parser/data/v2/common"
+	"github.com/devfile/library/v2/pkg/git"
 	"github.com/golang/mock/gomock"
 )
 
@@ -385,3 +394,315 @@ func TestGetImageBuildComponent(t *testing.T) {
 	}
 
 }
+
+func TestGetStarterProjectUrls(t *testing.T) {
+	gitStarter := func(name string, remotes map[string]string, checkoutFrom *v1.CheckoutFrom) v1.StarterProject {
+		return v1.StarterProject{
+			Name: name,
+			ProjectSource: v1.ProjectSource{
+				Git: &v1.GitProjectSource{
+					GitLikeProjectSource: v1.GitLikeProjectSource{Remotes: remotes, CheckoutFrom: checkoutFrom},
+				},
+			},
+		}
+	}
+	zipStarter := v1.StarterProject{
+		Name: "zip-starter",
+		ProjectSource: v1.ProjectSource{
+			Zip: &v1.ZipProjectSource{Location: "https://example.com/starter.zip"},
+		},
+	}
+
+	tests := []struct {
+		name            string
+		starterProjects []v1.StarterProject
+		want            []*git.GitUrl
+		wantErr         string
+	}{
+		{
+			name: "git starter projects, skipping zip starter projects",
+			starterProjects: []v1.StarterProject{
+				gitStarter("nodejs-starter", map[string]string{"origin": "https://github.com/devfile-samples/nodejs-basic.git"}, nil),
+				zipStarter,
+				gitStarter("nodejs-release", map[string]string{"origin": "https://github.com/devfile-samples/nodejs-basic"},
+					&v1.CheckoutFrom{Revision: "release-1.0"}),
+				gitStarter("gitlab-starter", map[string]string{
+					"origin":   "https://github.com/devfile-samples/nodejs-basic.git",
+					"upstream": "https://gitlab.com/devfile/nodejs-basic.git",
+				}, &v1.CheckoutFrom{Remote: "upstream", Revision: "main"}),
+			},
+			want: []*git.GitUrl{
+				{Protocol: "https", Host: "github.com", Owner: "devfile-samples", Repo: "nodejs-basic"},
+				{Protocol: "https", Host: "github.com", Owner: "devfile-samples", Repo: "nodejs-basic", Revision: "release-1.0"},
+				{Protocol: "https", Host: "gitlab.com", Owner: "devfile", Repo: "nodejs-basic", Revision: "main"},
+			},
+		},
+		{
+			name:            "only zip starter projects",
+			starterProjects: []v1.StarterProject{zipStarter},
+		},
+		{
+			name: "git starter project with several remotes and no checkout remote",
+			starterProjects: []v1.StarterProject{
+				gitStarter("ambiguous", map[string]string{
+					"origin":   "https://github.com/devfile-samples/nodejs-basic.git",
+					"upstream": "https://gitlab.com/devfile/nodejs-basic.git",
+				}, nil),
+			},
+			wantErr: "failed to get the remote of starter project ambiguous: there are multiple git remotes but no checkoutFrom information",
+		},
+		{
+			name: "git starter project with a remote of an unsupported git provider",
+			starterProjects: []v1.StarterProject{
+				gitStarter("self-hosted", map[string]string{"origin": "https://git.example.com/devfile/nodejs-basic.git"}, nil),
+			},
+			wantErr: "failed to parse the remote https://git.example.com/devfile/nodejs-basic.git of starter project self-hosted: .*",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			devfileData := &v2.DevfileV2{
+				Devfile: v1.Devfile{
+					DevWorkspaceTemplateSpec: v1.DevWorkspaceTemplateSpec{
+						DevWorkspaceTemplateSpecContent: v1.DevWorkspaceTemplateSpecContent{
+							StarterProjects: tt.starterProjects,
+						},
+					},
+				},
+			}
+			got, err := GetStarterProjectUrls(devfileData)
+			if (err != nil) != (tt.wantErr != "") {
+				t.Fatalf("TestGetStarterProjectUrls() unexpected error: %v, wantErr: %v", err, tt.wantErr)
+			}
+			if err != nil {
+				assert.Regexp(t, tt.wantErr, err.Error(), "Error message should match")
+			} else {
+				assert.Equal(t, tt.want, got, "TestGetStarterProjectUrls(): the git urls should match")
+			}
+		})
+	}
+}
+
+// testZipArchive returns a zip archive of the files, keyed by their path in the archive
+func testZipArchive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err = f.Write([]byte(content)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestDownloadZipProject(t *testing.T) {
+	const token = "fake-token"
+	archives := map[string][]byte{
+		"/project.zip": testZipArchive(t, map[string]string{
+			"nodejs/package.json":  "{}",
+			"nodejs/src/server.js": "console.log()",
+		}),
+		"/malicious.zip": testZipArchive(t, map[string]string{
+			"nodejs/package.json": "{}",
+			"../../evil.sh":       "rm -rf /",
+		}),
+		"/invalid.zip": []byte("not a zip archive"),
+	}
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/private.zip" {
+			if r.Header.Get("Authorization") != "Bearer "+token {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write(archives["/project.zip"])
+			return
+		}
+		archive, ok := archives[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(archive)
+	}))
+	defer testServer.Close()
+
+	zipProject := func(location string) v1.Project {
+		return v1.Project{
+			Name: "nodejs",
+			ProjectSource: v1.ProjectSource{
+				Zip: &v1.ZipProjectSource{Location: location},
+			},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		project   v1.Project
+		token     string
+		wantFiles map[string]string
+		wantErr   string
+	}{
+		{
+			name:    "should extract a zip project",
+			project: zipProject(testServer.URL + "/project.zip"),
+			wantFiles: map[string]string{
+				"nodejs/package.json":  "{}",
+				"nodejs/src/server.js": "console.log()",
+			},
+		},
+		{
+			name:      "should extract a private zip project with the token",
+			project:   zipProject(testServer.URL + "/private.zip"),
+			token:     token,
+			wantFiles: map[string]string{"nodejs/package.json": "{}"},
+		},
+		{
+			name:    "should reject an entry escaping the destination",
+			project: zipProject(testServer.URL + "/malicious.zip"),
+			wantErr: "failed to extract zip project nodejs: illegal file path ../../evil.sh in the zip archive",
+		},
+		{
+			name:    "should fail with an archive that is not a zip",
+			project: zipProject(testServer.URL + "/invalid.zip"),
+			wantErr: "failed to read zip project nodejs, .*/invalid.zip is not a valid zip archive: .*",
+		},
+		{
+			name:    "should fail with an unreachable archive",
+			project: zipProject(testServer.URL + "/missing.zip"),
+			wantErr: "failed to download zip project nodejs: failed to retrieve .*, 404: Not Found",
+		},
+		{
+			name:    "should fail with a private archive without the token",
+			project: zipProject(testServer.URL + "/private.zip"),
+			wantErr: "failed to download zip project nodejs: failed to retrieve .*, 401: Unauthorized",
+		},
+		{
+			name: "should fail with a project that is not a zip project",
+			project: v1.Project{
+				Name: "nodejs",
+				ProjectSource: v1.ProjectSource{
+					Git: &v1.GitProjectSource{},
+				},
+			},
+			wantErr: "project nodejs is not a zip project",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parentDir := t.TempDir()
+			destDir := filepath.Join(parentDir, "dest")
+			err := DownloadZipProject(tt.project, destDir, nil, tt.token)
+			if (err != nil) != (tt.wantErr != "") {
+				t.Fatalf("unexpected error: %v, want: %v", err, tt.wantErr)
+			}
+			if err != nil {
+				assert.Regexp(t, tt.wantErr, err.Error(), "Error message should match")
+				// nothing is extracted from a rejected archive
+				if _, statErr := os.Stat(destDir); !os.IsNotExist(statErr) {
+					t.Errorf("Got: files extracted into %s, want: none", destDir)
+				}
+				return
+			}
+			for name, want := range tt.wantFiles {
+				content, err := os.ReadFile(filepath.Join(destDir, filepath.FromSlash(name)))
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				} else if string(content) != want {
+					t.Errorf("Got: %s, want: %s", content, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDownloadZipProject_SizeLimits(t *testing.T) {
+	originalMaxZipEntryBytes, originalMaxZipArchiveBytes := maxZipEntryBytes, maxZipArchiveBytes
+	defer func() { maxZipEntryBytes, maxZipArchiveBytes = originalMaxZipEntryBytes, originalMaxZipArchiveBytes }()
+	maxZipEntryBytes = 8
+
+	// understatedArchive declares a size of its entry under the limit, while its content exceeds the limit
+	var understated bytes.Buffer
+	w := zip.NewWriter(&understated)
+	f, err := w.CreateRaw(&zip.FileHeader{Name: "nodejs/big.txt", Method: zip.Store, CompressedSize64: 20, UncompressedSize64: 4})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err = f.Write([]byte(strings.Repeat("x", 20))); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	archives := map[string][]byte{
+		"/project.zip":     testZipArchive(t, map[string]string{"nodejs/package.json": "{}"}),
+		"/oversized.zip":   testZipArchive(t, map[string]string{"nodejs/package.json": "{}", "nodejs/big.txt": strings.Repeat("x", 20)}),
+		"/understated.zip": understated.Bytes(),
+	}
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(archives[r.URL.Path])
+	}))
+	defer testServer.Close()
+
+	tests := []struct {
+		name          string
+		path          string
+		maxZipArchive int64
+		wantErr       string
+	}{
+		{
+			name: "should extract a zip project under the limits",
+			path: "/project.zip",
+		},
+		{
+			name:    "should reject an archive declaring an entry over the limit",
+			path:    "/oversized.zip",
+			wantErr: "failed to extract zip project nodejs: file nodejs/big.txt of the zip archive exceeds the size limit of 8 bytes",
+		},
+		{
+			name:    "should fail on an entry whose content exceeds its declared size",
+			path:    "/understated.zip",
+			wantErr: "failed to extract zip project nodejs: .*",
+		},
+		{
+			name:          "should fail on an archive over the limit",
+			path:          "/project.zip",
+			maxZipArchive: 16,
+			wantErr:       "failed to download zip project nodejs: failed to retrieve .*, resource exceeds size limit of 16 bytes",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			maxZipArchiveBytes = originalMaxZipArchiveBytes
+			if tt.maxZipArchive != 0 {
+				maxZipArchiveBytes = tt.maxZipArchive
+			}
+			destDir := t.TempDir()
+			project := v1.Project{
+				Name: "nodejs",
+				ProjectSource: v1.ProjectSource{
+					Zip: &v1.ZipProjectSource{Location: testServer.URL + tt.path},
+				},
+			}
+			err := DownloadZipProject(project, destDir, nil, "")
+			if (err != nil) != (tt.wantErr != "") {
+				t.Fatalf("unexpected error: %v, want: %v", err, tt.wantErr)
+			}
+			if err != nil {
+				assert.Regexp(t, tt.wantErr, err.Error(), "Error message should match")
+			}
+			// no truncated file is left on disk
+			if _, statErr := os.Stat(filepath.Join(destDir, "nodejs", "big.txt")); !os.IsNotExist(statErr) {
+				t.Errorf("Got: a file extracted over the size limit, want: none")
+			}
+		})
+	}
+}
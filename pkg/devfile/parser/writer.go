@@ -16,6 +16,8 @@
 package parser
 
 import (
+	"encoding/json"
+
 	v1 "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
 	apiAttributes "github.com/devfile/api/v2/pkg/attributes"
 	"github.com/devfile/library/v2/pkg/devfile/parser/data/v2/common"
@@ -56,6 +58,26 @@ func (d *DevfileObj) WriteYamlDevfile() error {
 	return nil
 }
 
+// ToJSON returns the JSON encoding of the in-memory devfile data. Unlike the raw devfile content, the data
+// reflects the resolved devfile model, i.e. with the parent and plugins merged when the devfile is flattened,
+// and the variables substituted when parsed through devfile.ParseDevfileAndValidate
+func (d *DevfileObj) ToJSON() ([]byte, error) {
+	jsonData, err := json.Marshal(d.Data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal devfile object into json")
+	}
+	return jsonData, nil
+}
+
+// ToYAML returns the YAML encoding of the in-memory devfile data, see ToJSON
+func (d *DevfileObj) ToYAML() ([]byte, error) {
+	yamlData, err := yaml.Marshal(d.Data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal devfile object into yaml")
+	}
+	return yamlData, nil
+}
+
 func restoreK8sCompURI(devObj *DevfileObj) error {
 	getKubeCompOptions := common.DevfileOptions{
 		ComponentOptions: common.ComponentOptions{
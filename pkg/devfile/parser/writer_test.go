@@ -16,13 +16,18 @@
 package parser
 
 import (
+	"encoding/json"
 	"fmt"
 	v1 "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
 	apiAttributes "github.com/devfile/api/v2/pkg/attributes"
 	devfilepkg "github.com/devfile/api/v2/pkg/devfile"
 	devfileCtx "github.com/devfile/library/v2/pkg/devfile/parser/context"
 	v2 "github.com/devfile/library/v2/pkg/devfile/parser/data/v2"
+	"github.com/devfile/library/v2/pkg/git"
 	"github.com/devfile/library/v2/pkg/testingutil/filesystem"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -118,3 +123,68 @@ func TestWriteYamlDevfile(t *testing.T) {
 		}
 	})
 }
+
+func TestDevfileObj_ToJSON(t *testing.T) {
+
+	const parentDevfile = `schemaVersion: 2.2.0
+components:
+- name: parent-runtime
+  container:
+    image: parent-image:latest
+`
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte(parentDevfile))
+		if err != nil {
+			t.Errorf("TestDevfileObj_ToJSON() unexpected error while writing data: %v", err)
+		}
+	}))
+	defer testServer.Close()
+
+	originalDownloadGitRepoResources := downloadGitRepoResources
+	defer func() { downloadGitRepoResources = originalDownloadGitRepoResources }()
+	downloadGitRepoResources = mockDownloadGitRepoResources(&git.GitUrl{}, "")
+
+	devfileData := fmt.Sprintf(`schemaVersion: 2.2.0
+metadata:
+  name: json-output
+parent:
+  uri: %s
+components:
+- name: runtime
+  container:
+    image: runtime-image:latest
+`, testServer.URL)
+
+	d, err := ParseDevfile(ParserArgs{Data: []byte(devfileData)})
+	if err != nil {
+		t.Fatalf("TestDevfileObj_ToJSON() unexpected error while parsing devfile: %v", err)
+	}
+
+	jsonData, err := d.ToJSON()
+	if err != nil {
+		t.Fatalf("TestDevfileObj_ToJSON() unexpected error: %v", err)
+	}
+
+	var got v1.Devfile
+	if err := json.Unmarshal(jsonData, &got); err != nil {
+		t.Fatalf("TestDevfileObj_ToJSON() failed to unmarshal json output: %v", err)
+	}
+
+	gotImages := make(map[string]string)
+	for _, component := range got.Components {
+		if component.Container != nil {
+			gotImages[component.Name] = component.Container.Image
+		}
+	}
+	wantImages := map[string]string{
+		"parent-runtime": "parent-image:latest",
+		"runtime":        "runtime-image:latest",
+	}
+	if !reflect.DeepEqual(gotImages, wantImages) {
+		t.Errorf("TestDevfileObj_ToJSON() error: container components mismatch, got: %v, want: %v", gotImages, wantImages)
+	}
+	if got.Parent != nil {
+		t.Errorf("TestDevfileObj_ToJSON() error: expected the parent to be flattened, got: %v", got.Parent)
+	}
+}
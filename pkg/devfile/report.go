@@ -0,0 +1,94 @@
+//
+// Copyright 2023 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package devfile
+
+import (
+	v1 "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	"github.com/devfile/library/v2/pkg/devfile/parser"
+	devfileCtx "github.com/devfile/library/v2/pkg/devfile/parser/context"
+)
+
+// Report is a machine-readable report of a devfile parse, e.g. for IDE integrations
+type Report struct {
+	// SchemaVersion is the schemaVersion of the devfile
+	SchemaVersion string `json:"schemaVersion"`
+	// SchemaValid is false if the devfile does not conform to its JSON schema, in which case the rest of the report is empty
+	SchemaValid bool `json:"schemaValid"`
+	// SchemaErrors are the schema violations of the devfile
+	SchemaErrors []devfileCtx.ValidationError `json:"schemaErrors,omitempty"`
+	// Warnings are the warnings of the devfile, as listed by ParserArgs.StrictWarnings
+	Warnings []string `json:"warnings,omitempty"`
+	// Parents are the parent devfiles resolved, see parser.DevfileObj.GetResolvedParents
+	Parents []v1.ImportReference `json:"parents,omitempty"`
+	// Variables are the values of the devfile variables, including the external variables
+	Variables map[string]string `json:"variables,omitempty"`
+	// URIs are the uris of the Kubernetes and Openshift component resources
+	URIs []string `json:"uris,omitempty"`
+}
+
+// ParseDevfileReport parses and validates the devfile like ParseDevfileAndValidate, and returns a report of the parse.
+// A devfile that does not conform to its JSON schema is reported with its schema errors rather than returning an error.
+// ParserArgs.StrictWarnings is ignored, the warnings are reported instead.
+func ParseDevfileReport(args parser.ParserArgs) (Report, error) {
+	args.StrictWarnings = false
+	d, varWarning, err := ParseDevfileAndValidate(args)
+	if err != nil {
+		content := d.Ctx.GetDevfileContent()
+		if content == nil {
+			return Report{}, err
+		}
+		result, resultErr := devfileCtx.ValidateDevfileSchemaResult(content)
+		if resultErr != nil || result.Valid {
+			return Report{}, err
+		}
+		return Report{
+			SchemaVersion: d.Ctx.GetApiVersion(),
+			SchemaErrors:  result.Errors,
+		}, nil
+	}
+
+	warnings, err := collectWarnings(d, varWarning, args.WarnNonPersistentPaths)
+	if err != nil {
+		return Report{}, err
+	}
+
+	report := Report{
+		SchemaVersion: d.Data.GetSchemaVersion(),
+		SchemaValid:   true,
+		Warnings:      warnings,
+		Parents:       d.GetResolvedParents(),
+		Variables:     d.Data.GetDevfileWorkspaceSpec().Variables,
+	}
+	for _, component := range d.Data.GetDevfileWorkspaceSpec().Components {
+		var k8sLikeComponent *v1.K8sLikeComponent
+		switch {
+		case component.Kubernetes != nil:
+			k8sLikeComponent = &component.Kubernetes.K8sLikeComponent
+		case component.Openshift != nil:
+			k8sLikeComponent = &component.Openshift.K8sLikeComponent
+		default:
+			continue
+		}
+		// the uri of a component whose resource was converted to inlined content is kept as an attribute
+		if k8sLikeComponent.Uri != "" {
+			report.URIs = append(report.URIs, k8sLikeComponent.Uri)
+		} else if uri := component.Attributes.GetString(parser.K8sLikeComponentOriginalURIKey, nil); uri != "" {
+			report.URIs = append(report.URIs, uri)
+		}
+	}
+
+	return report, nil
+}
@@ -0,0 +1,143 @@
+//
+// Copyright 2023 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package devfile
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	v1 "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	"github.com/devfile/library/v2/pkg/devfile/parser"
+)
+
+func TestParseDevfileReport(t *testing.T) {
+	const parentDevfile = `schemaVersion: 2.2.0
+metadata:
+  name: parent
+variables:
+  tag: "1.0"
+components:
+- name: runtime
+  container:
+    image: "my-app:{{tag}}"
+`
+	const deployYaml = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app
+`
+	devfile := `schemaVersion: 2.2.0
+metadata:
+  name: report
+parent:
+  uri: parent/devfile.yaml
+variables:
+  registry: quay.io
+components:
+- name: deploy
+  kubernetes:
+    uri: deploy.yaml
+- name: tools
+  container:
+    image: "{{registry}}/tools:{{version}}"
+`
+	devfileDir := t.TempDir()
+	for name, content := range map[string]string{
+		"devfile.yaml":        devfile,
+		"parent/devfile.yaml": parentDevfile,
+		"deploy.yaml":         deployYaml,
+	} {
+		filePath := filepath.Join(devfileDir, name)
+		if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+			t.Fatalf("TestParseDevfileReport() unexpected error: %v", err)
+		}
+		if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+			t.Fatalf("TestParseDevfileReport() unexpected error: %v", err)
+		}
+	}
+
+	const invalidDevfile = `schemaVersion: 2.2.0
+metadata:
+  name: invalid
+components:
+- container:
+    image: my-app
+`
+
+	tests := []struct {
+		name       string
+		args       parser.ParserArgs
+		wantReport Report
+		wantErr    bool
+	}{
+		{
+			name: "should report the parent, variables, uris and warnings of a devfile",
+			args: parser.ParserArgs{
+				Path:              filepath.Join(devfileDir, "devfile.yaml"),
+				ExternalVariables: map[string]string{"tag": "2.0"},
+			},
+			wantReport: Report{
+				SchemaVersion: "2.2.0",
+				SchemaValid:   true,
+				Warnings:      []string{"component tools references undefined variables: version"},
+				Parents: []v1.ImportReference{
+					{ImportReferenceUnion: v1.ImportReferenceUnion{Uri: filepath.Join(devfileDir, "parent", "devfile.yaml")}},
+				},
+				Variables: map[string]string{"registry": "quay.io", "tag": "2.0"},
+				URIs:      []string{"deploy.yaml"},
+			},
+		},
+		{
+			name: "should report the schema errors of a devfile that does not conform to its schema",
+			args: parser.ParserArgs{
+				Data: []byte(invalidDevfile),
+			},
+			wantReport: Report{
+				SchemaVersion: "2.2.0",
+				SchemaValid:   false,
+			},
+		},
+		{
+			name: "should fail on a devfile that cannot be parsed",
+			args: parser.ParserArgs{
+				Data: []byte("schemaVersion: ["),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := ParseDevfileReport(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDevfileReport() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !tt.wantReport.SchemaValid {
+				if report.SchemaValid || len(report.SchemaErrors) == 0 {
+					t.Errorf("Got: %v, want schema errors", report)
+				}
+				report.SchemaErrors = nil
+			}
+			if !reflect.DeepEqual(report, tt.wantReport) {
+				t.Errorf("Got: %+v, want: %+v", report, tt.wantReport)
+			}
+		})
+	}
+}
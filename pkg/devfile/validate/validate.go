@@ -16,15 +16,26 @@
 package validate
 
 import (
+	"encoding/json"
 	"fmt"
+	"path"
+	"strings"
+
+	v1 "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
 	v2Validation "github.com/devfile/api/v2/pkg/validation"
+	"github.com/devfile/library/v2/pkg/devfile/parser"
+	devfileCtx "github.com/devfile/library/v2/pkg/devfile/parser/context"
 	devfileData "github.com/devfile/library/v2/pkg/devfile/parser/data"
 	v2 "github.com/devfile/library/v2/pkg/devfile/parser/data/v2"
 	"github.com/devfile/library/v2/pkg/devfile/parser/data/v2/common"
 	"github.com/hashicorp/go-multierror"
 )
 
-// ValidateDevfileData validates whether sections of devfile are compatible
+// ValidateDevfileData validates whether sections of devfile are compatible.
+// Among other checks, each command group, e.g. build or run, should have a single default command: a group with
+// multiple default commands is an error, as is a group of more than one command without a default command.
+// Endpoint names should be unique across all the components, and two containers should not expose the same endpoint
+// targetPort; endpoints of a single container may share a targetPort.
 func ValidateDevfileData(data devfileData.DevfileData) error {
 
 	commands, err := data.GetCommands(common.DevfileOptions{})
@@ -80,3 +91,161 @@ func ValidateDevfileData(data devfileData.DevfileData) error {
 		return fmt.Errorf("unknown devfile type %T", d)
 	}
 }
+
+// ValidateAll validates the devfile data against the JSON schema of its schemaVersion and the semantic rules of
+// ValidateDevfileData, e.g. unique names, references of commands to components and default commands, and returns every
+// violation found, or nil if the devfile is valid. The schema is validated with the devfile data encoded as JSON.
+func ValidateAll(data devfileData.DevfileData) []error {
+	var errs []error
+
+	content, err := json.Marshal(data)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed to encode the devfile for its schema validation: %v", err))
+	} else if result, err := devfileCtx.ValidateDevfileSchemaResult(content); err != nil {
+		errs = append(errs, err)
+	} else {
+		for _, violation := range result.Errors {
+			errs = append(errs, fmt.Errorf("invalid devfile schema, %s: %s", violation.Field, violation.Description))
+		}
+	}
+
+	return append(errs, flattenErrors(ValidateDevfileData(data))...)
+}
+
+// flattenErrors returns the errors aggregated by err, if any, flattening the nested multierrors
+func flattenErrors(err error) []error {
+	if err == nil {
+		return nil
+	}
+	merr, ok := err.(*multierror.Error)
+	if !ok {
+		return []error{err}
+	}
+	var errs []error
+	for _, e := range merr.Errors {
+		errs = append(errs, flattenErrors(e)...)
+	}
+	return errs
+}
+
+// ValidateRelativeURIs returns a warning for each relative uri of the parent, plugin, kubernetes and openshift components
+// of the devfile when the devfile has no base location to resolve relative uris against, i.e. when it is parsed from bytes.
+// Such uris fail to be fetched when the devfile is flattened or its kubernetes content converted to inlined.
+func ValidateRelativeURIs(devObj parser.DevfileObj) ([]string, error) {
+	if devObj.Ctx.GetAbsPath() != "" || devObj.Ctx.GetURL() != "" {
+		return nil, nil
+	}
+
+	var warnings []string
+	isRelative := func(uri string) bool {
+		return uri != "" && !strings.HasPrefix(uri, "http://") && !strings.HasPrefix(uri, "https://")
+	}
+	warn := func(element, uri string) {
+		warnings = append(warnings, fmt.Sprintf("%s uses the relative uri %s, which cannot be resolved as the devfile has no base location, use an absolute url or parse the devfile from a path or url instead", element, uri))
+	}
+
+	if parent := devObj.Data.GetParent(); parent != nil && isRelative(parent.Uri) {
+		warn("parent", parent.Uri)
+	}
+	components, err := devObj.Data.GetComponents(common.DevfileOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, component := range components {
+		var uri string
+		switch {
+		case component.Kubernetes != nil:
+			uri = component.Kubernetes.Uri
+		case component.Openshift != nil:
+			uri = component.Openshift.Uri
+		case component.Plugin != nil:
+			uri = component.Plugin.Uri
+		}
+		if isRelative(uri) {
+			warn(fmt.Sprintf("component %s", component.Name), uri)
+		}
+	}
+	return warnings, nil
+}
+
+// projectsRootVars are the variables of the container env set to the directory of the project sources
+var projectsRootVars = []string{"$PROJECTS_ROOT", "${PROJECTS_ROOT}", "$PROJECT_SOURCE", "${PROJECT_SOURCE}"}
+
+// ValidatePersistentPaths returns a warning for each exec command whose workingDir is not backed by one of the volume mounts
+// of its container component or by the project sources the container mounts, and for each volume mount of a relative path.
+// Files written out of the volumes are lost when the container restarts. This is a best-practices check: such devfiles are
+// valid, and a workingDir starting with a variable other than the project sources ones is not checked.
+func ValidatePersistentPaths(data devfileData.DevfileData) ([]string, error) {
+	containers, err := data.GetComponents(common.DevfileOptions{ComponentOptions: common.ComponentOptions{ComponentType: v1.ContainerComponentType}})
+	if err != nil {
+		return nil, err
+	}
+
+	var warnings []string
+	// the absolute persisted paths and the project sources directory, if mounted, of each container by component name
+	isContainer := map[string]bool{}
+	persistedPaths := map[string][]string{}
+	sourceMappings := map[string]string{}
+	for _, component := range containers {
+		isContainer[component.Name] = true
+		container := component.Container.Container
+		for _, volumeMount := range container.VolumeMounts {
+			mountPath := volumeMount.Path
+			if mountPath == "" {
+				mountPath = "/" + volumeMount.Name
+			}
+			if !path.IsAbs(mountPath) {
+				warnings = append(warnings, fmt.Sprintf("component %s mounts the volume %s at the relative path %s, use an absolute path instead", component.Name, volumeMount.Name, mountPath))
+				continue
+			}
+			persistedPaths[component.Name] = append(persistedPaths[component.Name], mountPath)
+		}
+		if container.GetMountSources() {
+			sourceMapping := container.SourceMapping
+			if sourceMapping == "" {
+				sourceMapping = "/projects"
+			}
+			sourceMappings[component.Name] = sourceMapping
+			persistedPaths[component.Name] = append(persistedPaths[component.Name], sourceMapping)
+		}
+	}
+
+	commands, err := data.GetCommands(common.DevfileOptions{CommandOptions: common.CommandOptions{CommandType: v1.ExecCommandType}})
+	if err != nil {
+		return nil, err
+	}
+	for _, command := range commands {
+		workingDir := command.Exec.WorkingDir
+		// the commands of other components are reported by ValidateDevfileData
+		if workingDir == "" || !isContainer[command.Exec.Component] {
+			continue
+		}
+		if sourceMapping := sourceMappings[command.Exec.Component]; sourceMapping != "" {
+			for _, projectsRootVar := range projectsRootVars {
+				if strings.HasPrefix(workingDir, projectsRootVar) {
+					workingDir = sourceMapping + strings.TrimPrefix(workingDir, projectsRootVar)
+					break
+				}
+			}
+		}
+		if strings.HasPrefix(workingDir, "$") || !path.IsAbs(workingDir) {
+			continue
+		}
+		if !isUnderAnyPath(workingDir, persistedPaths[command.Exec.Component]) {
+			warnings = append(warnings, fmt.Sprintf("command %s has the workingDir %s, which is not under a volume mount or the project sources of component %s, and loses its files when the container restarts", command.Id, command.Exec.WorkingDir, command.Exec.Component))
+		}
+	}
+	return warnings, nil
+}
+
+// isUnderAnyPath returns if the absolute path is one of the absolute parent paths or under one of them
+func isUnderAnyPath(p string, parents []string) bool {
+	p = path.Clean(p)
+	for _, parent := range parents {
+		parent = path.Clean(parent)
+		if p == parent || strings.HasPrefix(p, strings.TrimSuffix(parent, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
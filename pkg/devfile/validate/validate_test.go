@@ -0,0 +1,445 @@
+//
+// Copyright 2023 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	v1 "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	devfilepkg "github.com/devfile/api/v2/pkg/devfile"
+	"github.com/devfile/library/v2/pkg/devfile/parser"
+	v2 "github.com/devfile/library/v2/pkg/devfile/parser/data/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateDevfileData_DefaultCommands(t *testing.T) {
+	const devfileTemplate = `schemaVersion: 2.2.0
+metadata:
+  name: default-commands
+components:
+- name: runtime
+  container:
+    image: nodejs
+commands:
+- id: run
+  exec:
+    component: runtime
+    commandLine: npm start
+    group:
+      kind: run
+      isDefault: %s
+- id: debug
+  exec:
+    component: runtime
+    commandLine: npm run debug
+    group:
+      kind: run
+      isDefault: %s
+`
+
+	tests := []struct {
+		name      string
+		isDefault [2]string
+		wantErr   string
+	}{
+		{
+			name:      "should fail with two default run commands",
+			isDefault: [2]string{"true", "true"},
+			wantErr:   "command group run error - there should be exactly one default command, currently there are multiple default commands; command: run; command: debug",
+		},
+		{
+			name:      "should fail with run commands without a default command",
+			isDefault: [2]string{"false", "false"},
+			wantErr:   "command group run warning - there should be exactly one default command, currently there is no default command",
+		},
+		{
+			name:      "should pass with a single default run command",
+			isDefault: [2]string{"true", "false"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isFalse := false
+			devObj, err := parser.ParseDevfile(parser.ParserArgs{
+				Data:             []byte(fmt.Sprintf(devfileTemplate, tt.isDefault[0], tt.isDefault[1])),
+				FlattenedDevfile: &isFalse,
+			})
+			if err != nil {
+				t.Fatalf("TestValidateDevfileData_DefaultCommands() unexpected error: %v", err)
+			}
+			err = ValidateDevfileData(devObj.Data)
+			if (err != nil) != (tt.wantErr != "") {
+				t.Fatalf("TestValidateDevfileData_DefaultCommands() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				assert.Contains(t, err.Error(), tt.wantErr, "Error message should match")
+			}
+		})
+	}
+}
+
+func TestValidateDevfileData_Endpoints(t *testing.T) {
+	const devfileTemplate = `schemaVersion: 2.2.0
+metadata:
+  name: endpoints
+components:
+- name: runtime
+  container:
+    image: nodejs
+    endpoints:
+    - name: http
+      targetPort: 3000
+%s
+- name: tools
+  container:
+    image: tools
+    endpoints:
+    - name: %s
+      targetPort: %d
+`
+
+	tests := []struct {
+		name              string
+		runtimeEndpoints  string
+		toolsEndpointName string
+		toolsEndpointPort int
+		wantErrs          []string
+	}{
+		{
+			name:              "should pass with unique endpoint names and ports",
+			toolsEndpointName: "debug",
+			toolsEndpointPort: 5858,
+		},
+		{
+			name:              "should fail with two endpoints of the same name",
+			toolsEndpointName: "http",
+			toolsEndpointPort: 5858,
+			wantErrs:          []string{"devfile contains multiple endpoint entries with same name: http"},
+		},
+		{
+			name:              "should fail with two containers exposing the same port",
+			toolsEndpointName: "debug",
+			toolsEndpointPort: 3000,
+			wantErrs:          []string{"devfile contains multiple containers with same endpoint targetPort: 3000"},
+		},
+		{
+			name:              "should fail with two endpoints of a container of the same name",
+			runtimeEndpoints:  "    - name: http\n      targetPort: 3001",
+			toolsEndpointName: "debug",
+			toolsEndpointPort: 5858,
+			wantErrs:          []string{"devfile contains multiple endpoint entries with same name: http"},
+		},
+		{
+			name:              "should pass with two endpoints of a container on the same port",
+			runtimeEndpoints:  "    - name: https\n      targetPort: 3000",
+			toolsEndpointName: "debug",
+			toolsEndpointPort: 5858,
+		},
+		{
+			name:              "should fail with duplicate endpoint names and ports",
+			toolsEndpointName: "http",
+			toolsEndpointPort: 3000,
+			wantErrs: []string{
+				"devfile contains multiple endpoint entries with same name: http",
+				"devfile contains multiple containers with same endpoint targetPort: 3000",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isFalse := false
+			devObj, err := parser.ParseDevfile(parser.ParserArgs{
+				Data:             []byte(fmt.Sprintf(devfileTemplate, tt.runtimeEndpoints, tt.toolsEndpointName, tt.toolsEndpointPort)),
+				FlattenedDevfile: &isFalse,
+			})
+			if err != nil {
+				t.Fatalf("TestValidateDevfileData_Endpoints() unexpected error: %v", err)
+			}
+			err = ValidateDevfileData(devObj.Data)
+			if (err != nil) != (len(tt.wantErrs) > 0) {
+				t.Fatalf("TestValidateDevfileData_Endpoints() error = %v, wantErrs %v", err, tt.wantErrs)
+			}
+			if err != nil {
+				errs := flattenErrors(err)
+				if len(errs) != len(tt.wantErrs) {
+					t.Errorf("TestValidateDevfileData_Endpoints() got errors: %v, want: %v", errs, tt.wantErrs)
+				}
+				for _, wantErr := range tt.wantErrs {
+					assert.Contains(t, err.Error(), wantErr, "Error message should match")
+				}
+			}
+		})
+	}
+}
+
+func TestValidateRelativeURIs(t *testing.T) {
+	const relativeUriDevfile = `schemaVersion: 2.2.0
+metadata:
+  name: relative-uri
+components:
+- name: deploy
+  kubernetes:
+    uri: deploy.yaml
+`
+	const absoluteUriDevfile = `schemaVersion: 2.2.0
+metadata:
+  name: absolute-uri
+components:
+- name: deploy
+  kubernetes:
+    uri: https://example.com/deploy.yaml
+`
+	const relativeParentDevfile = `schemaVersion: 2.2.0
+metadata:
+  name: relative-parent
+parent:
+  uri: parent/devfile.yaml
+`
+
+	devfileDir := t.TempDir()
+	devfilePath := filepath.Join(devfileDir, "devfile.yaml")
+	err := os.WriteFile(devfilePath, []byte(relativeUriDevfile), 0644)
+	if err != nil {
+		t.Fatalf("TestValidateRelativeURIs() unexpected error: %v", err)
+	}
+
+	isFalse := false
+	relativeUriWarning := "component deploy uses the relative uri deploy.yaml, which cannot be resolved as the devfile has no base location"
+	relativeParentWarning := "parent uses the relative uri parent/devfile.yaml, which cannot be resolved as the devfile has no base location"
+
+	tests := []struct {
+		name         string
+		args         parser.ParserArgs
+		wantWarnings []string
+	}{
+		{
+			name: "should warn on a relative kubernetes uri of a devfile parsed from bytes",
+			args: parser.ParserArgs{
+				Data:                          []byte(relativeUriDevfile),
+				ConvertKubernetesContentInUri: &isFalse,
+			},
+			wantWarnings: []string{relativeUriWarning},
+		},
+		{
+			name: "should warn on a relative parent uri of a raw devfile parsed from bytes",
+			args: parser.ParserArgs{
+				Data:             []byte(relativeParentDevfile),
+				FlattenedDevfile: &isFalse,
+			},
+			wantWarnings: []string{relativeParentWarning},
+		},
+		{
+			name: "should not warn on an absolute kubernetes uri of a devfile parsed from bytes",
+			args: parser.ParserArgs{
+				Data:                          []byte(absoluteUriDevfile),
+				ConvertKubernetesContentInUri: &isFalse,
+			},
+		},
+		{
+			name: "should not warn on a relative kubernetes uri of a devfile parsed from a path",
+			args: parser.ParserArgs{
+				Path:                          devfilePath,
+				ConvertKubernetesContentInUri: &isFalse,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			devObj, err := parser.ParseDevfile(tt.args)
+			if err != nil {
+				t.Fatalf("TestValidateRelativeURIs() unexpected error: %v", err)
+			}
+			warnings, err := ValidateRelativeURIs(devObj)
+			if err != nil {
+				t.Fatalf("TestValidateRelativeURIs() unexpected error: %v", err)
+			}
+			if len(warnings) != len(tt.wantWarnings) {
+				t.Fatalf("Got: %v, want: %v", warnings, tt.wantWarnings)
+			}
+			for i := range warnings {
+				assert.Regexp(t, tt.wantWarnings[i], warnings[i], "Warning message should match")
+			}
+		})
+	}
+}
+
+func TestValidateAll(t *testing.T) {
+	devfileWith := func(version string, commandComponent string) *v2.DevfileV2 {
+		return &v2.DevfileV2{
+			Devfile: v1.Devfile{
+				DevfileHeader: devfilepkg.DevfileHeader{
+					SchemaVersion: "2.2.0",
+					Metadata:      devfilepkg.DevfileMetadata{Name: "nodejs", Version: version},
+				},
+				DevWorkspaceTemplateSpec: v1.DevWorkspaceTemplateSpec{
+					DevWorkspaceTemplateSpecContent: v1.DevWorkspaceTemplateSpecContent{
+						Components: []v1.Component{
+							{
+								Name: "runtime",
+								ComponentUnion: v1.ComponentUnion{
+									Container: &v1.ContainerComponent{Container: v1.Container{Image: "nodejs"}},
+								},
+							},
+						},
+						Commands: []v1.Command{
+							{
+								Id: "build",
+								CommandUnion: v1.CommandUnion{
+									Exec: &v1.ExecCommand{CommandLine: "npm install", Component: commandComponent},
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		data     *v2.DevfileV2
+		wantErrs []string
+	}{
+		{
+			name: "should report both a schema error and a semantic error",
+			// the version is not a semantic version, and the command references a missing component
+			data: devfileWith("latest", "missing"),
+			wantErrs: []string{
+				"invalid devfile schema, metadata.version: Does not match pattern",
+				"the command \"build\" is invalid - command does not map to a valid component",
+			},
+		},
+		{
+			name: "should report no error for a valid devfile",
+			data: devfileWith("1.0.0", "runtime"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := ValidateAll(tt.data)
+			if len(errs) != len(tt.wantErrs) {
+				t.Fatalf("TestValidateAll() got errors: %v, want: %v", errs, tt.wantErrs)
+			}
+			for i, err := range errs {
+				assert.Contains(t, err.Error(), tt.wantErrs[i], "Error message should match")
+			}
+		})
+	}
+}
+
+func TestValidatePersistentPaths(t *testing.T) {
+	const devfileTemplate = `schemaVersion: 2.2.0
+metadata:
+  name: persistent-paths
+components:
+- name: runtime
+  container:
+    image: nodejs
+    mountSources: %s
+    volumeMounts:
+    - name: cache
+      path: %s
+- name: cache
+  volume:
+    size: 1Gi
+commands:
+- id: build
+  exec:
+    component: runtime
+    commandLine: npm run build
+    workingDir: %s
+`
+
+	tests := []struct {
+		name         string
+		mountSources string
+		mountPath    string
+		workingDir   string
+		wantWarnings []string
+	}{
+		{
+			name:         "should warn on a workingDir not backed by a volume",
+			mountSources: "true",
+			mountPath:    "/cache",
+			workingDir:   "/build",
+			wantWarnings: []string{"command build has the workingDir /build, which is not under a volume mount or the project sources of component runtime, and loses its files when the container restarts"},
+		},
+		{
+			name:         "should not warn on a workingDir under a volume mount",
+			mountSources: "true",
+			mountPath:    "/cache",
+			workingDir:   "/cache/build",
+		},
+		{
+			name:         "should not warn on a workingDir under the project sources",
+			mountSources: "true",
+			mountPath:    "/cache",
+			workingDir:   "${PROJECT_SOURCE}/app",
+		},
+		{
+			name:         "should warn on a workingDir under unmounted project sources",
+			mountSources: "false",
+			mountPath:    "/cache",
+			workingDir:   "/projects/app",
+			wantWarnings: []string{"command build has the workingDir /projects/app, which is not under a volume mount or the project sources of component runtime"},
+		},
+		{
+			name:         "should warn on a workingDir sharing a prefix with a volume mount",
+			mountSources: "false",
+			mountPath:    "/cache",
+			workingDir:   "/cache-build",
+			wantWarnings: []string{"command build has the workingDir /cache-build, which is not under a volume mount"},
+		},
+		{
+			name:         "should warn on a volume mounted at a relative path",
+			mountSources: "true",
+			mountPath:    "cache",
+			workingDir:   "${PROJECT_SOURCE}",
+			wantWarnings: []string{"component runtime mounts the volume cache at the relative path cache, use an absolute path instead"},
+		},
+		{
+			name:         "should not check a workingDir of another variable",
+			mountSources: "false",
+			mountPath:    "/cache",
+			workingDir:   "$HOME/build",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isFalse := false
+			devObj, err := parser.ParseDevfile(parser.ParserArgs{
+				Data:             []byte(fmt.Sprintf(devfileTemplate, tt.mountSources, tt.mountPath, tt.workingDir)),
+				FlattenedDevfile: &isFalse,
+			})
+			if err != nil {
+				t.Fatalf("TestValidatePersistentPaths() unexpected error: %v", err)
+			}
+			warnings, err := ValidatePersistentPaths(devObj.Data)
+			if err != nil {
+				t.Fatalf("TestValidatePersistentPaths() unexpected error: %v", err)
+			}
+			if len(warnings) != len(tt.wantWarnings) {
+				t.Fatalf("TestValidatePersistentPaths() got warnings: %v, want: %v", warnings, tt.wantWarnings)
+			}
+			for i := range warnings {
+				assert.Contains(t, warnings[i], tt.wantWarnings[i], "Warning should match")
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+//
+// Copyright 2023 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	gitpkg "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// CloneBackend is the implementation cloning git repos with GitUrl.CloneGitRepo
+type CloneBackend string
+
+const (
+	// CloneBackendExec clones repos by running the git binary, which must be installed. This is the default.
+	CloneBackendExec CloneBackend = "exec"
+	// CloneBackendGoGit clones repos with go-git, for environments without the git binary.
+	// The ShallowSince and ObjectCacheDir clone options are not supported by this backend.
+	CloneBackendGoGit CloneBackend = "go-git"
+)
+
+var (
+	cloneBackendLock sync.RWMutex
+	cloneBackend     = CloneBackendExec
+)
+
+// SetCloneBackend sets the backend cloning git repos, e.g. CloneBackendGoGit when the git binary is not available.
+// Both backends authenticate with the token of the GitUrl in the same way.
+func SetCloneBackend(backend CloneBackend) error {
+	switch backend {
+	case CloneBackendExec, CloneBackendGoGit:
+	default:
+		return fmt.Errorf("unknown clone backend %q", backend)
+	}
+	cloneBackendLock.Lock()
+	defer cloneBackendLock.Unlock()
+	cloneBackend = backend
+	return nil
+}
+
+// getCloneBackend returns the backend cloning git repos
+func getCloneBackend() CloneBackend {
+	cloneBackendLock.RLock()
+	defer cloneBackendLock.RUnlock()
+	return cloneBackend
+}
+
+// checkGoGitCloneOptions checks that the clone options are supported by the go-git backend
+func checkGoGitCloneOptions(options CloneOptions) error {
+	if !options.ShallowSince.IsZero() {
+		return fmt.Errorf("failed to clone repo, the ShallowSince option is not supported by the %s clone backend", CloneBackendGoGit)
+	}
+	if options.ObjectCacheDir != "" {
+		return fmt.Errorf("failed to clone repo, the ObjectCacheDir option is not supported by the %s clone backend", CloneBackendGoGit)
+	}
+	// go-git only speaks version 1 of the wire protocol, which is also what it negotiates by default
+	if options.ProtocolVersion > 1 {
+		return fmt.Errorf("failed to clone repo, protocol version %d is not supported by the %s clone backend, which only speaks version 1", options.ProtocolVersion, CloneBackendGoGit)
+	}
+	return nil
+}
+
+// goGitClone clones the repo of the authenticated cloneURL into destDir with go-git, and returns the progress output of the clone.
+// The error, if any, is appended to the output so that authentication failures are reported like the failures of the git binary.
+func goGitClone(ctx context.Context, destDir string, cloneURL string, revision string, options CloneOptions) ([]byte, error) {
+	var output bytes.Buffer
+	cloneOptions := &gitpkg.CloneOptions{
+		URL:      cloneURL,
+		Progress: &output,
+		Depth:    options.Depth,
+	}
+	if options.RevisionIsTag {
+		cloneOptions.ReferenceName = plumbing.NewTagReferenceName(revision)
+		cloneOptions.SingleBranch = true
+		cloneOptions.Depth = 1
+	} else if options.Bare && revision != "" {
+		cloneOptions.ReferenceName = plumbing.NewBranchReferenceName(revision)
+	}
+
+	_, err := gitpkg.PlainCloneContext(ctx, destDir, options.Bare, cloneOptions)
+	if err != nil {
+		output.WriteString(err.Error())
+	}
+	return output.Bytes(), err
+}
+
+// switchRevision checks out the remote branch of the revision in the cloned repo, detached like `git switch --detach origin/<revision>`
+func switchRevision(backend CloneBackend, destDir string, revision string) ([]byte, error) {
+	if backend != CloneBackendGoGit {
+		return execute(destDir, "git", "switch", "--detach", "origin/"+revision)
+	}
+
+	repo, err := gitpkg.PlainOpen(destDir)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(plumbing.NewRemoteReferenceName("origin", revision)))
+	if err != nil {
+		return []byte(err.Error()), err
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	return nil, worktree.Checkout(&gitpkg.CheckoutOptions{Hash: *hash})
+}
+
+// headCommit returns the commit id checked out in the cloned repo
+func headCommit(backend CloneBackend, destDir string) (string, error) {
+	if backend != CloneBackendGoGit {
+		commitSHA, err := execute(destDir, "git", "rev-parse", "HEAD")
+		return strings.TrimSpace(string(commitSHA)), err
+	}
+
+	repo, err := gitpkg.PlainOpen(destDir)
+	if err != nil {
+		return "", err
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return "", err
+	}
+	return head.Hash().String(), nil
+}
+
+// showFile returns the content of the file at path in the repo of dir at ref, like `git cat-file blob <ref>:<path>`
+func showFile(backend CloneBackend, dir string, ref string, path string) ([]byte, error) {
+	if backend != CloneBackendGoGit {
+		out, err := executeStdout(dir, "git", "cat-file", "blob", ref+":"+path)
+		if err != nil {
+			return nil, err
+		}
+		return out, nil
+	}
+
+	repo, err := gitpkg.PlainOpen(dir)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(ref))
+	if err != nil {
+		return nil, err
+	}
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, err
+	}
+	file, err := commit.File(path)
+	if err != nil {
+		return nil, err
+	}
+	content, err := file.Contents()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(content), nil
+}
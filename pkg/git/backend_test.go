@@ -0,0 +1,518 @@
+//
+// Copyright 2023 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/devfile/library/v2/pkg/telemetry"
+	"github.com/stretchr/testify/assert"
+)
+
+// runTestGit runs git in dir, with a committer identity for the commits of the test repos, and returns its trimmed output
+func runTestGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	args = append([]string{"-c", "user.name=devfile", "-c", "user.email=devfile@example.com"}, args...)
+	c := exec.Command("git", args...)
+	c.Dir = dir
+	out, err := c.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v failed: %v: %s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// initTestRepoWithBranches creates the stack repo in a temp directory, with a devfile.yaml on its main branch and a feature.yaml
+// added on its feature branch. Each file contains its name. It returns the GitUrl of the repo, and the commits of the branches.
+// The test is skipped if git is not installed.
+func initTestRepoWithBranches(t *testing.T) (GitUrl, string, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	commitFile := func(repoDir, name string) string {
+		if err := os.WriteFile(filepath.Join(repoDir, name), []byte(name), 0644); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		runTestGit(t, repoDir, "add", ".")
+		runTestGit(t, repoDir, "commit", "--quiet", "-m", "add "+name)
+		return runTestGit(t, repoDir, "rev-parse", "HEAD")
+	}
+
+	reposDir := t.TempDir()
+	repoDir := filepath.Join(reposDir, "stack.git")
+	runTestGit(t, reposDir, "init", "--quiet", "--initial-branch=main", repoDir)
+	mainCommit := commitFile(repoDir, "devfile.yaml")
+	runTestGit(t, repoDir, "switch", "--quiet", "-c", "feature")
+	featureCommit := commitFile(repoDir, "feature.yaml")
+	runTestGit(t, repoDir, "switch", "--quiet", "main")
+
+	gitUrl := GitUrl{
+		Protocol: "file",
+		Owner:    strings.TrimPrefix(filepath.ToSlash(reposDir), "/"),
+		Repo:     "stack",
+	}
+	return gitUrl, mainCommit, featureCommit
+}
+
+func Test_CloneGitRepoWithBackend(t *testing.T) {
+	repoUrl, mainCommit, featureCommit := initTestRepoWithBranches(t)
+
+	tests := []struct {
+		name       string
+		revision   string
+		options    CloneOptions
+		wantFiles  []string
+		wantCommit string
+		wantErr    string
+	}{
+		{
+			name:       "should clone the default branch",
+			wantFiles:  []string{"devfile.yaml"},
+			wantCommit: mainCommit,
+		},
+		{
+			name:       "should clone a branch",
+			revision:   "feature",
+			wantFiles:  []string{"devfile.yaml", "feature.yaml"},
+			wantCommit: featureCommit,
+		},
+		{
+			name:       "should make a shallow clone of a branch",
+			revision:   "feature",
+			options:    CloneOptions{Depth: 1},
+			wantFiles:  []string{"devfile.yaml", "feature.yaml"},
+			wantCommit: featureCommit,
+		},
+		{
+			name:     "should fail with a missing branch",
+			revision: "missing",
+			wantErr:  "failed to switch repo to revision. repo dir: .*, revision: missing",
+		},
+	}
+	for _, backend := range []CloneBackend{CloneBackendExec, CloneBackendGoGit} {
+		for _, tt := range tests {
+			t.Run(string(backend)+" "+tt.name, func(t *testing.T) {
+				if err := SetCloneBackend(backend); err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				defer func() { _ = SetCloneBackend(CloneBackendExec) }()
+
+				gitUrl := repoUrl
+				gitUrl.Revision = tt.revision
+				destDir := t.TempDir()
+				result, err := gitUrl.CloneGitRepoWithResult(destDir, tt.options)
+				if (err != nil) != (tt.wantErr != "") {
+					t.Fatalf("Unexpected error: %v, want: %v", err, tt.wantErr)
+				}
+				if err != nil {
+					assert.Regexp(t, tt.wantErr, err.Error(), "Error message should match")
+					return
+				}
+				if result.CommitSHA != tt.wantCommit {
+					t.Errorf("Got commit: %v, want: %v", result.CommitSHA, tt.wantCommit)
+				}
+				for _, file := range tt.wantFiles {
+					if _, err := os.Stat(filepath.Join(destDir, file)); err != nil {
+						t.Errorf("Unexpected error: %v", err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func Test_CloneGitRepoBare(t *testing.T) {
+	repoUrl, _, featureCommit := initTestRepoWithBranches(t)
+
+	for _, backend := range []CloneBackend{CloneBackendExec, CloneBackendGoGit} {
+		t.Run(string(backend), func(t *testing.T) {
+			if err := SetCloneBackend(backend); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			defer func() { _ = SetCloneBackend(CloneBackendExec) }()
+
+			gitUrl := repoUrl
+			gitUrl.Revision = "feature"
+			destDir := t.TempDir()
+			result, err := gitUrl.CloneGitRepoWithResult(destDir, CloneOptions{Bare: true})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result.CommitSHA != featureCommit {
+				t.Errorf("Got commit: %v, want: %v", result.CommitSHA, featureCommit)
+			}
+			// a bare clone has the git dir content and no working tree
+			if _, err = os.Stat(filepath.Join(destDir, "HEAD")); err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if _, err = os.Stat(filepath.Join(destDir, "feature.yaml")); !os.IsNotExist(err) {
+				t.Errorf("Got a working tree file in the bare clone: %v", err)
+			}
+
+			// files are extracted from the bare clone with git archive
+			archive := exec.Command("git", "--git-dir", destDir, "archive", "--format=tar", "HEAD", "feature.yaml")
+			out, err := archive.Output()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			reader := tar.NewReader(bytes.NewReader(out))
+			for header, err := reader.Next(); header == nil || header.Name != "feature.yaml"; header, err = reader.Next() {
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+			}
+			content, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if string(content) != "feature.yaml" {
+				t.Errorf("Got: %s, want: %s", content, "feature.yaml")
+			}
+		})
+	}
+}
+
+func Test_CloneGitRepoNonEmptyDest(t *testing.T) {
+	repoUrl, mainCommit, _ := initTestRepoWithBranches(t)
+
+	tests := []struct {
+		name        string
+		policy      NonEmptyDestPolicy
+		destIsRepo  bool
+		wantContent map[string]string
+		wantMissing []string
+		wantErr     string
+	}{
+		{
+			name:        "should fail by default",
+			wantContent: map[string]string{"local.txt": "local", "devfile.yaml": "local"},
+			wantErr:     "failed to clone repo, destination directory: '.*' is not empty",
+		},
+		{
+			name:        "should fail with the Fail policy",
+			policy:      NonEmptyDestFail,
+			wantContent: map[string]string{"local.txt": "local", "devfile.yaml": "local"},
+			wantErr:     "failed to clone repo, destination directory: '.*' is not empty",
+		},
+		{
+			name:        "should clear the destination with the Clear policy",
+			policy:      NonEmptyDestClear,
+			wantContent: map[string]string{"devfile.yaml": "devfile.yaml"},
+			wantMissing: []string{"local.txt"},
+		},
+		{
+			name:        "should merge the clone into the destination with the Merge policy",
+			policy:      NonEmptyDestMerge,
+			wantContent: map[string]string{"local.txt": "local", "devfile.yaml": "devfile.yaml"},
+		},
+		{
+			name:       "should fail to merge into a git repo",
+			policy:     NonEmptyDestMerge,
+			destIsRepo: true,
+			wantErr:    "failed to clone repo, cannot merge into destination directory: '.*' which is a git repo",
+		},
+		{
+			name:    "should fail with an unknown policy",
+			policy:  "Overwrite",
+			wantErr: "failed to clone repo, unknown non-empty destination policy \"Overwrite\"",
+		},
+	}
+	for _, backend := range []CloneBackend{CloneBackendExec, CloneBackendGoGit} {
+		for _, tt := range tests {
+			t.Run(string(backend)+" "+tt.name, func(t *testing.T) {
+				if err := SetCloneBackend(backend); err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				defer func() { _ = SetCloneBackend(CloneBackendExec) }()
+
+				destDir := t.TempDir()
+				for _, name := range []string{"local.txt", "devfile.yaml"} {
+					if err := os.WriteFile(filepath.Join(destDir, name), []byte("local"), 0644); err != nil {
+						t.Fatalf("Unexpected error: %v", err)
+					}
+				}
+				if tt.destIsRepo {
+					runTestGit(t, destDir, "init", "--quiet")
+				}
+
+				gitUrl := repoUrl
+				result, err := gitUrl.CloneGitRepoWithResult(destDir, CloneOptions{NonEmptyDestPolicy: tt.policy})
+				if (err != nil) != (tt.wantErr != "") {
+					t.Fatalf("Unexpected error: %v, want: %v", err, tt.wantErr)
+				}
+				if err != nil {
+					assert.Regexp(t, tt.wantErr, err.Error(), "Error message should match")
+				} else if result.CommitSHA != mainCommit {
+					t.Errorf("Got commit: %v, want: %v", result.CommitSHA, mainCommit)
+				}
+				for name, want := range tt.wantContent {
+					content, err := os.ReadFile(filepath.Join(destDir, name))
+					if err != nil {
+						t.Errorf("Unexpected error: %v", err)
+					} else if string(content) != want {
+						t.Errorf("Got: %s, want: %s", content, want)
+					}
+				}
+				for _, name := range tt.wantMissing {
+					if _, err := os.Stat(filepath.Join(destDir, name)); !os.IsNotExist(err) {
+						t.Errorf("Got %s in the destination, want it removed: %v", name, err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func Test_ExtractFile(t *testing.T) {
+	repoUrl, mainCommit, _ := initTestRepoWithBranches(t)
+
+	tests := []struct {
+		name        string
+		options     CloneOptions
+		ref         string
+		path        string
+		gitTrace    bool
+		wantContent string
+		wantErr     string
+	}{
+		{
+			name:        "should extract a file of the checked out commit",
+			path:        "devfile.yaml",
+			wantContent: "devfile.yaml",
+		},
+		{
+			name:        "should extract only the content of a file while git writes to the standard error",
+			path:        "devfile.yaml",
+			gitTrace:    true,
+			wantContent: "devfile.yaml",
+		},
+		{
+			name:        "should extract a file of a remote branch",
+			ref:         "feature",
+			path:        "feature.yaml",
+			wantContent: "feature.yaml",
+		},
+		{
+			name:        "should extract a file of a commit",
+			ref:         mainCommit,
+			path:        "/devfile.yaml",
+			wantContent: "devfile.yaml",
+		},
+		{
+			name:        "should extract a file of a branch of a bare clone",
+			options:     CloneOptions{Bare: true},
+			ref:         "feature",
+			path:        "feature.yaml",
+			wantContent: "feature.yaml",
+		},
+		{
+			name:    "should fail to extract a missing file",
+			ref:     "main",
+			path:    "feature.yaml",
+			wantErr: "failed to extract file feature.yaml at main from the clone .*",
+		},
+	}
+	for _, backend := range []CloneBackend{CloneBackendExec, CloneBackendGoGit} {
+		for _, tt := range tests {
+			t.Run(string(backend)+" "+tt.name, func(t *testing.T) {
+				if err := SetCloneBackend(backend); err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				defer func() { _ = SetCloneBackend(CloneBackendExec) }()
+
+				gitUrl := repoUrl
+				if err := gitUrl.CloneGitRepoWithOptions(t.TempDir(), tt.options); err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if tt.gitTrace {
+					// git traces its commands to the standard error
+					t.Setenv("GIT_TRACE", "2")
+				}
+				got, err := gitUrl.ExtractFile(tt.ref, tt.path)
+				if (err != nil) != (tt.wantErr != "") {
+					t.Fatalf("Unexpected error: %v, want: %v", err, tt.wantErr)
+				}
+				if err != nil {
+					assert.Regexp(t, tt.wantErr, err.Error(), "Error message should match")
+				} else if string(got) != tt.wantContent {
+					t.Errorf("Got: %s, want: %s", got, tt.wantContent)
+				}
+			})
+		}
+	}
+
+	_, err := repoUrl.ExtractFile("", "devfile.yaml")
+	assert.Regexp(t, "failed to extract file devfile.yaml, the repo is not cloned", err.Error(), "Error message should match")
+}
+
+func Test_SetCloneBackend(t *testing.T) {
+	defer func() { _ = SetCloneBackend(CloneBackendExec) }()
+
+	err := SetCloneBackend("libgit2")
+	assert.Regexp(t, `unknown clone backend "libgit2"`, err.Error(), "Error message should match")
+
+	if err = SetCloneBackend(CloneBackendGoGit); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	gitUrl := GitUrl{Protocol: "https", Host: "github.com", Owner: "devfile", Repo: "library"}
+	err = gitUrl.CloneGitRepoWithOptions(t.TempDir(), CloneOptions{ObjectCacheDir: t.TempDir()})
+	assert.Regexp(t, "the ObjectCacheDir option is not supported by the go-git clone backend", err.Error(), "Error message should match")
+	err = gitUrl.CloneGitRepoWithOptions(t.TempDir(), CloneOptions{ProtocolVersion: 2})
+	assert.Regexp(t, "protocol version 2 is not supported by the go-git clone backend", err.Error(), "Error message should match")
+	if err = checkGoGitCloneOptions(CloneOptions{ProtocolVersion: 1}); err != nil {
+		t.Errorf("Unexpected error for protocol version 1: %v", err)
+	}
+}
+
+// telemetryRecorder records the telemetry events it receives
+type telemetryRecorder struct {
+	events []telemetry.Event
+}
+
+func (r *telemetryRecorder) Emit(event telemetry.Event) {
+	r.events = append(r.events, event)
+}
+
+func Test_CloneGitRepoTelemetry(t *testing.T) {
+	repoUrl, _, _ := initTestRepoWithBranches(t)
+
+	recorder := &telemetryRecorder{}
+	telemetry.SetTelemetrySink(recorder)
+	defer telemetry.SetTelemetrySink(nil)
+
+	if err := repoUrl.CloneGitRepo(t.TempDir()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	missingBranch := repoUrl
+	missingBranch.Revision = "missing"
+	if err := missingBranch.CloneGitRepo(t.TempDir()); err == nil {
+		t.Fatalf("Expected an error cloning a missing branch")
+	}
+
+	var gotTypes []telemetry.EventType
+	for _, event := range recorder.events {
+		gotTypes = append(gotTypes, event.Type)
+		if event.Properties["host"] != repoUrl.Host {
+			t.Errorf("Got host: %v, want: %v", event.Properties["host"], repoUrl.Host)
+		}
+	}
+	wantTypes := []telemetry.EventType{telemetry.CloneStarted, telemetry.CloneSucceeded, telemetry.CloneStarted, telemetry.CloneFailed}
+	if !reflect.DeepEqual(gotTypes, wantTypes) {
+		t.Errorf("Got: %v, want: %v", gotTypes, wantTypes)
+	}
+}
+
+func Test_CloneGitRepoCloneUsername(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	// the handler runs on the goroutines of the test server
+	var gotUsernameLock sync.Mutex
+	var gotUsername string
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, _, ok := r.BasicAuth()
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Basic realm="git"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		gotUsernameLock.Lock()
+		gotUsername = username
+		gotUsernameLock.Unlock()
+		http.NotFound(w, r)
+	}))
+	defer testServer.Close()
+	host := strings.TrimPrefix(testServer.URL, "http://")
+	t.Setenv("GIT_TERMINAL_PROMPT", "0")
+
+	tests := []struct {
+		name          string
+		provider      string
+		cloneUsername string
+		wantUsername  string
+	}{
+		{
+			name:         "should authenticate as token by default",
+			provider:     GitHubHost,
+			wantUsername: "token",
+		},
+		{
+			name:         "should authenticate as x-token-auth by default for Bitbucket",
+			provider:     BitbucketHost,
+			wantUsername: "x-token-auth",
+		},
+		{
+			name:          "should authenticate as the clone username",
+			provider:      GitLabHost,
+			cloneUsername: "oauth2",
+			wantUsername:  "oauth2",
+		},
+		{
+			name:          "should authenticate as the clone username for Bitbucket",
+			provider:      BitbucketHost,
+			cloneUsername: "devfile-user",
+			wantUsername:  "devfile-user",
+		},
+	}
+	for _, backend := range []CloneBackend{CloneBackendExec, CloneBackendGoGit} {
+		for _, tt := range tests {
+			t.Run(string(backend)+" "+tt.name, func(t *testing.T) {
+				if err := SetCloneBackend(backend); err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				defer func() { _ = SetCloneBackend(CloneBackendExec) }()
+				if err := RegisterGitHost(GitHost{Host: host, Provider: tt.provider, APIBaseURL: testServer.URL}); err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				defer UnregisterGitHost(host)
+
+				gotUsernameLock.Lock()
+				gotUsername = ""
+				gotUsernameLock.Unlock()
+				g := GitUrl{
+					Protocol: "http",
+					Host:     host,
+					Owner:    "owner",
+					Repo:     "repo",
+					token:    "fake-token",
+				}
+				// the test server rejects every clone once the credentials are received
+				_, err := g.CloneGitRepoWithResult(t.TempDir(), CloneOptions{CloneUsername: tt.cloneUsername})
+				if err == nil {
+					t.Fatalf("Expected an error cloning from the test server")
+				}
+				gotUsernameLock.Lock()
+				defer gotUsernameLock.Unlock()
+				if gotUsername != tt.wantUsername {
+					t.Errorf("Got: %v, want: %v", gotUsername, tt.wantUsername)
+				}
+			})
+		}
+	}
+}
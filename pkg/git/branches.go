@@ -0,0 +1,89 @@
+//
+// Copyright 2023 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ResolveSlashedBranch resolves the branch of a Bitbucket url whose branch contains slashes, e.g. release/1.2 in
+// https://bitbucket.org/owner/repo/src/release/1.2/devfile.yaml, which ParseGitUrl splits at the first slash into the
+// release branch and the 1.2/devfile.yaml path. The branches of the repo are listed with the Bitbucket branches API, and the
+// longest branch matching the start of the revision and path is kept as the revision, the rest being the path.
+// The GitUrl is left unchanged if no branch with slashes matches, e.g. if its revision is a tag or a commit id.
+func (g *GitUrl) ResolveSlashedBranch(httpTimeout *int) error {
+	if g.provider() != BitbucketHost {
+		return fmt.Errorf("failed to resolve the branch, %s is not a Bitbucket host", g.Host)
+	}
+	if g.Revision == "" || g.Path == "" {
+		return nil
+	}
+
+	token, err := g.refreshToken(context.Background())
+	if err != nil {
+		return err
+	}
+	branches, err := g.bitbucketBranches(token, httpTimeout)
+	if err != nil {
+		return err
+	}
+
+	segments := strings.Split(g.Revision+"/"+g.Path, "/")
+	// a match of the first segment only is the revision as parsed
+	for i := len(segments); i > 1; i-- {
+		branch := strings.Join(segments[:i], "/")
+		if !branches[branch] {
+			continue
+		}
+		g.Revision = branch
+		g.Path = strings.Join(segments[i:], "/")
+		g.IsFile = filepath.Ext(g.Path) != ""
+		return nil
+	}
+	return nil
+}
+
+// bitbucketBranches lists the names of the branches of the Bitbucket repo, following the pages of the branches API
+func (g *GitUrl) bitbucketBranches(token string, httpTimeout *int) (map[string]bool, error) {
+	var page struct {
+		Values []struct {
+			Name string `json:"name"`
+		} `json:"values"`
+		Next string `json:"next"`
+	}
+
+	branches := make(map[string]bool)
+	next := fmt.Sprintf("%s/repositories/%s/%s/refs/branches?pagelen=100", g.apiBaseURL(), g.Owner, g.Repo)
+	for next != "" {
+		res, err := HTTPGetRequest(HTTPRequestParams{URL: next, Token: token, Timeout: httpTimeout}, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the branches of the repo: %v", err)
+		}
+		page.Values, page.Next = nil, ""
+		if err = json.Unmarshal(res, &page); err != nil {
+			return nil, fmt.Errorf("failed to decode the branches from %s: %v", next, err)
+		}
+		for _, branch := range page.Values {
+			branches[branch.Name] = true
+		}
+		next = page.Next
+	}
+	return branches, nil
+}
@@ -0,0 +1,105 @@
+//
+// Copyright 2023 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ResolveSlashedBranch(t *testing.T) {
+	var testServer *httptest.Server
+	testServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/2.0/repositories/owner/repo/refs/branches" {
+			http.NotFound(w, r)
+			return
+		}
+		// the branches are listed in two pages
+		if r.URL.Query().Get("page") == "" {
+			_, _ = w.Write([]byte(`{"values":[{"name":"main"},{"name":"release"}],"next":"` + testServer.URL + `/2.0/repositories/owner/repo/refs/branches?pagelen=100&page=2"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"values":[{"name":"release/1.2"},{"name":"feature/a/b"}]}`))
+	}))
+	defer testServer.Close()
+	host := strings.TrimPrefix(testServer.URL, "http://")
+
+	err := RegisterGitHost(GitHost{Host: host, Provider: BitbucketHost, APIBaseURL: testServer.URL + "/2.0"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer UnregisterGitHost(host)
+
+	tests := []struct {
+		name    string
+		url     string
+		want    GitUrl
+		wantErr string
+	}{
+		{
+			name: "should resolve a branch with a slash",
+			url:  "http://" + host + "/owner/repo/src/release/1.2/stack/devfile.yaml",
+			want: GitUrl{Protocol: "http", Host: host, Owner: "owner", Repo: "repo", Revision: "release/1.2", Path: "stack/devfile.yaml", IsFile: true},
+		},
+		{
+			name: "should resolve a branch with several slashes from the second page of branches",
+			url:  "http://" + host + "/owner/repo/src/feature/a/b/stack",
+			want: GitUrl{Protocol: "http", Host: host, Owner: "owner", Repo: "repo", Revision: "feature/a/b", Path: "stack"},
+		},
+		{
+			name: "should keep a branch without a slash",
+			url:  "http://" + host + "/owner/repo/src/main/1.2/devfile.yaml",
+			want: GitUrl{Protocol: "http", Host: host, Owner: "owner", Repo: "repo", Revision: "main", Path: "1.2/devfile.yaml", IsFile: true},
+		},
+		{
+			name: "should keep a revision that is not a branch",
+			url:  "http://" + host + "/owner/repo/src/v1.0.0/devfile.yaml",
+			want: GitUrl{Protocol: "http", Host: host, Owner: "owner", Repo: "repo", Revision: "v1.0.0", Path: "devfile.yaml", IsFile: true},
+		},
+		{
+			name:    "should fail if the branches cannot be listed",
+			url:     "http://" + host + "/owner/missing/src/release/1.2/devfile.yaml",
+			wantErr: "failed to list the branches of the repo: failed to retrieve .*, 404: Not Found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := ParseGitUrl(tt.url)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			err = g.ResolveSlashedBranch(nil)
+			if (err != nil) != (tt.wantErr != "") {
+				t.Fatalf("Unexpected error: %v, want: %v", err, tt.wantErr)
+			}
+			if err != nil {
+				assert.Regexp(t, tt.wantErr, err.Error(), "Error message should match")
+			} else if g != tt.want {
+				t.Errorf("Got: %+v, want: %+v", g, tt.want)
+			}
+		})
+	}
+
+	t.Run("should fail for a url that is not a Bitbucket url", func(t *testing.T) {
+		g := GitUrl{Protocol: "https", Host: GitHubHost, Owner: "owner", Repo: "repo", Revision: "release", Path: "1.2/devfile.yaml"}
+		err := g.ResolveSlashedBranch(nil)
+		assert.Regexp(t, "failed to resolve the branch, github.com is not a Bitbucket host", err.Error(), "Error message should match")
+	})
+}
@@ -0,0 +1,135 @@
+//
+// Copyright 2023 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// fileFetchStrategy is a way of fetching the file of a GitUrl
+type fileFetchStrategy struct {
+	name  string
+	fetch func(g *GitUrl, token string, httpTimeout *int) ([]byte, error)
+}
+
+var (
+	rawFileStrategy      = fileFetchStrategy{name: "raw file", fetch: (*GitUrl).fetchRawFile}
+	contentsAPIStrategy  = fileFetchStrategy{name: "contents API", fetch: (*GitUrl).fetchFromContentsAPI}
+	shallowCloneStrategy = fileFetchStrategy{name: "shallow clone", fetch: (*GitUrl).fetchFromShallowClone}
+)
+
+// fileFetchStrategies returns the strategies of fetching a file from the git provider of the GitUrl, in order of preference.
+// Bitbucket serves raw files from its REST API, so it has no separate contents API.
+func (g *GitUrl) fileFetchStrategies() []fileFetchStrategy {
+	switch g.provider() {
+	case GitHubHost, GitLabHost:
+		return []fileFetchStrategy{rawFileStrategy, contentsAPIStrategy, shallowCloneStrategy}
+	case BitbucketHost:
+		return []fileFetchStrategy{rawFileStrategy, shallowCloneStrategy}
+	default:
+		return nil
+	}
+}
+
+// FetchFileWithFallback fetches the file of the GitUrl from its raw file endpoint, falling back to the contents API of the
+// git provider, then to a shallow clone of the repo, and returns the content of the first success. The fallbacks cover files
+// that a git provider does not serve from a given endpoint, e.g. large files are not returned by the GitHub contents API.
+// The token authenticates the requests and the clone, the GitUrl token is used if empty.
+func (g *GitUrl) FetchFileWithFallback(token string, httpTimeout *int) ([]byte, error) {
+	if !g.IsFile || g.Path == "" {
+		return nil, fmt.Errorf("failed to fetch file, url should point to a file in the repo")
+	}
+	strategies := g.fileFetchStrategies()
+	if len(strategies) == 0 {
+		return nil, fmt.Errorf("failed to fetch file, %s is not a supported git provider", g.Host)
+	}
+
+	var err error
+	if token == "" {
+		if token, err = g.refreshToken(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+
+	var failures []string
+	for _, strategy := range strategies {
+		content, err := strategy.fetch(g, token, httpTimeout)
+		if err == nil {
+			return content, nil
+		}
+		failures = append(failures, fmt.Sprintf("%s: %v", strategy.name, err))
+	}
+	return nil, fmt.Errorf("failed to fetch file %s of repo %s/%s:\n%s", g.Path, g.Owner, g.Repo, strings.Join(failures, "\n"))
+}
+
+// fetchRawFile fetches the file from the raw file endpoint of the git provider
+func (g *GitUrl) fetchRawFile(token string, httpTimeout *int) ([]byte, error) {
+	return HTTPGetRequest(HTTPRequestParams{URL: g.GitRawFileAPI(), Token: token, Timeout: httpTimeout}, 0)
+}
+
+// fetchFromContentsAPI fetches the base64 encoded file from the contents API of GitHub or the files API of GitLab
+func (g *GitUrl) fetchFromContentsAPI(token string, httpTimeout *int) ([]byte, error) {
+	var file struct {
+		Content  string `json:"content"`
+		Encoding string `json:"encoding"`
+	}
+
+	var apiURL string
+	switch g.provider() {
+	case GitHubHost:
+		apiURL = fmt.Sprintf("%s/repos/%s/%s/contents/%s?ref=%s", g.apiBaseURL(), g.Owner, g.Repo, g.Path, g.Revision)
+	case GitLabHost:
+		apiURL = fmt.Sprintf("%s/projects/%s%%2F%s/repository/files/%s?ref=%s", g.apiBaseURL(), g.Owner, g.Repo, g.Path, g.Revision)
+	default:
+		return nil, fmt.Errorf("%s has no contents API", g.Host)
+	}
+
+	res, err := HTTPGetRequest(HTTPRequestParams{URL: apiURL, Token: token, Timeout: httpTimeout}, 0)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(res, &file); err != nil {
+		return nil, fmt.Errorf("failed to decode the file from %s: %v", apiURL, err)
+	}
+	// GitHub does not return the content of files larger than 1MB
+	if file.Encoding != "base64" {
+		return nil, fmt.Errorf("unsupported encoding %q of the file from %s", file.Encoding, apiURL)
+	}
+	return base64.StdEncoding.DecodeString(strings.ReplaceAll(file.Content, "\n", ""))
+}
+
+// fetchFromShallowClone reads the file from a shallow clone of the repo in a temporary directory
+func (g *GitUrl) fetchFromShallowClone(token string, _ *int) ([]byte, error) {
+	cloneDir, err := os.MkdirTemp("", "fetch-"+g.Repo+"-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(cloneDir)
+
+	clone := *g
+	clone.token = token
+	clone.tokenProvider = nil
+	if err = clone.CloneGitRepoWithOptions(cloneDir, CloneOptions{Depth: 1}); err != nil {
+		return nil, err
+	}
+	return os.ReadFile(filepath.Join(cloneDir, filepath.FromSlash(g.Path)))
+}
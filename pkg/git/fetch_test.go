@@ -0,0 +1,169 @@
+//
+// Copyright 2023 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_FetchFileWithFallback(t *testing.T) {
+	const content = "schemaVersion: 2.2.0\n"
+
+	var rawFails, contentsFails, cloneFails bool
+	var stages []string
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		escapedPath := r.URL.EscapedPath()
+		switch {
+		// GitHub Enterprise, GitLab and Bitbucket raw file endpoints
+		case strings.HasPrefix(escapedPath, "/raw/"), strings.HasSuffix(escapedPath, "/raw"), strings.Contains(escapedPath, "/src/"):
+			stages = append(stages, "raw")
+			if rawFails {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = w.Write([]byte(content))
+		// GitHub contents API and GitLab files API
+		case strings.Contains(escapedPath, "/contents/"), strings.Contains(escapedPath, "/repository/files/"):
+			stages = append(stages, "contents")
+			if contentsFails {
+				http.NotFound(w, r)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"content":  base64.StdEncoding.EncodeToString([]byte(content)),
+				"encoding": "base64",
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer testServer.Close()
+	host := strings.TrimPrefix(testServer.URL, "http://")
+
+	originalExecute := execute
+	defer func() { execute = originalExecute }()
+	execute = func(baseDir string, cmd CommandType, args ...string) ([]byte, error) {
+		if len(args) > 0 && args[0] == "clone" {
+			stages = append(stages, "clone")
+			if cloneFails {
+				return []byte("fatal: repository not found"), os.ErrNotExist
+			}
+			return nil, os.WriteFile(filepath.Join(args[len(args)-1], "devfile.yaml"), []byte(content), 0644)
+		}
+		return nil, nil
+	}
+
+	tests := []struct {
+		name          string
+		provider      string
+		apiPrefix     string
+		rawFails      bool
+		contentsFails bool
+		cloneFails    bool
+		wantStages    []string
+		wantErr       string
+	}{
+		{
+			name:       "should fetch a GitHub file from the raw endpoint",
+			provider:   GitHubHost,
+			apiPrefix:  "/api/v3",
+			wantStages: []string{"raw"},
+		},
+		{
+			name:       "should fall back to the GitHub contents API",
+			provider:   GitHubHost,
+			apiPrefix:  "/api/v3",
+			rawFails:   true,
+			wantStages: []string{"raw", "contents"},
+		},
+		{
+			name:          "should fall back to a shallow clone of a GitHub repo",
+			provider:      GitHubHost,
+			apiPrefix:     "/api/v3",
+			rawFails:      true,
+			contentsFails: true,
+			wantStages:    []string{"raw", "contents", "clone"},
+		},
+		{
+			name:       "should fall back to the GitLab files API",
+			provider:   GitLabHost,
+			apiPrefix:  "/api/v4",
+			rawFails:   true,
+			wantStages: []string{"raw", "contents"},
+		},
+		{
+			name:       "should fall back to a shallow clone of a Bitbucket repo",
+			provider:   BitbucketHost,
+			apiPrefix:  "/2.0",
+			rawFails:   true,
+			wantStages: []string{"raw", "clone"},
+		},
+		{
+			name:          "should fail if every strategy fails",
+			provider:      GitHubHost,
+			apiPrefix:     "/api/v3",
+			rawFails:      true,
+			contentsFails: true,
+			cloneFails:    true,
+			wantStages:    []string{"raw", "contents", "clone"},
+			wantErr:       "failed to fetch file devfile.yaml of repo owner/repo:\nraw file: .*\ncontents API: .*\nshallow clone: .*",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := RegisterGitHost(GitHost{Host: host, Provider: tt.provider, APIBaseURL: testServer.URL + tt.apiPrefix})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			defer UnregisterGitHost(host)
+
+			rawFails, contentsFails, cloneFails = tt.rawFails, tt.contentsFails, tt.cloneFails
+			stages = nil
+
+			g := GitUrl{
+				Protocol: "http",
+				Host:     host,
+				Owner:    "owner",
+				Repo:     "repo",
+				Revision: "main",
+				Path:     "devfile.yaml",
+				IsFile:   true,
+			}
+			got, err := g.FetchFileWithFallback("", nil)
+			if (err != nil) != (tt.wantErr != "") {
+				t.Fatalf("Unexpected error: %v, want: %v", err, tt.wantErr)
+			}
+			if err != nil {
+				assert.Regexp(t, tt.wantErr, err.Error(), "Error message should match")
+			} else if string(got) != content {
+				t.Errorf("Got: %s, want: %s", got, content)
+			}
+			if !reflect.DeepEqual(stages, tt.wantStages) {
+				t.Errorf("Got stages: %v, want: %v", stages, tt.wantStages)
+			}
+		})
+	}
+}
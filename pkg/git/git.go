@@ -16,12 +16,24 @@
 package git
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"net/url"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/devfile/library/v2/pkg/telemetry"
+	gitpkg "github.com/go-git/go-git/v5"
+	"k8s.io/klog"
 )
 
 const (
@@ -29,8 +41,15 @@ const (
 	RawGitHubHost string = "raw.githubusercontent.com"
 	GitLabHost    string = "gitlab.com"
 	BitbucketHost string = "bitbucket.org"
+	// GistHost and RawGistHost serve GitHub gists, single or multi-file snippets shared as git repos
+	GistHost    string = "gist.github.com"
+	RawGistHost string = "gist.githubusercontent.com"
 )
 
+// GitProtocol is the scheme of the anonymous git protocol, e.g. git://github.com/devfile/library.git,
+// served by read-only mirrors. Repos are cloned over it without authentication.
+const GitProtocol string = "git"
+
 type GitUrl struct {
 	Protocol string // URL scheme
 	Host     string // URL domain name
@@ -40,25 +59,62 @@ type GitUrl struct {
 	Path     string // path to a directory or file in the repo
 	token    string // authenticates private repo actions for parent devfiles
 	IsFile   bool   // defines if the URL points to a file in the repo
+
+	tokenProvider TokenProvider // mints the token before each clone, takes precedence over token
+	cloneDir      string        // directory of the last successful clone of the repo, read by ExtractFile
 }
 
-// NewGitUrlWithURL NewGitUrl creates a GitUrl from a string url
-func NewGitUrlWithURL(url string) (GitUrl, error) {
-	gitUrl, err := ParseGitUrl(url)
+// TokenProvider provides the token used to authenticate requests to a git provider.
+// It is called before each request or clone so that short-lived tokens, such as
+// GitHub App installation tokens, can be refreshed.
+type TokenProvider interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// StaticTokenProvider is a TokenProvider that always returns the same token
+type StaticTokenProvider string
+
+// Token returns the static token
+func (s StaticTokenProvider) Token(ctx context.Context) (string, error) {
+	return string(s), nil
+}
+
+// FileTokenProvider is a TokenProvider that reads the token from a file, such as a mounted Kubernetes secret.
+// The file is read each time a token is requested so that rotated secrets are picked up.
+type FileTokenProvider string
+
+// Token returns the content of the token file, trimmed of surrounding whitespace
+func (f FileTokenProvider) Token(ctx context.Context) (string, error) {
+	content, err := os.ReadFile(filepath.Clean(string(f)))
 	if err != nil {
-		return gitUrl, err
+		return "", fmt.Errorf("failed to read token from file %s: %v", string(f), err)
 	}
-	return gitUrl, nil
+	token := strings.TrimSpace(string(content))
+	if token == "" {
+		return "", fmt.Errorf("token file %s is empty", string(f))
+	}
+	return token, nil
+}
+
+// NewGitUrlWithURL NewGitUrl creates a GitUrl from a string url
+// On failure, the partially parsed GitUrl is returned with the error, as with ParseGitUrl
+func NewGitUrlWithURL(url string) (GitUrl, error) {
+	return ParseGitUrl(url)
 }
 
 // ParseGitUrl extracts information from a support git url
-// Only supports git repositories hosted on GitHub, GitLab, and Bitbucket
+// Only supports git repositories hosted on GitHub, GitLab, and Bitbucket, or on an instance registered with RegisterGitHost
+// On failure, the GitUrl is returned with the fields parsed before the failure, e.g. the owner and repo of a url missing its
+// branch, so that callers can suggest corrections
 func ParseGitUrl(fullUrl string) (GitUrl, error) {
 	var g GitUrl
 	err := ValidateURL(fullUrl)
 	if err != nil {
 		return g, err
 	}
+	if err = CheckHostAllowed(fullUrl); err != nil {
+		return g, err
+	}
 
 	parsedUrl, err := url.Parse(fullUrl)
 	if err != nil {
@@ -69,23 +125,211 @@ func ParseGitUrl(fullUrl string) (GitUrl, error) {
 		return g, fmt.Errorf("url path should not be empty")
 	}
 
-	if parsedUrl.Host == RawGitHubHost || parsedUrl.Host == GitHubHost {
+	g.Host = parsedUrl.Host
+	switch g.provider() {
+	case GitHubHost:
 		err = g.parseGitHubUrl(parsedUrl)
-	} else if parsedUrl.Host == GitLabHost {
+	case GitLabHost:
 		err = g.parseGitLabUrl(parsedUrl)
-	} else if parsedUrl.Host == BitbucketHost {
+	case BitbucketHost:
 		err = g.parseBitbucketUrl(parsedUrl)
-	} else {
+	default:
 		err = fmt.Errorf("url host should be a valid GitHub, GitLab, or Bitbucket host; received: %s", parsedUrl.Host)
 	}
 
+	// git protocol urls are clone urls, e.g. git://github.com/devfile/library.git
+	if err == nil && g.Protocol == GitProtocol {
+		g.Repo = strings.TrimSuffix(g.Repo, ".git")
+	}
+	if err == nil {
+		g.Path, err = cleanRepoPath(g.Path)
+	}
+
 	return g, err
 }
 
+// cleanRepoPath removes the redundant separators and the dot-segments of the path of a file or directory in a repo, e.g.
+// dir/./sub//../devfile.yaml is cleaned to dir/devfile.yaml, so that the raw file urls built from it are valid.
+// Returns an error if the path escapes the root of the repo.
+func cleanRepoPath(repoPath string) (string, error) {
+	if repoPath == "" {
+		return "", nil
+	}
+	// a path starting with a separator, e.g. from blob/main//devfile.yaml, is relative to the root of the repo
+	cleaned := path.Clean(strings.TrimLeft(repoPath, "/"))
+	if cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("url path %s escapes the root of the repo", repoPath)
+	}
+	if cleaned == "." {
+		return "", nil
+	}
+	return cleaned, nil
+}
+
+// shorthandProviders are the git provider hosts of the provider prefixes of shorthand references
+var shorthandProviders = map[string]string{
+	"github":    GitHubHost,
+	"gitlab":    GitLabHost,
+	"bitbucket": BitbucketHost,
+}
+
+// ParseShorthand expands a shorthand reference to a git repo into a GitUrl, e.g. devfile/library for https://github.com/devfile/library.
+// The reference is of the form [provider:]owner/repo[@branch], where provider is one of github, gitlab or bitbucket and defaults to github.
+// A GitLab repo may be in nested groups, e.g. gitlab:group/subgroup/repo.
+func ParseShorthand(ref string) (GitUrl, error) {
+	invalidRefErr := fmt.Errorf("invalid shorthand reference %q, should be [provider:]owner/repo[@branch]", ref)
+
+	host := GitHubHost
+	if provider, rest, found := strings.Cut(ref, ":"); found {
+		var ok bool
+		if host, ok = shorthandProviders[provider]; !ok {
+			return GitUrl{}, fmt.Errorf("invalid shorthand reference %q, provider should be one of github, gitlab or bitbucket; received: %s", ref, provider)
+		}
+		ref = rest
+	}
+	repoPath, branch, hasBranch := strings.Cut(ref, "@")
+	if hasBranch && branch == "" {
+		return GitUrl{}, invalidRefErr
+	}
+
+	parts := strings.Split(repoPath, "/")
+	if len(parts) < 2 || (len(parts) > 2 && host != GitLabHost) {
+		return GitUrl{}, invalidRefErr
+	}
+	for _, part := range parts {
+		if part == "" {
+			return GitUrl{}, invalidRefErr
+		}
+	}
+
+	g, err := ParseGitUrl(fmt.Sprintf("https://%s/%s", host, repoPath))
+	if err != nil {
+		return GitUrl{}, err
+	}
+	g.Revision = branch
+	return g, nil
+}
+
+// GitUrlFromLocalRepo creates a GitUrl from the remote of a local git repository containing the given path.
+// The origin remote is preferred, otherwise the first remote by name is used. The revision is set to the
+// current branch, or to the commit id if HEAD is detached. If path is inside the working tree, it is set as the GitUrl path.
+func GitUrlFromLocalRepo(path string) (*GitUrl, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	repo, err := gitpkg.PlainOpenWithOptions(absPath, &gitpkg.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open git repository at %s. error: %v", path, err)
+	}
+
+	remote, err := repo.Remote("origin")
+	if err == gitpkg.ErrRemoteNotFound {
+		remotes, err := repo.Remotes()
+		if err != nil {
+			return nil, err
+		}
+		if len(remotes) == 0 {
+			return nil, fmt.Errorf("git repository at %s does not have any remotes", path)
+		}
+		sort.Slice(remotes, func(i, j int) bool {
+			return remotes[i].Config().Name < remotes[j].Config().Name
+		})
+		remote = remotes[0]
+	} else if err != nil {
+		return nil, err
+	}
+
+	remoteUrl := remote.Config().URLs[0]
+	if strings.HasPrefix(remoteUrl, "git@") {
+		// convert scp-like ssh remotes, e.g. git@github.com:devfile/library.git
+		remoteUrl = "https://" + strings.Replace(strings.TrimPrefix(remoteUrl, "git@"), ":", "/", 1)
+	}
+	g, err := ParseGitUrl(strings.TrimSuffix(remoteUrl, ".git"))
+	if err != nil {
+		return nil, err
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HEAD of git repository at %s. error: %v", path, err)
+	}
+	if head.Name().IsBranch() {
+		g.Revision = head.Name().Short()
+	} else {
+		g.Revision = head.Hash().String()
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	relPath, err := filepath.Rel(worktree.Filesystem.Root(), absPath)
+	if err != nil {
+		return nil, err
+	}
+	if relPath != "." {
+		g.Path = filepath.ToSlash(relPath)
+		info, err := os.Stat(absPath)
+		if err != nil {
+			return nil, err
+		}
+		g.IsFile = !info.IsDir()
+	}
+
+	return &g, nil
+}
+
+// VerifyHeadSignature verifies that the HEAD commit of the git repository at repoDir, e.g. a cloned repo,
+// is signed by one of the allowed armored PGP public keys. An error is returned if the commit is unsigned
+// or not signed by an allowed key.
+func VerifyHeadSignature(repoDir string, allowedKeys []string) error {
+	if len(allowedKeys) == 0 {
+		return fmt.Errorf("failed to verify HEAD signature, no allowed keys provided")
+	}
+
+	repo, err := gitpkg.PlainOpen(repoDir)
+	if err != nil {
+		return fmt.Errorf("failed to open git repository at %s. error: %v", repoDir, err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return fmt.Errorf("failed to get HEAD of git repository at %s. error: %v", repoDir, err)
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return fmt.Errorf("failed to get HEAD commit of git repository at %s. error: %v", repoDir, err)
+	}
+
+	if commit.PGPSignature == "" {
+		return fmt.Errorf("HEAD commit %s of git repository at %s is not signed", commit.Hash, repoDir)
+	}
+	for _, key := range allowedKeys {
+		if _, err := commit.Verify(key); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("HEAD commit %s of git repository at %s is not signed by an allowed key", commit.Hash, repoDir)
+}
+
+// GetToken returns the last token set or minted for the GitUrl
 func (g *GitUrl) GetToken() string {
 	return g.token
 }
 
+// refreshToken mints a new token from the token provider, if set, with the context, and returns the current token
+func (g *GitUrl) refreshToken(ctx context.Context) (string, error) {
+	if g.tokenProvider != nil {
+		token, err := g.tokenProvider.Token(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to get token from token provider. error: %v", err)
+		}
+		g.token = token
+	}
+	return g.token, nil
+}
+
 type CommandType string
 
 const (
@@ -107,39 +351,344 @@ var execute = func(baseDir string, cmd CommandType, args ...string) ([]byte, err
 	return []byte(""), fmt.Errorf(unsupportedCmdMsg, string(cmd))
 }
 
+// executeStdout runs the command like execute, but returns its standard output only, e.g. the content of a file, so that the
+// warnings and hints written to its standard error are not mixed into it. The standard error is returned in the error of a failed command.
+// Exposed as a global variable for the purpose of running mock tests, only "git" is supported
+/* #nosec G204 -- used internally to execute git actions, calling methods validate user input to ensure commands are used appropriately */
+var executeStdout = func(baseDir string, cmd CommandType, args ...string) ([]byte, error) {
+	if cmd != GitCommand {
+		return []byte(""), fmt.Errorf(unsupportedCmdMsg, string(cmd))
+	}
+
+	c := exec.Command(string(cmd), args...)
+	c.Dir = baseDir
+	output, err := c.Output()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return output, fmt.Errorf("%v: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return output, err
+}
+
+// cancelDrainTimeout is how long the output of a command killed on the cancellation of its context is still read,
+// as the child processes of the command may keep its output open
+const cancelDrainTimeout = 100 * time.Millisecond
+
+// executeContext runs the command like execute, but streams its combined output to output as it is written and kills the
+// command when the context is done, so that the output written before a timeout or a cancellation is kept.
+// Exposed as a global variable for the purpose of running mock tests, only "git" is supported
+/* #nosec G204 -- used internally to execute git actions, calling methods validate user input to ensure commands are used appropriately */
+var executeContext = func(ctx context.Context, baseDir string, cmd CommandType, output io.Writer, args ...string) error {
+	if cmd != GitCommand {
+		return fmt.Errorf(unsupportedCmdMsg, string(cmd))
+	}
+
+	// the output is written to a pipe rather than copied from the command by exec, so that waiting for a killed command
+	// does not wait for its child processes to close their output
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	c := exec.CommandContext(ctx, string(cmd), args...)
+	c.Dir = baseDir
+	c.Stdout = writer
+	c.Stderr = writer
+	err = c.Start()
+	writer.Close()
+	if err != nil {
+		return err
+	}
+
+	copied := make(chan struct{})
+	go func() {
+		defer close(copied)
+		_, _ = io.Copy(output, reader)
+	}()
+	err = c.Wait()
+	if ctx.Err() != nil {
+		// read what is left of the output, without waiting for the child processes of the killed command
+		if reader.SetReadDeadline(time.Now().Add(cancelDrainTimeout)) != nil {
+			reader.Close()
+		}
+	}
+	<-copied
+	return err
+}
+
+// BranchNotFoundPolicy is the behavior of a clone when the revision of the GitUrl is not found in the repo
+type BranchNotFoundPolicy string
+
+const (
+	// BranchNotFoundError fails the clone, this is the default
+	BranchNotFoundError BranchNotFoundPolicy = "Error"
+	// BranchNotFoundFallbackToDefault retries once with the default branch of the repo, queried from the git provider,
+	// e.g. when the default branch of the repo was renamed. The GitUrl revision is set to the default branch.
+	BranchNotFoundFallbackToDefault BranchNotFoundPolicy = "FallbackToDefault"
+)
+
+// NonEmptyDestPolicy is the behavior of a clone when the destination directory is not empty
+type NonEmptyDestPolicy string
+
+const (
+	// NonEmptyDestFail fails the clone, this is the default
+	NonEmptyDestFail NonEmptyDestPolicy = "Fail"
+	// NonEmptyDestClear removes the content of the destination directory before the clone
+	NonEmptyDestClear NonEmptyDestPolicy = "Clear"
+	// NonEmptyDestMerge clones the repo into a temporary directory, then copies the clone over the content of the destination
+	// directory, replacing the files of the same paths and keeping the others. The destination directory must not be a git repo.
+	NonEmptyDestMerge NonEmptyDestPolicy = "Merge"
+)
+
+// CloneOptions holds optional settings for cloning a git repo
+type CloneOptions struct {
+	// MaxTotalBytes is the maximum size in bytes of the cloned repo on disk, 0 for no limit
+	MaxTotalBytes int64
+	// ShallowSince creates a shallow clone with history after the given time, the zero value clones the full history
+	ShallowSince time.Time
+	// FallbackToDefaultBranch retries once with the default branch of the repo, queried from the git provider,
+	// if the revision of the GitUrl is not found, e.g. when the default branch of the repo was renamed.
+	// Deprecated: use BranchNotFoundPolicy BranchNotFoundFallbackToDefault instead
+	FallbackToDefaultBranch bool
+	// BranchNotFoundPolicy is the behavior when the revision of the GitUrl is not found, BranchNotFoundError if empty
+	BranchNotFoundPolicy BranchNotFoundPolicy
+	// RevisionIsTag clones the revision of the GitUrl as a tag, fetching only the tagged snapshot with a shallow clone of depth 1
+	RevisionIsTag bool
+	// Depth creates a shallow clone of the last Depth commits of each branch, 0 clones the full history.
+	// The revision of the GitUrl, if any, must then be within the depth of a branch.
+	Depth int
+	// ObjectCacheDir is a bare git repo caching the git objects of the repos cloned with it, e.g. when cloning the repos of a registry.
+	// The objects of the repo are fetched into the cache, which is created if it does not exist, then the repo is cloned with the cache
+	// as a reference, so that objects shared between related repos are only downloaded once. The cloned repo does not depend on the cache.
+	ObjectCacheDir string
+	// Bare creates a bare clone of the repo, without a working tree, e.g. to extract files with git archive or GitUrl.ExtractFile.
+	// The revision of the GitUrl, if any, is the branch of the bare clone HEAD, and is not switched to the default branch if not found.
+	Bare bool
+	// NonEmptyDestPolicy is the behavior when the destination directory is not empty, NonEmptyDestFail if empty
+	NonEmptyDestPolicy NonEmptyDestPolicy
+	// ProtocolVersion is the version of the git wire protocol used by the clone, e.g. 2 for the faster ref advertisement of protocol
+	// version 2 on repos with many refs. The default 0 uses the version negotiated by git with the server. The go-git clone backend
+	// cannot speak version 2, it only accepts 0 and 1, which both use version 1.
+	ProtocolVersion int
+	// CloneUsername is the username authenticating with the token of the GitUrl, e.g. oauth2 for GitLab or the account of a
+	// Bitbucket app password. Defaults to x-token-auth for Bitbucket repos and to token otherwise. Unused without a token.
+	CloneUsername string
+}
+
+// CloneGitRepo clones the repo of the GitUrl into destDir with the default clone options
 func (g *GitUrl) CloneGitRepo(destDir string) error {
+	return g.CloneGitRepoWithOptions(destDir, CloneOptions{})
+}
+
+// CloneResult holds the outcome of a clone
+type CloneResult struct {
+	// Output is the combined output of the git commands run for the clone, with the token redacted
+	Output string
+	// CommitSHA is the commit id checked out by the clone, empty if the clone failed
+	CommitSHA string
+}
+
+// CloneGitRepoWithOptions clones the repo of the GitUrl into destDir with the given clone options
+func (g *GitUrl) CloneGitRepoWithOptions(destDir string, options CloneOptions) error {
+	return g.cloneGitRepo(context.Background(), destDir, options, io.Discard)
+}
+
+// CloneGitRepoWithResult clones the repo of the GitUrl into destDir with the given clone options.
+// The output of git is returned on both success and failure, and the commit id checked out on success.
+func (g *GitUrl) CloneGitRepoWithResult(destDir string, options CloneOptions) (CloneResult, error) {
+	return g.CloneGitRepoWithContext(context.Background(), destDir, options)
+}
+
+// CloneGitRepoWithContext clones the repo of the GitUrl into destDir with the given clone options like CloneGitRepoWithResult,
+// and interrupts the clone when the context is done, e.g. on a timeout. The output of git is streamed as the clone progresses,
+// so that an interrupted clone returns the output written so far, e.g. "Receiving objects: 40%", in its result and its error.
+// The error of an interrupted clone wraps the context error.
+func (g *GitUrl) CloneGitRepoWithContext(ctx context.Context, destDir string, options CloneOptions) (CloneResult, error) {
+	var output strings.Builder
+	err := g.cloneGitRepo(ctx, destDir, options, &output)
+	result := CloneResult{Output: output.String()}
+	if err != nil {
+		return result, err
+	}
+
+	result.CommitSHA, err = headCommit(getCloneBackend(), destDir)
+	if err != nil {
+		return result, fmt.Errorf("failed to get the commit id of the cloned repo. repo dir: %v, error: %v", destDir, err)
+	}
+	return result, nil
+}
+
+// cloneGitRepo clones the repo of the GitUrl into destDir and writes the output of git, with the token redacted, to output.
+// The clone is interrupted when the context is done.
+func (g *GitUrl) cloneGitRepo(ctx context.Context, destDir string, options CloneOptions, output io.Writer) (err error) {
+	properties := map[string]string{"host": g.Host}
+	telemetry.Emit(telemetry.Event{Type: telemetry.CloneStarted, Properties: properties})
+	defer func(start time.Time) {
+		telemetry.EmitResult(telemetry.CloneSucceeded, telemetry.CloneFailed, start, properties, err)
+	}(time.Now())
+
 	exist := CheckPathExists(destDir)
 	if !exist {
 		return fmt.Errorf("failed to clone repo, destination directory: '%s' does not exists", destDir)
 	}
+	switch options.NonEmptyDestPolicy {
+	case "", NonEmptyDestFail, NonEmptyDestClear, NonEmptyDestMerge:
+	default:
+		return fmt.Errorf("failed to clone repo, unknown non-empty destination policy %q", options.NonEmptyDestPolicy)
+	}
 
-	host := g.Host
-	if host == RawGitHubHost {
-		host = GitHubHost
+	empty, err := isEmptyDir(destDir)
+	if err != nil {
+		return fmt.Errorf("failed to clone repo, cannot read destination directory: %v", err)
+	}
+	if empty {
+		return g.cloneIntoDir(ctx, destDir, options, output)
+	}
+	switch options.NonEmptyDestPolicy {
+	case NonEmptyDestClear:
+		if err = clearDir(destDir); err != nil {
+			return fmt.Errorf("failed to clone repo, cannot clear destination directory: %v", err)
+		}
+		return g.cloneIntoDir(ctx, destDir, options, output)
+	case NonEmptyDestMerge:
+		return g.mergeCloneIntoDir(ctx, destDir, options, output)
+	default:
+		return fmt.Errorf("failed to clone repo, destination directory: '%s' is not empty", destDir)
 	}
+}
 
-	var repoUrl string
-	if g.GetToken() == "" {
-		repoUrl = fmt.Sprintf("%s://%s/%s/%s.git", g.Protocol, host, g.Owner, g.Repo)
-	} else {
-		repoUrl = fmt.Sprintf("%s://token:%s@%s/%s/%s.git", g.Protocol, g.GetToken(), host, g.Owner, g.Repo)
-		if g.Host == BitbucketHost {
-			repoUrl = fmt.Sprintf("%s://x-token-auth:%s@%s/%s/%s.git", g.Protocol, g.GetToken(), host, g.Owner, g.Repo)
+// mergeCloneIntoDir clones the repo of the GitUrl into a temporary directory, then copies the clone over the content of destDir
+func (g *GitUrl) mergeCloneIntoDir(ctx context.Context, destDir string, options CloneOptions, output io.Writer) error {
+	// the objects of two repos cannot be merged
+	if CheckPathExists(filepath.Join(destDir, ".git")) {
+		return fmt.Errorf("failed to clone repo, cannot merge into destination directory: '%s' which is a git repo", destDir)
+	}
+	tmpDir, err := os.MkdirTemp("", "git-clone-merge")
+	if err != nil {
+		return fmt.Errorf("failed to clone repo, cannot create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err = g.cloneIntoDir(ctx, tmpDir, options, output); err != nil {
+		return err
+	}
+	if err = mergeDir(tmpDir, destDir); err != nil {
+		return fmt.Errorf("failed to merge the cloned repo into destination directory: '%s': %v", destDir, err)
+	}
+	g.cloneDir = destDir
+	return nil
+}
+
+// cloneIntoDir clones the repo of the GitUrl into the existing, empty destDir
+func (g *GitUrl) cloneIntoDir(ctx context.Context, destDir string, options CloneOptions, output io.Writer) error {
+	switch options.BranchNotFoundPolicy {
+	case "", BranchNotFoundError, BranchNotFoundFallbackToDefault:
+	default:
+		return fmt.Errorf("failed to clone repo, unknown branch not found policy %q", options.BranchNotFoundPolicy)
+	}
+	if options.ProtocolVersion < 0 || options.ProtocolVersion > 2 {
+		return fmt.Errorf("failed to clone repo, unsupported protocol version %d, should be 0, 1 or 2", options.ProtocolVersion)
+	}
+	backend := getCloneBackend()
+	if backend == CloneBackendGoGit {
+		if err := checkGoGitCloneOptions(options); err != nil {
+			return err
 		}
 	}
+	if err := CheckHostAllowed(g.CloneURL()); err != nil {
+		return fmt.Errorf("failed to clone repo: %v", err)
+	}
 
-	_, err := execute(destDir, "git", "clone", repoUrl, destDir)
+	token, err := g.refreshToken(ctx)
+	if err != nil {
+		return err
+	}
+	// the git protocol does not support authentication
+	if g.Protocol == GitProtocol {
+		token = ""
+	}
+
+	args := []string{"clone"}
+	if !options.ShallowSince.IsZero() {
+		args = append(args, "--shallow-since="+options.ShallowSince.UTC().Format(time.RFC3339))
+	}
+	if options.Depth > 0 && !options.RevisionIsTag {
+		args = append(args, "--depth", strconv.Itoa(options.Depth), "--no-single-branch")
+	}
+	if options.RevisionIsTag {
+		if g.Revision == "" {
+			return fmt.Errorf("failed to clone repo, a revision is required to clone a tag")
+		}
+		args = append(args, "--branch", g.Revision, "--depth", "1")
+	}
+	if options.Bare {
+		args = append(args, "--bare")
+		if g.Revision != "" && !options.RevisionIsTag {
+			args = append(args, "--branch", g.Revision)
+		}
+	}
+	clone := func() ([]byte, error) {
+		if backend == CloneBackendGoGit {
+			return goGitClone(ctx, destDir, g.cloneURL(token, options.CloneUsername), g.Revision, options)
+		}
+		cloneArgs := append(append([]string{}, args[1:]...), g.cloneURL(token, options.CloneUsername), destDir)
+		// a context that is never done cannot interrupt the clone, so its output is not streamed
+		if ctx.Done() == nil {
+			return execute(destDir, "git", append(append(protocolVersionArgs(options.ProtocolVersion), "clone"), cloneArgs...)...)
+		}
+		// git reports its progress only to terminals, unless asked to
+		var out bytes.Buffer
+		err := executeContext(ctx, destDir, "git", &out, append(append(protocolVersionArgs(options.ProtocolVersion), "clone", "--progress"), cloneArgs...)...)
+		return out.Bytes(), err
+	}
+
+	redact := func(out []byte) []byte {
+		if token != "" {
+			out = bytes.ReplaceAll(out, []byte(token), []byte("<redacted>"))
+		}
+		return out
+	}
+	writeOutput := func(out []byte) {
+		_, _ = output.Write(redact(out))
+	}
+
+	if options.ObjectCacheDir != "" {
+		if err = g.fetchIntoObjectCache(options.ObjectCacheDir, token, options.CloneUsername, options.ProtocolVersion, writeOutput); err != nil {
+			return err
+		}
+		args = append(args, "--reference-if-able", options.ObjectCacheDir, "--dissociate")
+	}
+
+	out, err := clone()
+	writeOutput(out)
+	if err != nil && ctx.Err() != nil {
+		return fmt.Errorf("failed to clone repo, the clone was interrupted: %w. output so far:\n%s", ctx.Err(), redact(out))
+	}
+
+	// the token may have expired since it was provided, so a clone failing to authenticate is retried once with a fresh token
+	if err != nil && g.tokenProvider != nil && g.Protocol != GitProtocol && isAuthError(out) {
+		klog.V(4).Infof("failed to authenticate the clone of %s, retrying with a refreshed token", g.CloneURL())
+		if token, err = g.refreshToken(ctx); err != nil {
+			return err
+		}
+		out, err = clone()
+		writeOutput(out)
+	}
 
 	if err != nil {
-		if g.GetToken() == "" {
+		if token == "" {
 			return fmt.Errorf("failed to clone repo without a token, ensure that a token is set if the repo is private. error: %v", err)
 		} else {
 			return fmt.Errorf("failed to clone repo with token, ensure that the url and token is correct. error: %v", err)
 		}
 	}
 
-	if g.Revision != "" {
-		_, err := execute(destDir, "git", "switch", "--detach", "origin/"+g.Revision)
+	// a tag clone is already detached at the tag, and the HEAD of a bare clone is already the revision
+	if g.Revision != "" && !options.RevisionIsTag && !options.Bare {
+		out, err := switchRevision(backend, destDir, g.Revision)
+		writeOutput(out)
+		if err != nil && (options.FallbackToDefaultBranch || options.BranchNotFoundPolicy == BranchNotFoundFallbackToDefault) {
+			err = g.switchToDefaultBranch(backend, destDir, token)
+		}
 		if err != nil {
 			err = os.RemoveAll(destDir)
 			if err != nil {
@@ -149,9 +698,257 @@ func (g *GitUrl) CloneGitRepo(destDir string) error {
 		}
 	}
 
+	if options.MaxTotalBytes > 0 {
+		size, err := getDirSize(destDir)
+		if err != nil {
+			return fmt.Errorf("failed to get size of cloned repo. repo dir: %v, error: %v", destDir, err)
+		}
+		if size > options.MaxTotalBytes {
+			err = os.RemoveAll(destDir)
+			if err != nil {
+				return err
+			}
+			return fmt.Errorf("failed to clone repo, resource exceeds size limit of %d bytes. repo dir: %v", options.MaxTotalBytes, destDir)
+		}
+	}
+
+	g.cloneDir = destDir
+	return nil
+}
+
+// ExtractFile returns the content of the file at path in the repo at ref, read from the last clone of the GitUrl with `git cat-file blob <ref>:<path>`,
+// without copying the file out of the clone. This works with bare and shallow clones, as long as ref is in the clone.
+// The ref is a commit id, tag or branch, where a branch is also looked up among the remote branches of the clone, e.g. main for origin/main.
+// An empty ref is the commit checked out by the clone, or the HEAD of a bare clone.
+func (g *GitUrl) ExtractFile(ref string, path string) ([]byte, error) {
+	if g.cloneDir == "" {
+		return nil, fmt.Errorf("failed to extract file %s, the repo is not cloned", path)
+	}
+	if ref == "" {
+		ref = "HEAD"
+	}
+	path = strings.TrimPrefix(filepath.ToSlash(path), "/")
+
+	backend := getCloneBackend()
+	content, err := showFile(backend, g.cloneDir, ref, path)
+	if err != nil && ref != "HEAD" {
+		// branches of a clone with a working tree are remote branches, except the checked out branch
+		if remoteContent, remoteErr := showFile(backend, g.cloneDir, "origin/"+ref, path); remoteErr == nil {
+			return remoteContent, nil
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract file %s at %s from the clone %s: %v", path, ref, g.cloneDir, err)
+	}
+	return content, nil
+}
+
+// fetchIntoObjectCache fetches the branches of the repo into the bare repo of the object cache, creating the cache if it does not exist.
+// The refs of each repo are kept under refs/cache/<host>/<owner>/<repo>/ so that the refs of the cached repos do not overwrite each other.
+func (g *GitUrl) fetchIntoObjectCache(cacheDir string, token string, username string, protocolVersion int, writeOutput func([]byte)) error {
+	cacheDir, err := filepath.Abs(cacheDir)
+	if err != nil {
+		return err
+	}
+	if !CheckPathExists(filepath.Join(cacheDir, "objects")) {
+		if err = os.MkdirAll(cacheDir, 0750); err != nil {
+			return fmt.Errorf("failed to create the object cache %s: %v", cacheDir, err)
+		}
+		out, err := execute(cacheDir, "git", "init", "--bare", "--quiet", cacheDir)
+		writeOutput(out)
+		if err != nil {
+			return fmt.Errorf("failed to create the object cache %s: %v", cacheDir, err)
+		}
+	}
+
+	refspec := fmt.Sprintf("+refs/heads/*:refs/cache/%s/*", filepath.ToSlash(filepath.Join(hostname(g.Host), g.Owner, g.Repo)))
+	out, err := execute(cacheDir, "git", append(protocolVersionArgs(protocolVersion), "--git-dir", cacheDir, "fetch", "--quiet", "--no-tags", g.cloneURL(token, username), refspec)...)
+	writeOutput(out)
+	if err != nil {
+		return fmt.Errorf("failed to fetch the repo into the object cache %s: %v", cacheDir, err)
+	}
+	return nil
+}
+
+// isAuthError checks if the output of a failed git command reports an authentication failure. The HTTP status codes are only
+// matched in the failure lines of git, since the progress output of the clone, e.g. Receiving objects: 40/403, may contain them.
+func isAuthError(out []byte) bool {
+	output := strings.ToLower(string(out))
+	for _, message := range []string{"authentication failed", "authentication required", "authorization failed", "could not read username",
+		"http basic: access denied", "the requested url returned error: 401", "the requested url returned error: 403"} {
+		if strings.Contains(output, message) {
+			return true
+		}
+	}
+	return false
+}
+
+// protocolVersionArgs returns the git arguments selecting the wire protocol version, none for the default 0
+func protocolVersionArgs(protocolVersion int) []string {
+	if protocolVersion == 0 {
+		return []string{}
+	}
+	return []string{"-c", "protocol.version=" + strconv.Itoa(protocolVersion)}
+}
+
+// CloneURL returns the remote url of the repo of the GitUrl cloned by CloneGitRepo, without the token, e.g. https://github.com/devfile/library.git.
+// Raw GitHub urls are mapped to the GitHub repo.
+func (g *GitUrl) CloneURL() string {
+	return g.cloneURL("", "")
+}
+
+// cloneURL returns the remote url of the repo of the GitUrl, authenticated with the token if the token is not empty
+// and the protocol supports authentication. The username defaults to the username of the git provider for tokens if empty.
+func (g *GitUrl) cloneURL(token string, username string) string {
+	host := g.Host
+	if hostname(host) == RawGitHubHost {
+		host = GitHubHost
+	}
+
+	if token == "" || g.Protocol == GitProtocol {
+		return fmt.Sprintf("%s://%s/%s/%s.git", g.Protocol, host, g.Owner, g.Repo)
+	}
+	if username == "" {
+		username = "token"
+		if g.provider() == BitbucketHost {
+			username = "x-token-auth"
+		}
+	}
+	return fmt.Sprintf("%s://%s:%s@%s/%s/%s.git", g.Protocol, url.User(username).String(), token, host, g.Owner, g.Repo)
+}
+
+// switchToDefaultBranch switches the cloned repo in destDir to the default branch of the repo, and sets it as the GitUrl revision
+func (g *GitUrl) switchToDefaultBranch(backend CloneBackend, destDir string, token string) error {
+	defaultBranch, err := g.defaultBranch(HTTPRequestParams{Token: token})
+	if err != nil {
+		return err
+	}
+	if defaultBranch == g.Revision {
+		return fmt.Errorf("revision %s is the default branch of the repo", g.Revision)
+	}
+
+	klog.V(4).Infof("revision %s not found, retrying with the default branch %s", g.Revision, defaultBranch)
+	_, err = switchRevision(backend, destDir, defaultBranch)
+	if err != nil {
+		return err
+	}
+	g.Revision = defaultBranch
 	return nil
 }
 
+// defaultBranch queries the git provider for the default branch of the repo
+func (g *GitUrl) defaultBranch(params HTTPRequestParams) (string, error) {
+	var repo struct {
+		DefaultBranch string `json:"default_branch"` // GitHub and GitLab
+		MainBranch    struct {
+			Name string `json:"name"`
+		} `json:"mainbranch"` // Bitbucket
+	}
+
+	if params.URL = g.repoAPIURL(); params.URL == "" {
+		return "", fmt.Errorf("failed to get the default branch, %s is not a supported git provider", g.Host)
+	}
+
+	res, err := HTTPGetRequest(params, 0)
+	if err != nil {
+		return "", fmt.Errorf("failed to get the default branch of the repo: %v", err)
+	}
+	if err = json.Unmarshal(res, &repo); err != nil {
+		return "", fmt.Errorf("failed to decode the repo info from %s: %v", params.URL, err)
+	}
+
+	defaultBranch := repo.DefaultBranch
+	if defaultBranch == "" {
+		defaultBranch = repo.MainBranch.Name
+	}
+	if defaultBranch == "" {
+		return "", fmt.Errorf("failed to get the default branch of the repo from %s", params.URL)
+	}
+	return defaultBranch, nil
+}
+
+// repoAPIURL returns the REST API url of the repo of the GitUrl, or an empty string if the host is not a supported git provider
+func (g *GitUrl) repoAPIURL() string {
+	switch g.provider() {
+	case GitHubHost:
+		return fmt.Sprintf("%s/repos/%s/%s", g.apiBaseURL(), g.Owner, g.Repo)
+	case GitLabHost:
+		return fmt.Sprintf("%s/projects/%s%%2F%s", g.apiBaseURL(), g.Owner, g.Repo)
+	case BitbucketHost:
+		return fmt.Sprintf("%s/repositories/%s/%s", g.apiBaseURL(), g.Owner, g.Repo)
+	default:
+		return ""
+	}
+}
+
+// RepoInfo is the metadata of a git repo, as returned by the git provider API
+type RepoInfo struct {
+	// Description is the description of the repo
+	Description string
+	// Topics are the topics of a GitHub repo or the topics of a GitLab project. Bitbucket repos have no topics.
+	Topics []string
+	// DefaultBranch is the default branch of the repo
+	DefaultBranch string
+	// License is the SPDX identifier of the license of a GitHub repo, e.g. Apache-2.0, or the license key of a GitLab project,
+	// e.g. apache-2.0. Empty if the license is not detected by the git provider. Bitbucket repos have no license.
+	License string
+}
+
+// RepoMetadata returns the metadata of the repo of the GitUrl from the git provider API, authenticated with the GitUrl token if set
+func (g *GitUrl) RepoMetadata(httpTimeout *int) (RepoInfo, error) {
+	var repo struct {
+		Description   string   `json:"description"`
+		Topics        []string `json:"topics"`         // GitHub and GitLab
+		DefaultBranch string   `json:"default_branch"` // GitHub and GitLab
+		MainBranch    struct {
+			Name string `json:"name"`
+		} `json:"mainbranch"` // Bitbucket
+		License struct {
+			SPDXID string `json:"spdx_id"` // GitHub
+			Key    string `json:"key"`     // GitLab
+		} `json:"license"`
+	}
+
+	apiURL := g.repoAPIURL()
+	if apiURL == "" {
+		return RepoInfo{}, fmt.Errorf("failed to get the repo metadata, %s is not a supported git provider", g.Host)
+	}
+	if g.provider() == GitLabHost {
+		// the license of a GitLab project is only returned on request
+		apiURL += "?license=true"
+	}
+
+	token, err := g.refreshToken(context.Background())
+	if err != nil {
+		return RepoInfo{}, err
+	}
+	res, err := HTTPGetRequest(HTTPRequestParams{URL: apiURL, Token: token, Timeout: httpTimeout}, 0)
+	if err != nil {
+		return RepoInfo{}, fmt.Errorf("failed to get the metadata of the repo: %v", err)
+	}
+	if err = json.Unmarshal(res, &repo); err != nil {
+		return RepoInfo{}, fmt.Errorf("failed to decode the repo info from %s: %v", apiURL, err)
+	}
+
+	info := RepoInfo{
+		Description:   repo.Description,
+		Topics:        repo.Topics,
+		DefaultBranch: repo.DefaultBranch,
+		License:       repo.License.SPDXID,
+	}
+	if info.DefaultBranch == "" {
+		info.DefaultBranch = repo.MainBranch.Name
+	}
+	if info.License == "" {
+		info.License = repo.License.Key
+	}
+	// GitHub reports licenses it cannot identify as NOASSERTION
+	if info.License == "NOASSERTION" {
+		info.License = ""
+	}
+	return info, nil
+}
+
 func (g *GitUrl) parseGitHubUrl(url *url.URL) error {
 	var splitUrl []string
 	var err error
@@ -159,7 +956,11 @@ func (g *GitUrl) parseGitHubUrl(url *url.URL) error {
 	g.Protocol = url.Scheme
 	g.Host = url.Host
 
-	if g.Host == RawGitHubHost {
+	if isGistHost(g.Host) {
+		return g.parseGistUrl(url)
+	}
+
+	if hostname(g.Host) == RawGitHubHost {
 		g.IsFile = true
 		// raw GitHub urls don't contain "blob" or "tree"
 		// https://raw.githubusercontent.com/devfile/library/main/devfile.yaml -> [devfile library main devfile.yaml]
@@ -176,7 +977,7 @@ func (g *GitUrl) parseGitHubUrl(url *url.URL) error {
 		return err
 	}
 
-	if g.Host == GitHubHost {
+	if g.provider() == GitHubHost {
 		// https://github.com/devfile/library/blob/main/devfile.yaml -> [devfile library blob main devfile.yaml]
 		splitUrl = strings.SplitN(url.Path[1:], "/", 5)
 		if len(splitUrl) < 2 {
@@ -214,6 +1015,45 @@ func (g *GitUrl) parseGitHubUrl(url *url.URL) error {
 	return err
 }
 
+// parseGistUrl parses the url of a GitHub gist, whose owner is the gist user and whose repo is the gist id:
+// https://gist.github.com/<user>/<id>[/<revision>], or the raw url of a file of the gist,
+// https://gist.githubusercontent.com/<user>/<id>/raw[/<revision>]/<file>.
+// A gist is a file, the path being empty for the only file of a single-file gist.
+func (g *GitUrl) parseGistUrl(url *url.URL) error {
+	g.IsFile = true
+	splitUrl := strings.Split(strings.Trim(url.Path, "/"), "/")
+	if len(splitUrl) < 2 || splitUrl[0] == "" {
+		return fmt.Errorf("gist url path should contain <user>/<id>, received: %s", url.Path[1:])
+	}
+	g.Owner = splitUrl[0]
+	g.Repo = splitUrl[1]
+
+	if hostname(g.Host) == GistHost {
+		switch len(splitUrl) {
+		case 2:
+		case 3:
+			g.Revision = splitUrl[2]
+		default:
+			return fmt.Errorf("gist url path should contain <user>/<id>/<revision>, received: %s", url.Path[1:])
+		}
+		return nil
+	}
+
+	// raw gist urls
+	switch {
+	case len(splitUrl) < 4 || splitUrl[2] != "raw":
+		return fmt.Errorf("raw gist url path should contain <user>/<id>/raw/<revision>/<file>, received: %s", url.Path[1:])
+	case len(splitUrl) == 4:
+		g.Path = splitUrl[3]
+	case len(splitUrl) == 5:
+		g.Revision = splitUrl[3]
+		g.Path = splitUrl[4]
+	default:
+		return fmt.Errorf("raw gist url path should contain <user>/<id>/raw/<revision>/<file>, received: %s", url.Path[1:])
+	}
+	return nil
+}
+
 func (g *GitUrl) parseGitLabUrl(url *url.URL) error {
 	var splitFile, splitOrg []string
 	var err error
@@ -231,7 +1071,8 @@ func (g *GitUrl) parseGitLabUrl(url *url.URL) error {
 		return fmt.Errorf("url path should contain <user>/<repo>, received: %s", url.Path[1:])
 	} else {
 		g.Owner = splitOrg[0]
-		g.Repo = splitOrg[1]
+		// the project of the REST API urls is the repo without its .git suffix, e.g. gitlab.com/group/repo.git/-/blob/main/file
+		g.Repo = strings.TrimSuffix(splitOrg[1], ".git")
 	}
 
 	// url doesn't contain a path to a directory or file
@@ -298,22 +1139,111 @@ func (g *GitUrl) parseBitbucketUrl(url *url.URL) error {
 	return err
 }
 
+// gitUrlJSON is the JSON representation of a GitUrl, keeping the field names of the GitUrl
+type gitUrlJSON struct {
+	Protocol string
+	Host     string
+	Owner    string
+	Repo     string
+	Revision string
+	Path     string
+	IsFile   bool
+	Token    string `json:",omitempty"`
+}
+
+// MarshalJSON encodes the GitUrl without its token, so that credentials are not persisted by accident.
+// Use MarshalJSONWithToken to encode the token too. The token provider is never encoded.
+func (g GitUrl) MarshalJSON() ([]byte, error) {
+	return json.Marshal(g.toJSON(""))
+}
+
+// MarshalJSONWithToken encodes the GitUrl with its token, e.g. to store it in a secret store
+func (g GitUrl) MarshalJSONWithToken() ([]byte, error) {
+	return json.Marshal(g.toJSON(g.token))
+}
+
+// UnmarshalJSON decodes a GitUrl encoded by MarshalJSON or MarshalJSONWithToken, with its token if it was encoded
+func (g *GitUrl) UnmarshalJSON(data []byte) error {
+	var j gitUrlJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	*g = GitUrl{
+		Protocol: j.Protocol,
+		Host:     j.Host,
+		Owner:    j.Owner,
+		Repo:     j.Repo,
+		Revision: j.Revision,
+		Path:     j.Path,
+		IsFile:   j.IsFile,
+		token:    j.Token,
+	}
+	return nil
+}
+
+func (g GitUrl) toJSON(token string) gitUrlJSON {
+	return gitUrlJSON{
+		Protocol: g.Protocol,
+		Host:     g.Host,
+		Owner:    g.Owner,
+		Repo:     g.Repo,
+		Revision: g.Revision,
+		Path:     g.Path,
+		IsFile:   g.IsFile,
+		Token:    token,
+	}
+}
+
 // SetToken validates the token with a get request to the repo before setting the token
 // Defaults token to empty on failure.
 func (g *GitUrl) SetToken(token string, httpTimeout *int) error {
-	err := g.validateToken(HTTPRequestParams{Token: token, Timeout: httpTimeout})
+	return g.SetTokenProvider(StaticTokenProvider(token), httpTimeout)
+}
+
+// SetTokenWithContext sets the token like SetToken, unless the context is done first
+func (g *GitUrl) SetTokenWithContext(ctx context.Context, token string, httpTimeout *int) error {
+	return g.SetTokenProviderWithContext(ctx, StaticTokenProvider(token), httpTimeout)
+}
+
+// SetTokenFromFile validates the token read from the file at path with a get request to the repo before setting the token.
+// The file is read again before each clone so that rotated secrets are picked up.
+// Defaults token to empty on failure.
+func (g *GitUrl) SetTokenFromFile(path string, httpTimeout *int) error {
+	return g.SetTokenProvider(FileTokenProvider(path), httpTimeout)
+}
+
+// SetTokenProvider validates a token minted by the provider with a get request to the repo before setting the provider.
+// The provider is called again before each clone to get a fresh token.
+// Defaults token to empty on failure.
+func (g *GitUrl) SetTokenProvider(provider TokenProvider, httpTimeout *int) error {
+	return g.SetTokenProviderWithContext(context.Background(), provider, httpTimeout)
+}
+
+// SetTokenProviderWithContext sets the token provider like SetTokenProvider, unless the context is done first
+func (g *GitUrl) SetTokenProviderWithContext(ctx context.Context, provider TokenProvider, httpTimeout *int) error {
+	token, err := provider.Token(ctx)
+	if err == nil {
+		err = g.validateToken(HTTPRequestParams{Token: token, Timeout: httpTimeout, Context: ctx})
+	}
 	if err != nil {
 		g.token = ""
+		g.tokenProvider = nil
 		return fmt.Errorf("failed to set token. error: %v", err)
 	}
 	g.token = token
+	g.tokenProvider = provider
 	return nil
 }
 
 // IsPublic checks if the GitUrl is public with a get request to the repo using an empty token
 // Returns true if the request succeeds
 func (g *GitUrl) IsPublic(httpTimeout *int) bool {
-	err := g.validateToken(HTTPRequestParams{Token: "", Timeout: httpTimeout})
+	return g.IsPublicWithContext(context.Background(), httpTimeout)
+}
+
+// IsPublicWithContext checks if the GitUrl is public like IsPublic, and returns false if the context is done first
+func (g *GitUrl) IsPublicWithContext(ctx context.Context, httpTimeout *int) bool {
+	err := g.validateToken(HTTPRequestParams{Token: "", Timeout: httpTimeout, Context: ctx})
 	if err != nil {
 		return false
 	}
@@ -325,13 +1255,16 @@ func (g *GitUrl) IsPublic(httpTimeout *int) bool {
 func (g *GitUrl) validateToken(params HTTPRequestParams) error {
 	var apiUrl string
 
-	switch g.Host {
-	case GitHubHost, RawGitHubHost:
-		apiUrl = fmt.Sprintf("https://api.github.com/repos/%s/%s", g.Owner, g.Repo)
+	switch g.provider() {
+	case GitHubHost:
+		apiUrl = fmt.Sprintf("%s/repos/%s/%s", g.apiBaseURL(), g.Owner, g.Repo)
+		if isGistHost(g.Host) {
+			apiUrl = fmt.Sprintf("%s/gists/%s", g.apiBaseURL(), g.Repo)
+		}
 	case GitLabHost:
-		apiUrl = fmt.Sprintf("https://gitlab.com/api/v4/projects/%s%%2F%s", g.Owner, g.Repo)
+		apiUrl = fmt.Sprintf("%s/projects/%s%%2F%s", g.apiBaseURL(), g.Owner, g.Repo)
 	case BitbucketHost:
-		apiUrl = fmt.Sprintf("https://api.bitbucket.org/2.0/repositories/%s/%s", g.Owner, g.Repo)
+		apiUrl = fmt.Sprintf("%s/repositories/%s/%s", g.apiBaseURL(), g.Owner, g.Repo)
 	default:
 		apiUrl = fmt.Sprintf("%s://%s/%s/%s.git", g.Protocol, g.Host, g.Owner, g.Repo)
 	}
@@ -345,17 +1278,64 @@ func (g *GitUrl) validateToken(params HTTPRequestParams) error {
 	return nil
 }
 
+// FileURL returns the web url of the file or directory of the GitUrl at its revision, which ParseGitUrl parses back into the
+// GitUrl, e.g. https://github.com/devfile/library/blob/main/devfile.yaml, or a raw GitHub url for a raw.githubusercontent.com GitUrl.
+// Returns an empty string if the host is not a supported git provider.
+func (g *GitUrl) FileURL() string {
+	kind := "tree"
+	if g.IsFile {
+		kind = "blob"
+	}
+	switch g.provider() {
+	case GitHubHost:
+		if isGistHost(g.Host) {
+			// the files of a gist have no web url of their own, only raw urls
+			if g.Path != "" {
+				return g.GitRawFileAPI()
+			}
+			gistURL := fmt.Sprintf("%s://%s/%s/%s", g.Protocol, GistHost, g.Owner, g.Repo)
+			if g.Revision != "" {
+				gistURL += "/" + g.Revision
+			}
+			return gistURL
+		}
+		if hostname(g.Host) == RawGitHubHost {
+			return fmt.Sprintf("%s://%s/%s/%s/%s/%s", g.Protocol, g.Host, g.Owner, g.Repo, g.Revision, g.Path)
+		}
+		return fmt.Sprintf("%s://%s/%s/%s/%s/%s/%s", g.Protocol, g.Host, g.Owner, g.Repo, kind, g.Revision, g.Path)
+	case GitLabHost:
+		return fmt.Sprintf("%s://%s/%s/%s/-/%s/%s/%s", g.Protocol, g.Host, g.Owner, g.Repo, kind, g.Revision, g.Path)
+	case BitbucketHost:
+		return fmt.Sprintf("%s://%s/%s/%s/src/%s/%s", g.Protocol, g.Host, g.Owner, g.Repo, g.Revision, g.Path)
+	default:
+		return ""
+	}
+}
+
 // GitRawFileAPI returns the endpoint for the git providers raw file
 func (g *GitUrl) GitRawFileAPI() string {
 	var apiRawFile string
 
-	switch g.Host {
-	case GitHubHost, RawGitHubHost:
-		apiRawFile = fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s/%s", g.Owner, g.Repo, g.Revision, g.Path)
+	switch g.provider() {
+	case GitHubHost:
+		if isGistHost(g.Host) {
+			// the raw url of a gist without a revision is its latest revision, and without a file its first file
+			apiRawFile = fmt.Sprintf("https://%s/%s/%s/raw", RawGistHost, g.Owner, g.Repo)
+			for _, segment := range []string{g.Revision, g.Path} {
+				if segment != "" {
+					apiRawFile += "/" + segment
+				}
+			}
+		} else if isGitHubHost(g.Host) {
+			apiRawFile = fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s/%s", g.Owner, g.Repo, g.Revision, g.Path)
+		} else {
+			// GitHub Enterprise instances serve raw files from the instance host
+			apiRawFile = fmt.Sprintf("%s://%s/raw/%s/%s/%s/%s", g.Protocol, g.Host, g.Owner, g.Repo, g.Revision, g.Path)
+		}
 	case GitLabHost:
-		apiRawFile = fmt.Sprintf("https://gitlab.com/api/v4/projects/%s%%2F%s/repository/files/%s/raw?ref=%s", g.Owner, g.Repo, g.Path, g.Revision)
+		apiRawFile = fmt.Sprintf("%s/projects/%s%%2F%s/repository/files/%s/raw?ref=%s", g.apiBaseURL(), g.Owner, g.Repo, g.Path, g.Revision)
 	case BitbucketHost:
-		apiRawFile = fmt.Sprintf("https://api.bitbucket.org/2.0/repositories/%s/%s/src/%s/%s", g.Owner, g.Repo, g.Revision, g.Path)
+		apiRawFile = fmt.Sprintf("%s/repositories/%s/%s/src/%s/%s", g.apiBaseURL(), g.Owner, g.Repo, g.Revision, g.Path)
 	}
 
 	return apiRawFile
@@ -363,10 +1343,5 @@ func (g *GitUrl) GitRawFileAPI() string {
 
 // IsGitProviderRepo checks if the url matches a repo from a supported git provider
 func (g *GitUrl) IsGitProviderRepo() bool {
-	switch g.Host {
-	case GitHubHost, RawGitHubHost, GitLabHost, BitbucketHost:
-		return true
-	default:
-		return false
-	}
+	return g.provider() != ""
 }
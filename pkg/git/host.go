@@ -0,0 +1,219 @@
+//
+// Copyright 2023 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// GitHost is an instance of a supported git provider, e.g. a GitHub Enterprise or self-hosted GitLab instance
+type GitHost struct {
+	// Host is the domain name of the instance, e.g. github.mycorp, with an optional port, e.g. github.mycorp:8443.
+	// A host without a port matches urls of the instance on any port.
+	Host string
+	// Provider is the git provider run by the instance, one of GitHubHost, GitLabHost or BitbucketHost.
+	// Defaults to Host if Host is the domain name of a git provider.
+	Provider string
+	// APIBaseURL is the base URL of the REST API of the instance, e.g. https://github.mycorp/api/v3.
+	// Defaults to the API base URL of the git provider.
+	APIBaseURL string
+}
+
+// defaultAPIBaseURLs are the REST API base URLs of the git providers
+var defaultAPIBaseURLs = map[string]string{
+	GitHubHost:    "https://api.github.com",
+	GitLabHost:    "https://gitlab.com/api/v4",
+	BitbucketHost: "https://api.bitbucket.org/2.0",
+}
+
+var (
+	gitHostsLock sync.RWMutex
+	gitHosts     = map[string]GitHost{}
+)
+
+// RegisterGitHost registers an instance of a git provider so that urls of the instance are parsed as git provider urls,
+// and token validation and raw file requests target the API base URL of the instance.
+// Registering a host again replaces the previous registration.
+func RegisterGitHost(host GitHost) error {
+	if host.Host == "" {
+		return fmt.Errorf("failed to register git host, host should not be empty")
+	}
+	if host.Provider == "" {
+		host.Provider = providerOfHost(host.Host)
+	}
+	if _, ok := defaultAPIBaseURLs[host.Provider]; !ok {
+		return fmt.Errorf("failed to register git host %s, provider should be one of %s, %s or %s; received: %s", host.Host, GitHubHost, GitLabHost, BitbucketHost, host.Provider)
+	}
+	if host.APIBaseURL == "" {
+		host.APIBaseURL = defaultAPIBaseURLs[host.Provider]
+	} else if err := ValidateURL(host.APIBaseURL); err != nil {
+		return fmt.Errorf("failed to register git host %s, invalid API base URL: %v", host.Host, err)
+	}
+	host.APIBaseURL = strings.TrimSuffix(host.APIBaseURL, "/")
+
+	gitHostsLock.Lock()
+	defer gitHostsLock.Unlock()
+	gitHosts[host.Host] = host
+	return nil
+}
+
+// UnregisterGitHost removes the registration of a git provider instance
+func UnregisterGitHost(host string) {
+	gitHostsLock.Lock()
+	defer gitHostsLock.Unlock()
+	delete(gitHosts, host)
+}
+
+var (
+	allowedHostsLock sync.RWMutex
+	allowedHosts     []string
+)
+
+// SetAllowedHosts restricts the hosts that urls are parsed, fetched and cloned from to the allowlist, whatever the urls supplied.
+// A host without a port, e.g. github.com, allows the host on any port. Hosts are matched case insensitively.
+// Hosts requested on behalf of an allowed git provider host, such as raw.githubusercontent.com or api.github.com, should be allowed as well.
+// An empty allowlist allows all hosts, which is the default.
+func SetAllowedHosts(hosts []string) {
+	allowed := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		allowed = append(allowed, strings.ToLower(host))
+	}
+
+	allowedHostsLock.Lock()
+	defer allowedHostsLock.Unlock()
+	allowedHosts = allowed
+}
+
+// CheckHostAllowed returns an error if the host of the url is not allowed by SetAllowedHosts
+func CheckHostAllowed(rawURL string) error {
+	allowedHostsLock.RLock()
+	defer allowedHostsLock.RUnlock()
+	if len(allowedHosts) == 0 {
+		return nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	host := strings.ToLower(u.Host)
+	for _, allowed := range allowedHosts {
+		if allowed == host || allowed == hostname(host) {
+			return nil
+		}
+	}
+	return fmt.Errorf("host not allowed: %s", u.Host)
+}
+
+// IsGitProviderHost checks if the host is a supported git provider or a registered instance of one
+func IsGitProviderHost(host string) bool {
+	if providerOfHost(host) != "" {
+		return true
+	}
+	_, ok := lookupGitHost(host)
+	return ok
+}
+
+// lookupGitHost returns the registration of the host, if any. A registration of the host with its port
+// takes precedence over a registration of the host without a port.
+func lookupGitHost(host string) (GitHost, bool) {
+	gitHostsLock.RLock()
+	defer gitHostsLock.RUnlock()
+	if gitHost, ok := gitHosts[host]; ok {
+		return gitHost, true
+	}
+	gitHost, ok := gitHosts[hostname(host)]
+	return gitHost, ok
+}
+
+// hostname returns the host without its port, if any
+func hostname(host string) string {
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		return name
+	}
+	return host
+}
+
+// isGitHubHost checks if the host, ignoring its port, is the GitHub or raw GitHub domain name
+func isGitHubHost(host string) bool {
+	name := hostname(host)
+	return name == GitHubHost || name == RawGitHubHost
+}
+
+// isGistHost checks if the host, ignoring its port, is the GitHub gist or raw gist domain name
+func isGistHost(host string) bool {
+	name := hostname(host)
+	return name == GistHost || name == RawGistHost
+}
+
+// providerOfHost returns the git provider of the host if the host, ignoring its port, is the domain name of a git provider
+func providerOfHost(host string) string {
+	switch host = hostname(host); host {
+	case GitHubHost, RawGitHubHost, GistHost, RawGistHost:
+		return GitHubHost
+	case GitLabHost, BitbucketHost:
+		return host
+	default:
+		return ""
+	}
+}
+
+// provider returns the git provider of the GitUrl host, or an empty string if the host is not a supported git provider
+func (g *GitUrl) provider() string {
+	return hostProvider(g.Host)
+}
+
+// hostProvider returns the git provider of the host, including the registered instances of git providers,
+// or an empty string if the host is not a supported git provider
+func hostProvider(host string) string {
+	if gitHost, ok := lookupGitHost(host); ok {
+		return gitHost.Provider
+	}
+	return providerOfHost(host)
+}
+
+// AuthHeader returns the name and value of the header authenticating a request with the token: a bearer Authorization
+// header, which every git provider accepts for both personal access tokens and OAuth tokens.
+// A request rejected with a 401 status is retried with the header of AuthFallbackHeader for the url, if any.
+func AuthHeader(token string) (string, string) {
+	return "Authorization", "Bearer " + token
+}
+
+// AuthFallbackHeader returns the name and value of the header authenticating a request to the url with the token once the
+// header of AuthHeader is rejected: the PRIVATE-TOKEN header for GitLab and its registered instances, as some instances only
+// accept personal access tokens in it. ok is false for the other hosts.
+func AuthFallbackHeader(rawURL string, token string) (name string, value string, ok bool) {
+	if u, err := url.Parse(rawURL); err == nil && hostProvider(u.Host) == GitLabHost {
+		return "PRIVATE-TOKEN", token, true
+	}
+	return "", "", false
+}
+
+// apiBaseURL returns the REST API base URL of the GitUrl host
+func (g *GitUrl) apiBaseURL() string {
+	host := g.Host
+	if hostname(host) == RawGitHubHost || isGistHost(host) {
+		host = GitHubHost
+	}
+	if gitHost, ok := lookupGitHost(host); ok {
+		return gitHost.APIBaseURL
+	}
+	return defaultAPIBaseURLs[g.provider()]
+}
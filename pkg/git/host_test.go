@@ -0,0 +1,461 @@
+//
+// Copyright 2023 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RegisterGitHost(t *testing.T) {
+	tests := []struct {
+		name    string
+		host    GitHost
+		want    GitHost
+		wantErr string
+	}{
+		{
+			name: "should register a GitHub Enterprise instance",
+			host: GitHost{Host: "github.mycorp", Provider: GitHubHost, APIBaseURL: "https://github.mycorp/api/v3/"},
+			want: GitHost{Host: "github.mycorp", Provider: GitHubHost, APIBaseURL: "https://github.mycorp/api/v3"},
+		},
+		{
+			name: "should default the API base URL to the provider API",
+			host: GitHost{Host: "gitlab.internal", Provider: GitLabHost},
+			want: GitHost{Host: "gitlab.internal", Provider: GitLabHost, APIBaseURL: "https://gitlab.com/api/v4"},
+		},
+		{
+			name: "should default the provider of a git provider host",
+			host: GitHost{Host: BitbucketHost, APIBaseURL: "https://bitbucket.proxy/2.0"},
+			want: GitHost{Host: BitbucketHost, Provider: BitbucketHost, APIBaseURL: "https://bitbucket.proxy/2.0"},
+		},
+		{
+			name:    "should fail with an empty host",
+			host:    GitHost{Provider: GitHubHost},
+			wantErr: "failed to register git host, host should not be empty",
+		},
+		{
+			name:    "should fail with an unknown provider",
+			host:    GitHost{Host: "git.mycorp"},
+			wantErr: "failed to register git host git.mycorp, provider should be one of .*; received: ",
+		},
+		{
+			name:    "should fail with an invalid API base URL",
+			host:    GitHost{Host: "github.mycorp", Provider: GitHubHost, APIBaseURL: "github.mycorp/api/v3"},
+			wantErr: "failed to register git host github.mycorp, invalid API base URL",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer UnregisterGitHost(tt.host.Host)
+			err := RegisterGitHost(tt.host)
+			if (err != nil) != (tt.wantErr != "") {
+				t.Fatalf("Unexpected error: %v, want: %v", err, tt.wantErr)
+			}
+			if err != nil {
+				assert.Regexp(t, tt.wantErr, err.Error(), "Error message should match")
+				return
+			}
+			got, ok := lookupGitHost(tt.host.Host)
+			if !ok {
+				t.Fatalf("host %s is not registered", tt.host.Host)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ParseGitUrl_RegisteredHost(t *testing.T) {
+	hosts := []GitHost{
+		{Host: "github.mycorp", Provider: GitHubHost, APIBaseURL: "https://github.mycorp/api/v3"},
+		{Host: "gitlab.internal", Provider: GitLabHost, APIBaseURL: "https://gitlab.internal/api/v4"},
+	}
+	for _, host := range hosts {
+		if err := RegisterGitHost(host); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		defer UnregisterGitHost(host.Host)
+	}
+
+	tests := []struct {
+		name    string
+		url     string
+		wantUrl GitUrl
+		wantErr string
+	}{
+		{
+			name: "should parse a GitHub Enterprise url",
+			url:  "https://github.mycorp/devfile/library/blob/main/devfile.yaml",
+			wantUrl: GitUrl{
+				Protocol: "https",
+				Host:     "github.mycorp",
+				Owner:    "devfile",
+				Repo:     "library",
+				Revision: "main",
+				Path:     "devfile.yaml",
+				IsFile:   true,
+			},
+		},
+		{
+			name: "should parse a self-hosted GitLab url",
+			url:  "https://gitlab.internal/gitlab-org/gitlab/-/blob/main/README.md",
+			wantUrl: GitUrl{
+				Protocol: "https",
+				Host:     "gitlab.internal",
+				Owner:    "gitlab-org",
+				Repo:     "gitlab",
+				Revision: "main",
+				Path:     "README.md",
+				IsFile:   true,
+			},
+		},
+		{
+			name:    "should fail with an unregistered host",
+			url:     "https://gitea.mycorp/devfile/library",
+			wantErr: "url host should be a valid GitHub, GitLab, or Bitbucket host; received: gitea.mycorp",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseGitUrl(tt.url)
+			if (err != nil) != (tt.wantErr != "") {
+				t.Fatalf("Unexpected error: %v, want: %v", err, tt.wantErr)
+			}
+			if err != nil {
+				assert.Regexp(t, tt.wantErr, err.Error(), "Error message should match")
+				return
+			}
+			if !reflect.DeepEqual(got, tt.wantUrl) {
+				t.Errorf("Got: %v, want: %v", got, tt.wantUrl)
+			}
+			if !got.IsGitProviderRepo() {
+				t.Errorf("Got: %v, want: %v", got.IsGitProviderRepo(), true)
+			}
+		})
+	}
+}
+
+func Test_GetGitRawFileAPI_RegisteredHost(t *testing.T) {
+	hosts := []GitHost{
+		{Host: "github.mycorp", Provider: GitHubHost, APIBaseURL: "https://github.mycorp/api/v3"},
+		{Host: "gitlab.internal", Provider: GitLabHost, APIBaseURL: "https://gitlab.internal/api/v4"},
+		{Host: "bitbucket.mycorp", Provider: BitbucketHost, APIBaseURL: "https://bitbucket.mycorp/rest/api/2.0"},
+	}
+	for _, host := range hosts {
+		if err := RegisterGitHost(host); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		defer UnregisterGitHost(host.Host)
+	}
+
+	tests := []struct {
+		name string
+		g    GitUrl
+		want string
+	}{
+		{
+			name: "GitHub Enterprise url",
+			g:    GitUrl{Protocol: "https", Host: "github.mycorp", Owner: "devfile", Repo: "library", Revision: "main", Path: "devfile.yaml"},
+			want: "https://github.mycorp/raw/devfile/library/main/devfile.yaml",
+		},
+		{
+			name: "self-hosted GitLab url",
+			g:    GitUrl{Protocol: "https", Host: "gitlab.internal", Owner: "gitlab-org", Repo: "gitlab", Revision: "main", Path: "README.md"},
+			want: "https://gitlab.internal/api/v4/projects/gitlab-org%2Fgitlab/repository/files/README.md/raw?ref=main",
+		},
+		{
+			name: "self-hosted Bitbucket url",
+			g:    GitUrl{Protocol: "https", Host: "bitbucket.mycorp", Owner: "owner", Repo: "repo-name", Revision: "main", Path: "path/to/file.md"},
+			want: "https://bitbucket.mycorp/rest/api/2.0/repositories/owner/repo-name/src/main/path/to/file.md",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := tt.g.GitRawFileAPI()
+			if result != tt.want {
+				t.Errorf("Got: %v, want: %v", result, tt.want)
+			}
+		})
+	}
+}
+
+func Test_validateToken_APIBaseURL(t *testing.T) {
+	var mu sync.Mutex
+	var gotPath string
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		gotPath = r.URL.EscapedPath()
+		mu.Unlock()
+		_, err := w.Write([]byte("{}"))
+		if err != nil {
+			t.Errorf("Unexpected error while writing data: %v", err)
+		}
+	}))
+	defer testServer.Close()
+
+	tests := []struct {
+		name     string
+		host     GitHost
+		wantPath string
+	}{
+		{
+			name:     "GitHub Enterprise API",
+			host:     GitHost{Host: "github.mycorp", Provider: GitHubHost, APIBaseURL: testServer.URL + "/api/v3"},
+			wantPath: "/api/v3/repos/owner/repo",
+		},
+		{
+			name:     "self-hosted GitLab API",
+			host:     GitHost{Host: "gitlab.internal", Provider: GitLabHost, APIBaseURL: testServer.URL + "/api/v4"},
+			wantPath: "/api/v4/projects/owner%2Frepo",
+		},
+		{
+			name:     "self-hosted Bitbucket API",
+			host:     GitHost{Host: "bitbucket.mycorp", Provider: BitbucketHost, APIBaseURL: testServer.URL + "/rest/api/2.0"},
+			wantPath: "/rest/api/2.0/repositories/owner/repo",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := RegisterGitHost(tt.host); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			defer UnregisterGitHost(tt.host.Host)
+
+			g := GitUrl{Protocol: "https", Host: tt.host.Host, Owner: "owner", Repo: "repo"}
+			if err := g.SetToken("fake-token", nil); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if gotPath != tt.wantPath {
+				t.Errorf("Got: %v, want: %v", gotPath, tt.wantPath)
+			}
+		})
+	}
+}
+
+func Test_GitUrl_HostWithPort(t *testing.T) {
+	hosts := []GitHost{
+		{Host: "git.internal", Provider: GitLabHost, APIBaseURL: "https://git.internal:8443/api/v4"},
+		{Host: "github.mycorp:8443", Provider: GitHubHost, APIBaseURL: "https://github.mycorp:8443/api/v3"},
+	}
+	for _, host := range hosts {
+		if err := RegisterGitHost(host); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		defer UnregisterGitHost(host.Host)
+	}
+
+	originalExecute := execute
+	defer func() { execute = originalExecute }()
+
+	var cloneArgs []string
+	execute = func(baseDir string, cmd CommandType, args ...string) ([]byte, error) {
+		if len(args) > 0 && args[0] == "clone" {
+			cloneArgs = args
+		}
+		return []byte(""), nil
+	}
+
+	tests := []struct {
+		name          string
+		url           string
+		wantHost      string
+		wantRawFile   string
+		wantAPIURL    string
+		wantRemoteUrl string
+		wantErr       string
+	}{
+		{
+			name:          "should match a host registered without a port",
+			url:           "https://git.internal:8443/owner/repo/-/blob/main/devfile.yaml",
+			wantHost:      "git.internal:8443",
+			wantRawFile:   "https://git.internal:8443/api/v4/projects/owner%2Frepo/repository/files/devfile.yaml/raw?ref=main",
+			wantAPIURL:    "https://git.internal:8443/api/v4",
+			wantRemoteUrl: "https://git.internal:8443/owner/repo.git",
+		},
+		{
+			name:          "should match a host registered with a port",
+			url:           "https://github.mycorp:8443/owner/repo/blob/main/devfile.yaml",
+			wantHost:      "github.mycorp:8443",
+			wantRawFile:   "https://github.mycorp:8443/raw/owner/repo/main/devfile.yaml",
+			wantAPIURL:    "https://github.mycorp:8443/api/v3",
+			wantRemoteUrl: "https://github.mycorp:8443/owner/repo.git",
+		},
+		{
+			name:    "should not match a host registered with a different port",
+			url:     "https://github.mycorp:9443/owner/repo/blob/main/devfile.yaml",
+			wantErr: "url host should be a valid GitHub, GitLab, or Bitbucket host; received: github.mycorp:9443",
+		},
+		{
+			name:          "should match a git provider host with a port",
+			url:           "https://github.com:443/owner/repo/blob/main/devfile.yaml",
+			wantHost:      "github.com:443",
+			wantRawFile:   "https://raw.githubusercontent.com/owner/repo/main/devfile.yaml",
+			wantAPIURL:    "https://api.github.com",
+			wantRemoteUrl: "https://github.com:443/owner/repo.git",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := ParseGitUrl(tt.url)
+			if (err != nil) != (tt.wantErr != "") {
+				t.Fatalf("Unexpected error: %v, want: %v", err, tt.wantErr)
+			}
+			if err != nil {
+				assert.Regexp(t, tt.wantErr, err.Error(), "Error message should match")
+				return
+			}
+			if g.Host != tt.wantHost {
+				t.Errorf("Got: %v, want: %v", g.Host, tt.wantHost)
+			}
+			if got := g.GitRawFileAPI(); got != tt.wantRawFile {
+				t.Errorf("Got: %v, want: %v", got, tt.wantRawFile)
+			}
+			if got := g.apiBaseURL(); got != tt.wantAPIURL {
+				t.Errorf("Got: %v, want: %v", got, tt.wantAPIURL)
+			}
+
+			cloneArgs = nil
+			g.Revision = ""
+			if err := g.CloneGitRepo(t.TempDir()); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(cloneArgs) < 2 || cloneArgs[1] != tt.wantRemoteUrl {
+				t.Errorf("Got: %v, want: %v", cloneArgs, tt.wantRemoteUrl)
+			}
+		})
+	}
+}
+
+func Test_SetAllowedHosts(t *testing.T) {
+	var requests int
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_, _ = w.Write([]byte("content"))
+	}))
+	defer testServer.Close()
+
+	originalExecute := execute
+	defer func() { execute = originalExecute }()
+
+	var clones int
+	execute = func(baseDir string, cmd CommandType, args ...string) ([]byte, error) {
+		if len(args) > 0 && args[0] == "clone" {
+			clones++
+		}
+		return []byte(""), nil
+	}
+
+	defer SetAllowedHosts(nil)
+	hostNotAllowedErr := "host not allowed: .*"
+
+	tests := []struct {
+		name         string
+		allowedHosts []string
+		wantErr      string
+	}{
+		{
+			name: "should allow all hosts with an empty allowlist",
+		},
+		{
+			name:         "should allow a host on any port",
+			allowedHosts: []string{"127.0.0.1", "GitHub.com"},
+		},
+		{
+			name:         "should reject hosts off the allowlist",
+			allowedHosts: []string{"example.com"},
+			wantErr:      hostNotAllowedErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetAllowedHosts(tt.allowedHosts)
+			requests, clones = 0, 0
+			wantCalls := 1
+			if tt.wantErr != "" {
+				wantCalls = 0
+			}
+
+			_, err := HTTPGetRequest(HTTPRequestParams{URL: testServer.URL}, 0)
+			if (err != nil) != (tt.wantErr != "") {
+				t.Errorf("Unexpected error: %v, want: %v", err, tt.wantErr)
+			} else if err != nil {
+				assert.Regexp(t, tt.wantErr, err.Error(), "Error message should match")
+			}
+			if requests != wantCalls {
+				t.Errorf("Got requests: %d, want: %d", requests, wantCalls)
+			}
+
+			_, err = ParseGitUrl("https://github.com/devfile/library")
+			if (err != nil) != (tt.wantErr != "") {
+				t.Errorf("Unexpected error: %v, want: %v", err, tt.wantErr)
+			} else if err != nil {
+				assert.Regexp(t, tt.wantErr, err.Error(), "Error message should match")
+			}
+
+			g := GitUrl{Protocol: "https", Host: "github.com", Owner: "devfile", Repo: "library"}
+			err = g.CloneGitRepo(t.TempDir())
+			if (err != nil) != (tt.wantErr != "") {
+				t.Errorf("Unexpected error: %v, want: %v", err, tt.wantErr)
+			} else if err != nil {
+				assert.Regexp(t, tt.wantErr, err.Error(), "Error message should match")
+			}
+			if clones != wantCalls {
+				t.Errorf("Got clones: %d, want: %d", clones, wantCalls)
+			}
+		})
+	}
+}
+
+func Test_validateToken_Gist(t *testing.T) {
+	var gotPath string
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.EscapedPath()
+		_, _ = w.Write([]byte(`{"id":"6cad326836d38bd3a7ae"}`))
+	}))
+	defer testServer.Close()
+
+	// gists are served by the GitHub API
+	if err := RegisterGitHost(GitHost{Host: GitHubHost, Provider: GitHubHost, APIBaseURL: testServer.URL}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer UnregisterGitHost(GitHubHost)
+
+	g, err := ParseGitUrl("https://gist.github.com/octocat/6cad326836d38bd3a7ae")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !g.IsPublic(nil) {
+		t.Errorf("Got: a private gist, want: a public gist")
+	}
+	if want := "/gists/6cad326836d38bd3a7ae"; gotPath != want {
+		t.Errorf("Got: %v, want: %v", gotPath, want)
+	}
+}
@@ -0,0 +1,131 @@
+//
+// Copyright 2023 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// licenseFileNames are the common names of the license file at the root of a repo, in order of lookup
+var licenseFileNames = []string{"LICENSE", "LICENSE.md", "LICENSE.txt", "COPYING"}
+
+// licenseRule identifies a license by phrases of its text, all of which must be found in the normalized license file
+type licenseRule struct {
+	spdxID  string
+	phrases []string
+}
+
+// licenseRules are checked in order, so the rules of licenses whose text contains the phrases of another license come first,
+// e.g. the LGPL text refers to the GPL
+var licenseRules = []licenseRule{
+	{spdxID: "Apache-2.0", phrases: []string{"apache license", "version 2.0"}},
+	{spdxID: "LGPL-3.0", phrases: []string{"gnu lesser general public license", "version 3"}},
+	{spdxID: "LGPL-2.1", phrases: []string{"gnu lesser general public license", "version 2.1"}},
+	{spdxID: "AGPL-3.0", phrases: []string{"gnu affero general public license", "version 3"}},
+	{spdxID: "GPL-3.0", phrases: []string{"gnu general public license", "version 3"}},
+	{spdxID: "GPL-2.0", phrases: []string{"gnu general public license", "version 2"}},
+	{spdxID: "MPL-2.0", phrases: []string{"mozilla public license", "2.0"}},
+	{spdxID: "EPL-2.0", phrases: []string{"eclipse public license", "2.0"}},
+	{spdxID: "MIT", phrases: []string{"permission is hereby granted, free of charge"}},
+	{spdxID: "BSD-3-Clause", phrases: []string{"redistribution and use in source and binary forms", "neither the name"}},
+	{spdxID: "BSD-2-Clause", phrases: []string{"redistribution and use in source and binary forms"}},
+	{spdxID: "Unlicense", phrases: []string{"free and unencumbered software released into the public domain"}},
+}
+
+// GetLicense returns the SPDX identifier of the license of the repo of the GitUrl, e.g. Apache-2.0, to check it against the
+// licenses allowed by a policy. The license detected by the GitHub license API is returned for GitHub repos, otherwise the
+// license is identified from the text of a common license file at the root of the repo, e.g. LICENSE, at the GitUrl revision
+// or the default branch. The requests are authenticated with the GitUrl token if set.
+func (g *GitUrl) GetLicense(httpTimeout *int) (string, error) {
+	if g.repoAPIURL() == "" {
+		return "", fmt.Errorf("failed to get the license, %s is not a supported git provider", g.Host)
+	}
+	token, err := g.refreshToken(context.Background())
+	if err != nil {
+		return "", err
+	}
+
+	var failures []string
+	if g.provider() == GitHubHost {
+		spdxID, err := g.gitHubLicense(token, httpTimeout)
+		if err == nil {
+			return spdxID, nil
+		}
+		failures = append(failures, fmt.Sprintf("license API: %v", err))
+	}
+
+	for _, name := range licenseFileNames {
+		file := *g
+		file.Path, file.IsFile = name, true
+		if file.Revision == "" {
+			file.Revision = "HEAD"
+		}
+		content, err := file.fetchRawFile(token, httpTimeout)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		if spdxID := detectLicense(content); spdxID != "" {
+			return spdxID, nil
+		}
+		failures = append(failures, fmt.Sprintf("%s: the license is not a known license", name))
+	}
+	return "", fmt.Errorf("failed to get the license of repo %s/%s:\n%s", g.Owner, g.Repo, strings.Join(failures, "\n"))
+}
+
+// gitHubLicense returns the SPDX identifier of the license detected by the GitHub license API
+func (g *GitUrl) gitHubLicense(token string, httpTimeout *int) (string, error) {
+	var license struct {
+		License struct {
+			SPDXID string `json:"spdx_id"`
+		} `json:"license"`
+	}
+	apiURL := g.repoAPIURL() + "/license"
+	res, err := HTTPGetRequest(HTTPRequestParams{URL: apiURL, Token: token, Timeout: httpTimeout}, 0)
+	if err != nil {
+		return "", err
+	}
+	if err = json.Unmarshal(res, &license); err != nil {
+		return "", fmt.Errorf("failed to decode the license from %s: %v", apiURL, err)
+	}
+	// GitHub reports licenses it cannot identify as NOASSERTION
+	if license.License.SPDXID == "" || license.License.SPDXID == "NOASSERTION" {
+		return "", fmt.Errorf("the license is not identified by GitHub")
+	}
+	return license.License.SPDXID, nil
+}
+
+// detectLicense returns the SPDX identifier of the license text, or an empty string if the license is not known.
+// The text is lower cased and its whitespace collapsed, as license files are wrapped differently.
+func detectLicense(content []byte) string {
+	text := strings.Join(strings.Fields(strings.ToLower(string(content))), " ")
+	for _, rule := range licenseRules {
+		matched := true
+		for _, phrase := range rule.phrases {
+			if !strings.Contains(text, phrase) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return rule.spdxID
+		}
+	}
+	return ""
+}
@@ -0,0 +1,168 @@
+//
+// Copyright 2023 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testMITLicense = `MIT License
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software")`
+	testApacheLicense = `
+                                 Apache License
+                           Version 2.0, January 2004
+                        http://www.apache.org/licenses/`
+)
+
+func Test_GetLicense(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.EscapedPath() {
+		case "/api/v3/repos/owner/repo/license":
+			_, _ = w.Write([]byte(`{"name":"LICENSE","license":{"key":"apache-2.0","spdx_id":"Apache-2.0"}}`))
+		case "/api/v3/repos/owner/custom/license":
+			_, _ = w.Write([]byte(`{"name":"LICENSE","license":{"key":"other","spdx_id":"NOASSERTION"}}`))
+		// GitHub Enterprise raw files
+		case "/raw/owner/nolicenseapi/HEAD/LICENSE":
+			_, _ = w.Write([]byte(testMITLicense))
+		case "/raw/owner/custom/main/LICENSE":
+			_, _ = w.Write([]byte("All rights reserved."))
+		// Bitbucket raw files
+		case "/2.0/repositories/owner/repo/src/HEAD/LICENSE.md":
+			_, _ = w.Write([]byte(testApacheLicense))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer testServer.Close()
+	host := strings.TrimPrefix(testServer.URL, "http://")
+
+	tests := []struct {
+		name      string
+		provider  string
+		apiPrefix string
+		repo      string
+		revision  string
+		want      string
+		wantErr   string
+	}{
+		{
+			name:      "should get the license from the GitHub license API",
+			provider:  GitHubHost,
+			apiPrefix: "/api/v3",
+			repo:      "repo",
+			want:      "Apache-2.0",
+		},
+		{
+			name:      "should fall back to the LICENSE file",
+			provider:  GitHubHost,
+			apiPrefix: "/api/v3",
+			repo:      "nolicenseapi",
+			want:      "MIT",
+		},
+		{
+			name:      "should identify the license from a LICENSE.md file of a Bitbucket repo",
+			provider:  BitbucketHost,
+			apiPrefix: "/2.0",
+			repo:      "repo",
+			want:      "Apache-2.0",
+		},
+		{
+			name:      "should fail for a license that is not known",
+			provider:  GitHubHost,
+			apiPrefix: "/api/v3",
+			repo:      "custom",
+			revision:  "main",
+			wantErr:   "failed to get the license of repo owner/custom:\nlicense API: the license is not identified by GitHub\nLICENSE: the license is not a known license\n",
+		},
+		{
+			name:      "should fail if the repo has no license file",
+			provider:  BitbucketHost,
+			apiPrefix: "/2.0",
+			repo:      "missing",
+			wantErr:   "failed to get the license of repo owner/missing:\nLICENSE: .*404: Not Found\nLICENSE.md: .*\nLICENSE.txt: .*\nCOPYING: .*",
+		},
+		{
+			name:    "should fail for an unsupported git provider",
+			repo:    "repo",
+			wantErr: "failed to get the license, .* is not a supported git provider",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.provider != "" {
+				err := RegisterGitHost(GitHost{Host: host, Provider: tt.provider, APIBaseURL: testServer.URL + tt.apiPrefix})
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				defer UnregisterGitHost(host)
+			}
+
+			g := GitUrl{Protocol: "http", Host: host, Owner: "owner", Repo: tt.repo, Revision: tt.revision}
+			got, err := g.GetLicense(nil)
+			if (err != nil) != (tt.wantErr != "") {
+				t.Fatalf("Unexpected error: %v, want: %v", err, tt.wantErr)
+			}
+			if err != nil {
+				assert.Regexp(t, tt.wantErr, err.Error(), "Error message should match")
+			} else if got != tt.want {
+				t.Errorf("Got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_detectLicense(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "should detect the MIT license",
+			content: testMITLicense,
+			want:    "MIT",
+		},
+		{
+			name:    "should detect the Apache license wrapped on several lines",
+			content: testApacheLicense,
+			want:    "Apache-2.0",
+		},
+		{
+			name:    "should detect the LGPL before the GPL it refers to",
+			content: "GNU LESSER GENERAL PUBLIC LICENSE\nVersion 3, 29 June 2007\n... the GNU General Public License ...",
+			want:    "LGPL-3.0",
+		},
+		{
+			name:    "should not detect an unknown license",
+			content: "Copyright (c) Example. All rights reserved.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectLicense([]byte(tt.content)); got != tt.want {
+				t.Errorf("Got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+//
+// Copyright 2023 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// gitLabDeveloperAccess is the GitLab access level of the developer role, the lowest role allowed to push
+const gitLabDeveloperAccess = 30
+
+// HasWriteAccess checks whether the token can push to the repo of the GitUrl, from the permissions of the token user reported
+// by the git provider API: the push permission of a GitHub repo, the project or group access level of a GitLab project, or the
+// privilege on a Bitbucket repo. SetToken only checks that the token can read the repo.
+func (g *GitUrl) HasWriteAccess(token string, httpTimeout *int) (bool, error) {
+	params := HTTPRequestParams{Token: token, Timeout: httpTimeout}
+	switch g.provider() {
+	case GitHubHost:
+		return g.gitHubWriteAccess(params)
+	case GitLabHost:
+		return g.gitLabWriteAccess(params)
+	case BitbucketHost:
+		return g.bitbucketWriteAccess(params)
+	default:
+		return false, fmt.Errorf("failed to check the write access, %s is not a supported git provider", g.Host)
+	}
+}
+
+// gitHubWriteAccess checks the push permission of the GitHub repo, which is only returned to authenticated users
+func (g *GitUrl) gitHubWriteAccess(params HTTPRequestParams) (bool, error) {
+	var repo struct {
+		Permissions struct {
+			Push bool `json:"push"`
+		} `json:"permissions"`
+	}
+	params.URL = g.repoAPIURL()
+	if err := getPermissions(params, &repo); err != nil {
+		return false, err
+	}
+	return repo.Permissions.Push, nil
+}
+
+// gitLabWriteAccess checks the access level of the GitLab project, granted either on the project or on its group
+func (g *GitUrl) gitLabWriteAccess(params HTTPRequestParams) (bool, error) {
+	type access struct {
+		AccessLevel int `json:"access_level"`
+	}
+	var project struct {
+		Permissions struct {
+			ProjectAccess *access `json:"project_access"`
+			GroupAccess   *access `json:"group_access"`
+		} `json:"permissions"`
+	}
+	params.URL = g.repoAPIURL()
+	if err := getPermissions(params, &project); err != nil {
+		return false, err
+	}
+	for _, a := range []*access{project.Permissions.ProjectAccess, project.Permissions.GroupAccess} {
+		if a != nil && a.AccessLevel >= gitLabDeveloperAccess {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// bitbucketWriteAccess checks the privilege of the token user on the Bitbucket repo, listed by the repository permissions API
+func (g *GitUrl) bitbucketWriteAccess(params HTTPRequestParams) (bool, error) {
+	var permissions struct {
+		Values []struct {
+			Permission string `json:"permission"`
+		} `json:"values"`
+	}
+	query := url.Values{"q": {fmt.Sprintf("repository.full_name=%q", g.Owner+"/"+g.Repo)}}
+	params.URL = fmt.Sprintf("%s/user/permissions/repositories?%s", g.apiBaseURL(), query.Encode())
+	if err := getPermissions(params, &permissions); err != nil {
+		return false, err
+	}
+	for _, p := range permissions.Values {
+		if p.Permission == "write" || p.Permission == "admin" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// getPermissions gets the permissions from the git provider API at the url of the params and decodes them into v
+func getPermissions(params HTTPRequestParams, v interface{}) error {
+	res, err := HTTPGetRequest(params, 0)
+	if err != nil {
+		return fmt.Errorf("failed to get the permissions on the repo: %v", err)
+	}
+	if err = json.Unmarshal(res, v); err != nil {
+		return fmt.Errorf("failed to decode the permissions from %s: %v", params.URL, err)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+//
+// Copyright 2023 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HasWriteAccess(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token == "" {
+			token = r.Header.Get("PRIVATE-TOKEN")
+		}
+		write := token == "write-token"
+		switch {
+		case r.URL.EscapedPath() == "/api/v3/repos/owner/repo":
+			if write {
+				_, _ = w.Write([]byte(`{"name":"repo","permissions":{"admin":false,"push":true,"pull":true}}`))
+			} else {
+				_, _ = w.Write([]byte(`{"name":"repo","permissions":{"admin":false,"push":false,"pull":true}}`))
+			}
+		case r.URL.EscapedPath() == "/api/v4/projects/owner%2Frepo":
+			if write {
+				// the developer access is granted on the group of the project
+				_, _ = w.Write([]byte(`{"name":"repo","permissions":{"project_access":null,"group_access":{"access_level":30}}}`))
+			} else {
+				_, _ = w.Write([]byte(`{"name":"repo","permissions":{"project_access":{"access_level":20},"group_access":null}}`))
+			}
+		case r.URL.Path == "/2.0/user/permissions/repositories" && r.URL.Query().Get("q") == `repository.full_name="owner/repo"`:
+			if write {
+				_, _ = w.Write([]byte(`{"values":[{"permission":"write"}]}`))
+			} else {
+				_, _ = w.Write([]byte(`{"values":[{"permission":"read"}]}`))
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer testServer.Close()
+	host := strings.TrimPrefix(testServer.URL, "http://")
+
+	tests := []struct {
+		name      string
+		provider  string
+		apiPrefix string
+		repo      string
+		token     string
+		want      bool
+		wantErr   string
+	}{
+		{
+			name:      "should report the push permission of a GitHub token",
+			provider:  GitHubHost,
+			apiPrefix: "/api/v3",
+			token:     "write-token",
+			want:      true,
+		},
+		{
+			name:      "should report a read-only GitHub token",
+			provider:  GitHubHost,
+			apiPrefix: "/api/v3",
+			token:     "read-token",
+			want:      false,
+		},
+		{
+			name:      "should report the developer group access of a GitLab token",
+			provider:  GitLabHost,
+			apiPrefix: "/api/v4",
+			token:     "write-token",
+			want:      true,
+		},
+		{
+			name:      "should report the reporter project access of a GitLab token as read-only",
+			provider:  GitLabHost,
+			apiPrefix: "/api/v4",
+			token:     "read-token",
+			want:      false,
+		},
+		{
+			name:      "should report the write privilege of a Bitbucket token",
+			provider:  BitbucketHost,
+			apiPrefix: "/2.0",
+			token:     "write-token",
+			want:      true,
+		},
+		{
+			name:      "should report a read-only Bitbucket token",
+			provider:  BitbucketHost,
+			apiPrefix: "/2.0",
+			token:     "read-token",
+			want:      false,
+		},
+		{
+			name:      "should fail if the repo is not found",
+			provider:  GitHubHost,
+			apiPrefix: "/api/v3",
+			repo:      "missing",
+			token:     "write-token",
+			wantErr:   "failed to get the permissions on the repo: failed to retrieve .*, 404: Not Found",
+		},
+		{
+			name:    "should fail for an unsupported git provider",
+			token:   "write-token",
+			wantErr: "failed to check the write access, .* is not a supported git provider",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.provider != "" {
+				err := RegisterGitHost(GitHost{Host: host, Provider: tt.provider, APIBaseURL: testServer.URL + tt.apiPrefix})
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				defer UnregisterGitHost(host)
+			}
+
+			repo := "repo"
+			if tt.repo != "" {
+				repo = tt.repo
+			}
+			g := GitUrl{Protocol: "http", Host: host, Owner: "owner", Repo: repo}
+			got, err := g.HasWriteAccess(tt.token, nil)
+			if (err != nil) != (tt.wantErr != "") {
+				t.Fatalf("Unexpected error: %v, want: %v", err, tt.wantErr)
+			}
+			if err != nil {
+				assert.Regexp(t, tt.wantErr, err.Error(), "Error message should match")
+			} else if got != tt.want {
+				t.Errorf("Got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
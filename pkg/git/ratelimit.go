@@ -0,0 +1,147 @@
+//
+// Copyright 2023 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RateLimit is the status of the REST API rate limit of a git provider for the token of the requests
+type RateLimit struct {
+	// Limit is the maximum number of requests in the rate limit window
+	Limit int
+	// Remaining is the number of requests remaining in the rate limit window, -1 if the git provider does not report it
+	Remaining int
+	// Reset is when the rate limit window resets, the zero time if the git provider does not report it
+	Reset time.Time
+	// NearLimit is true if fewer than 20% of the requests of the window remain, as reported by Bitbucket or computed from Remaining
+	NearLimit bool
+}
+
+// rateLimitHeaders are the response headers of a git provider reporting its rate limit
+type rateLimitHeaders struct {
+	limit, remaining, reset, nearLimit string
+}
+
+var (
+	// GitLab reports its rate limit in the headers of each REST API response
+	gitLabRateLimitHeaders = rateLimitHeaders{limit: "RateLimit-Limit", remaining: "RateLimit-Remaining", reset: "RateLimit-Reset"}
+	// Bitbucket reports its rate limit in the headers of each REST API response, without the remaining requests
+	bitbucketRateLimitHeaders = rateLimitHeaders{limit: "X-RateLimit-Limit", remaining: "X-RateLimit-Remaining", reset: "X-RateLimit-Reset", nearLimit: "X-RateLimit-NearLimit"}
+)
+
+// RateLimitStatus queries the REST API rate limit of the git provider of the GitUrl, so that callers can throttle their requests
+// before reaching the limit. GitHub is queried with its rate_limit endpoint, GitLab and Bitbucket with the API of the repo, which
+// report the rate limit in their response headers. The token authenticates the request, the GitUrl token is used if empty.
+func (g *GitUrl) RateLimitStatus(token string, httpTimeout *int) (RateLimit, error) {
+	var err error
+	if token == "" {
+		if token, err = g.refreshToken(context.Background()); err != nil {
+			return RateLimit{}, err
+		}
+	}
+	params := HTTPRequestParams{Token: token, Timeout: httpTimeout}
+
+	switch g.provider() {
+	case GitHubHost:
+		params.URL = g.apiBaseURL() + "/rate_limit"
+		return gitHubRateLimit(params)
+	case GitLabHost:
+		params.URL = g.repoAPIURL()
+		return headerRateLimit(params, gitLabRateLimitHeaders)
+	case BitbucketHost:
+		params.URL = g.repoAPIURL()
+		return headerRateLimit(params, bitbucketRateLimitHeaders)
+	default:
+		return RateLimit{}, fmt.Errorf("failed to get the rate limit, %s is not a supported git provider", g.Host)
+	}
+}
+
+// gitHubRateLimit gets the rate limit of the core REST API from the GitHub rate_limit endpoint, which does not count against the limit
+func gitHubRateLimit(params HTTPRequestParams) (RateLimit, error) {
+	var status struct {
+		Resources struct {
+			Core struct {
+				Limit     int   `json:"limit"`
+				Remaining int   `json:"remaining"`
+				Reset     int64 `json:"reset"`
+			} `json:"core"`
+		} `json:"resources"`
+	}
+
+	res, err := HTTPGetRequest(params, 0)
+	if err != nil {
+		return RateLimit{}, fmt.Errorf("failed to get the rate limit: %v", err)
+	}
+	if err = json.Unmarshal(res, &status); err != nil {
+		return RateLimit{}, fmt.Errorf("failed to decode the rate limit from %s: %v", params.URL, err)
+	}
+
+	core := status.Resources.Core
+	rateLimit := RateLimit{Limit: core.Limit, Remaining: core.Remaining, Reset: time.Unix(core.Reset, 0)}
+	rateLimit.NearLimit = isNearLimit(rateLimit)
+	return rateLimit, nil
+}
+
+// headerRateLimit gets the rate limit from the response headers of a request to the REST API.
+// The rate limit is reported even if the request failed, e.g. because the limit is exceeded.
+func headerRateLimit(params HTTPRequestParams, headers rateLimitHeaders) (RateLimit, error) {
+	_, header, err := httpGetRequestWithHeader(params, 0)
+	if header.Get(headers.limit) == "" {
+		if err != nil {
+			return RateLimit{}, fmt.Errorf("failed to get the rate limit: %v", err)
+		}
+		return RateLimit{}, fmt.Errorf("failed to get the rate limit, %s does not report a rate limit", params.URL)
+	}
+
+	rateLimit := RateLimit{Remaining: -1}
+	if rateLimit.Limit, err = strconv.Atoi(header.Get(headers.limit)); err != nil {
+		return RateLimit{}, fmt.Errorf("failed to decode the rate limit from %s: %v", params.URL, err)
+	}
+	if remaining := header.Get(headers.remaining); remaining != "" {
+		if rateLimit.Remaining, err = strconv.Atoi(remaining); err != nil {
+			return RateLimit{}, fmt.Errorf("failed to decode the remaining requests from %s: %v", params.URL, err)
+		}
+	}
+	if reset := header.Get(headers.reset); reset != "" {
+		seconds, err := strconv.ParseInt(reset, 10, 64)
+		if err != nil {
+			return RateLimit{}, fmt.Errorf("failed to decode the rate limit reset from %s: %v", params.URL, err)
+		}
+		rateLimit.Reset = time.Unix(seconds, 0)
+	}
+	rateLimit.NearLimit = isNearLimit(rateLimit) || isHeaderTrue(header, headers.nearLimit)
+	return rateLimit, nil
+}
+
+// isNearLimit checks if fewer than 20% of the requests of the rate limit window remain
+func isNearLimit(rateLimit RateLimit) bool {
+	return rateLimit.Remaining >= 0 && rateLimit.Remaining*5 < rateLimit.Limit
+}
+
+// isHeaderTrue checks if the boolean response header is set to true
+func isHeaderTrue(header http.Header, key string) bool {
+	if key == "" {
+		return false
+	}
+	value, err := strconv.ParseBool(header.Get(key))
+	return err == nil && value
+}
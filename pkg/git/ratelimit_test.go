@@ -0,0 +1,124 @@
+//
+// Copyright 2023 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RateLimitStatus(t *testing.T) {
+	reset := time.Unix(1700000000, 0)
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		// GitHub rate limit endpoint
+		case "/api/v3/rate_limit":
+			if r.Header.Get("Authorization") != "Bearer fake-token" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(`{"resources":{"core":{"limit":5000,"remaining":4999,"reset":1700000000,"used":1}},"rate":{"limit":5000}}`))
+		// GitLab project API, exceeding the rate limit
+		case "/api/v4/projects/owner%2Frepo", "/api/v4/projects/owner/repo":
+			w.Header().Set("RateLimit-Limit", "2000")
+			w.Header().Set("RateLimit-Remaining", "0")
+			w.Header().Set("RateLimit-Reset", "1700000000")
+			http.Error(w, "Retry later", http.StatusTooManyRequests)
+		// Bitbucket repo API
+		case "/2.0/repositories/owner/repo":
+			w.Header().Set("X-RateLimit-Limit", "1000")
+			w.Header().Set("X-RateLimit-NearLimit", "true")
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer testServer.Close()
+	host := strings.TrimPrefix(testServer.URL, "http://")
+
+	tests := []struct {
+		name      string
+		provider  string
+		apiPrefix string
+		repo      string
+		token     string
+		want      RateLimit
+		wantErr   string
+	}{
+		{
+			name:      "should get the GitHub rate limit",
+			provider:  GitHubHost,
+			apiPrefix: "/api/v3",
+			repo:      "repo",
+			token:     "fake-token",
+			want:      RateLimit{Limit: 5000, Remaining: 4999, Reset: reset},
+		},
+		{
+			name:      "should get an exceeded GitLab rate limit",
+			provider:  GitLabHost,
+			apiPrefix: "/api/v4",
+			repo:      "repo",
+			want:      RateLimit{Limit: 2000, Remaining: 0, Reset: reset, NearLimit: true},
+		},
+		{
+			name:      "should get the Bitbucket rate limit",
+			provider:  BitbucketHost,
+			apiPrefix: "/2.0",
+			repo:      "repo",
+			want:      RateLimit{Limit: 1000, Remaining: -1, NearLimit: true},
+		},
+		{
+			name:      "should fail if the git provider does not report the rate limit",
+			provider:  BitbucketHost,
+			apiPrefix: "/2.0",
+			repo:      "missing",
+			wantErr:   "failed to get the rate limit: failed to retrieve .*, 404: Not Found",
+		},
+		{
+			name:      "should fail with an unauthorized GitHub request",
+			provider:  GitHubHost,
+			apiPrefix: "/api/v3",
+			repo:      "repo",
+			wantErr:   "failed to get the rate limit: failed to retrieve .*, 401: Unauthorized",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := RegisterGitHost(GitHost{Host: host, Provider: tt.provider, APIBaseURL: testServer.URL + tt.apiPrefix})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			defer UnregisterGitHost(host)
+
+			g := GitUrl{Protocol: "http", Host: host, Owner: "owner", Repo: tt.repo}
+			got, err := g.RateLimitStatus(tt.token, nil)
+			if (err != nil) != (tt.wantErr != "") {
+				t.Fatalf("Unexpected error: %v, want: %v", err, tt.wantErr)
+			}
+			if err != nil {
+				assert.Regexp(t, tt.wantErr, err.Error(), "Error message should match")
+			} else if got != tt.want {
+				t.Errorf("Got: %+v, want: %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+//
+// Copyright 2023 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/client"
+)
+
+// peeledSuffix is the suffix of the name of a remote annotated tag peeled to the commit it tags, as listed by git ls-remote
+const peeledSuffix = "^{}"
+
+// ResolveRemoteRef resolves the ref of the remote repo of the GitUrl to its commit id with `git ls-remote`, without cloning the repo,
+// e.g. to check that a branch or tag exists before a clone. The ref is a branch, a tag, or a full ref name such as refs/heads/main,
+// where a branch is preferred over a tag of the same name. An empty ref resolves the default branch of the repo.
+// The httpTimeout is the timeout in seconds of the request, the default HTTP request timeout is used if nil or invalid.
+func (g *GitUrl) ResolveRemoteRef(ref string, httpTimeout *int) (string, error) {
+	if err := CheckHostAllowed(g.CloneURL()); err != nil {
+		return "", fmt.Errorf("failed to resolve ref: %v", err)
+	}
+	token, err := g.refreshToken(context.Background())
+	if err != nil {
+		return "", err
+	}
+
+	timeout := HTTPRequestResponseTimeout
+	if httpTimeout != nil && *httpTimeout > 0 {
+		timeout = time.Duration(*httpTimeout) * time.Second
+	}
+
+	refs, err := listRemoteRefs(getCloneBackend(), g.cloneURL(token, ""), timeout)
+	if err != nil {
+		if token != "" {
+			err = fmt.Errorf("%s", strings.ReplaceAll(err.Error(), token, "<redacted>"))
+		}
+		return "", fmt.Errorf("failed to list the refs of repo %s: %v", g.CloneURL(), err)
+	}
+
+	if ref == "" {
+		ref = "HEAD"
+	}
+	// the commit of an annotated tag is its peeled ref
+	for _, name := range []string{ref, "refs/heads/" + ref, "refs/tags/" + ref + peeledSuffix, "refs/tags/" + ref} {
+		if sha, found := refs[name]; found {
+			return sha, nil
+		}
+	}
+	return "", fmt.Errorf("failed to resolve ref %s, not found in repo %s", ref, g.CloneURL())
+}
+
+// listRemoteRefs lists the refs of the remote repo of the authenticated cloneURL by name, including the peeled annotated tags
+func listRemoteRefs(backend CloneBackend, cloneURL string, timeout time.Duration) (map[string]string, error) {
+	if backend != CloneBackendGoGit {
+		// git has no overall timeout, the request is aborted if no data is received during the timeout instead
+		out, err := execute("", "git", "-c", "http.lowSpeedLimit=1", "-c", "http.lowSpeedTime="+strconv.Itoa(int(timeout.Seconds())),
+			"ls-remote", cloneURL)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(string(out)))
+		}
+		refs := map[string]string{}
+		scanner := bufio.NewScanner(bytes.NewReader(out))
+		for scanner.Scan() {
+			if sha, name, found := strings.Cut(scanner.Text(), "\t"); found {
+				refs[name] = sha
+			}
+		}
+		return refs, nil
+	}
+
+	endpoint, err := transport.NewEndpoint(cloneURL)
+	if err != nil {
+		return nil, err
+	}
+	c, err := client.NewClient(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	session, err := c.NewUploadPackSession(endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	advertised, err := session.AdvertisedReferencesContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := map[string]string{}
+	if advertised.Head != nil {
+		refs["HEAD"] = advertised.Head.String()
+	}
+	for name, hash := range advertised.References {
+		refs[name] = hash.String()
+	}
+	for name, hash := range advertised.Peeled {
+		refs[name+peeledSuffix] = hash.String()
+	}
+	return refs, nil
+}
@@ -0,0 +1,87 @@
+//
+// Copyright 2023 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ResolveRemoteRef(t *testing.T) {
+	repoUrl, mainCommit, featureCommit := initTestRepoWithBranches(t)
+	repoDir := filepath.FromSlash("/" + repoUrl.Owner + "/stack.git")
+	runTestGit(t, repoDir, "tag", "v1.0.0", featureCommit)
+	runTestGit(t, repoDir, "tag", "--annotate", "-m", "release", "v2.0.0", mainCommit)
+
+	tests := []struct {
+		name    string
+		ref     string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "should resolve the default branch",
+			want: mainCommit,
+		},
+		{
+			name: "should resolve a branch",
+			ref:  "feature",
+			want: featureCommit,
+		},
+		{
+			name: "should resolve a full ref name",
+			ref:  "refs/heads/feature",
+			want: featureCommit,
+		},
+		{
+			name: "should resolve a lightweight tag",
+			ref:  "v1.0.0",
+			want: featureCommit,
+		},
+		{
+			name: "should resolve an annotated tag to the commit it tags",
+			ref:  "v2.0.0",
+			want: mainCommit,
+		},
+		{
+			name:    "should fail with a missing ref",
+			ref:     "missing",
+			wantErr: "failed to resolve ref missing, not found in repo .*",
+		},
+	}
+	for _, backend := range []CloneBackend{CloneBackendExec, CloneBackendGoGit} {
+		for _, tt := range tests {
+			t.Run(string(backend)+" "+tt.name, func(t *testing.T) {
+				if err := SetCloneBackend(backend); err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				defer func() { _ = SetCloneBackend(CloneBackendExec) }()
+
+				got, err := repoUrl.ResolveRemoteRef(tt.ref, nil)
+				if (err != nil) != (tt.wantErr != "") {
+					t.Fatalf("Unexpected error: %v, want: %v", err, tt.wantErr)
+				}
+				if err != nil {
+					assert.Regexp(t, tt.wantErr, err.Error(), "Error message should match")
+				} else if got != tt.want {
+					t.Errorf("Got: %v, want: %v", got, tt.want)
+				}
+			})
+		}
+	}
+}
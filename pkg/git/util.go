@@ -16,6 +16,7 @@
 package git
 
 import (
+	"context"
 	"fmt"
 	"github.com/devfile/library/v2/pkg/testingutil/filesystem"
 	"github.com/gregjones/httpcache"
@@ -29,6 +30,8 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -36,6 +39,9 @@ const (
 	HTTPRequestResponseTimeout = 30 * time.Second // HTTPRequestTimeout configures timeout of all HTTP requests
 )
 
+// defaultMaxRedirects is the default limit of redirects of an http client
+const defaultMaxRedirects = 10
+
 // httpCacheDir determines directory where odo will cache HTTP responses
 var httpCacheDir = filepath.Join(os.TempDir(), "odohttpcache")
 
@@ -44,20 +50,44 @@ type HTTPRequestParams struct {
 	URL                 string
 	Token               string
 	Timeout             *int
-	TelemetryClientName string //optional client name for telemetry
+	TelemetryClientName string          //optional client name for telemetry
+	MaxBytes            int64           //optional maximum size of the response body in bytes, 0 for no limit
+	TokenProvider       TokenProvider   //optional provider of a fresh token for each request, takes precedence over Token
+	MaxRedirects        int             //optional maximum number of redirects followed, 0 for the default of 10, negative for none
+	Context             context.Context //optional context cancelling the request when done
 }
 
 // HTTPGetRequest gets resource contents given URL and token (if applicable)
 // cacheFor determines how long the response should be cached (in minutes), 0 for no caching
 func HTTPGetRequest(request HTTPRequestParams, cacheFor int) ([]byte, error) {
+	body, _, err := httpGetRequestWithHeader(request, cacheFor)
+	return body, err
+}
+
+// httpGetRequestWithHeader gets resource contents like HTTPGetRequest, and returns the response headers alongside the body
+func httpGetRequestWithHeader(request HTTPRequestParams, cacheFor int) ([]byte, http.Header, error) {
+	if err := CheckHostAllowed(request.URL); err != nil {
+		return nil, nil, err
+	}
+
+	ctx := request.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
 	// Build http request
-	req, err := http.NewRequest("GET", request.URL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", request.URL, nil)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	if request.TokenProvider != nil {
+		request.Token, err = request.TokenProvider.Token(ctx)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed to get token from token provider")
+		}
 	}
 	if request.Token != "" {
-		bearer := "Bearer " + request.Token
-		req.Header.Add("Authorization", bearer)
+		req.Header.Add(AuthHeader(request.Token))
 	}
 
 	//add the telemetry client name
@@ -82,10 +112,14 @@ func HTTPGetRequest(request HTTPRequestParams, cacheFor int) ([]byte, error) {
 			Proxy:                 http.ProxyFromEnvironment,
 			ResponseHeaderTimeout: overriddenTimeout,
 		},
-		Timeout: overriddenTimeout,
+		Timeout:       overriddenTimeout,
+		CheckRedirect: CheckRedirect(request.MaxRedirects),
 	}
 
-	klog.V(4).Infof("HTTPGetRequest: %s", req.URL.String())
+	// log the url without its credentials, if any
+	loggedURL := *req.URL
+	loggedURL.User = nil
+	klog.V(4).Infof("HTTPGetRequest: %s", loggedURL.String())
 
 	if cacheFor > 0 {
 		// if there is an error during cache setup we show warning and continue without using cache
@@ -113,8 +147,11 @@ func HTTPGetRequest(request HTTPRequestParams, cacheFor int) ([]byte, error) {
 	}
 
 	resp, err := httpClient.Do(req)
+	if err == nil {
+		resp, err = RetryWithAuthFallback(httpClient, req, resp, request.Token)
+	}
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer resp.Body.Close()
 
@@ -124,16 +161,56 @@ func HTTPGetRequest(request HTTPRequestParams, cacheFor int) ([]byte, error) {
 
 	// We have a non 1xx / 2xx status, return an error
 	if (resp.StatusCode - 300) > 0 {
-		return nil, errors.Errorf("failed to retrieve %s, %v: %s", request.URL, resp.StatusCode, http.StatusText(resp.StatusCode))
+		return nil, resp.Header, errors.Errorf("failed to retrieve %s, %v: %s", request.URL, resp.StatusCode, http.StatusText(resp.StatusCode))
 	}
 
 	// Process http response
-	bytes, err := ioutil.ReadAll(resp.Body)
+	body, err := ReadResponseBody(resp.Body, request.URL, request.MaxBytes)
+	return body, resp.Header, err
+}
+
+// CheckRedirect returns the redirect policy of an http client following at most maxRedirects redirects, so that requests to
+// servers redirecting in a loop fail fast. A maxRedirects of 0 keeps the default limit of the http client, of 10 redirects,
+// and a negative maxRedirects follows no redirect. Redirects to a host not allowed by SetAllowedHosts are not followed.
+func CheckRedirect(maxRedirects int) func(req *http.Request, via []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if err := CheckHostAllowed(req.URL.String()); err != nil {
+			return err
+		}
+		if maxRedirects == 0 {
+			if len(via) >= defaultMaxRedirects {
+				return errors.Errorf("stopped after %d redirects", defaultMaxRedirects)
+			}
+			return nil
+		}
+		limit := maxRedirects
+		if limit < 0 {
+			limit = 0
+		}
+		if len(via) > limit {
+			return errors.Errorf("stopped after the maximum of %d redirects", limit)
+		}
+		return nil
+	}
+}
+
+// ReadResponseBody reads the response body of the url, failing if it is larger than maxBytes
+// maxBytes of 0 or less reads the body without a limit
+func ReadResponseBody(body io.Reader, url string, maxBytes int64) ([]byte, error) {
+	if maxBytes <= 0 {
+		return ioutil.ReadAll(body)
+	}
+
+	// read one byte past the limit so an oversized body can be detected
+	bytes, err := ioutil.ReadAll(&io.LimitedReader{R: body, N: maxBytes + 1})
 	if err != nil {
 		return nil, err
 	}
+	if int64(len(bytes)) > maxBytes {
+		return nil, errors.Errorf("failed to retrieve %s, resource exceeds size limit of %d bytes", url, maxBytes)
+	}
 
-	return bytes, err
+	return bytes, nil
 }
 
 // ValidateURL validates the URL
@@ -150,8 +227,16 @@ func ValidateURL(sourceURL string) error {
 	return nil
 }
 
-// cleanHttpCache checks cacheDir and deletes all files that were modified more than cacheTime back
+// HTTPCacheLock serializes the clean ups of the HTTP cache directory by concurrent requests. It is shared with pkg/util,
+// which caches its requests in the same directory.
+var HTTPCacheLock sync.Mutex
+
+// cleanHttpCache checks cacheDir and deletes all files that were modified more than cacheTime back.
+// Files already deleted, e.g. by another process, are ignored.
 func cleanHttpCache(cacheDir string, cacheTime time.Duration) error {
+	HTTPCacheLock.Lock()
+	defer HTTPCacheLock.Unlock()
+
 	cacheFiles, err := ioutil.ReadDir(cacheDir)
 	if err != nil {
 		return err
@@ -161,7 +246,7 @@ func cleanHttpCache(cacheDir string, cacheTime time.Duration) error {
 		if f.ModTime().Add(cacheTime).Before(time.Now()) {
 			klog.V(4).Infof("Removing cache file %s, because it is older than %s", f.Name(), cacheTime.String())
 			err := os.Remove(filepath.Join(cacheDir, f.Name()))
-			if err != nil {
+			if err != nil && !os.IsNotExist(err) {
 				return err
 			}
 		}
@@ -169,6 +254,102 @@ func cleanHttpCache(cacheDir string, cacheTime time.Duration) error {
 	return nil
 }
 
+// getDirSize returns the total size in bytes of all files under a directory
+func getDirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
+// isEmptyDir checks if the directory has no entries
+func isEmptyDir(dir string) (bool, error) {
+	f, err := os.Open(dir)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	if _, err = f.Readdirnames(1); err == io.EOF {
+		return true, nil
+	}
+	return false, err
+}
+
+// clearDir removes the content of the directory, keeping the directory itself
+func clearDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err = os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeDir recursively copies srcDir over destDir, replacing the files and symlinks of the same paths and keeping the other
+// content of destDir. File modes and symlinks are preserved.
+func mergeDir(srcDir, destDir string) error {
+	return filepath.Walk(srcDir, func(srcPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(srcDir, srcPath)
+		if err != nil {
+			return err
+		}
+		destPath := filepath.Join(destDir, rel)
+
+		if info.IsDir() {
+			// a file of destDir in place of a directory of srcDir is replaced
+			if destInfo, err := os.Lstat(destPath); err == nil && !destInfo.IsDir() {
+				if err = os.Remove(destPath); err != nil {
+					return err
+				}
+			}
+			return os.MkdirAll(destPath, info.Mode().Perm())
+		}
+		if err = os.RemoveAll(destPath); err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			target, err := os.Readlink(srcPath)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(target, destPath)
+		}
+		return copyFileWithMode(srcPath, destPath, info.Mode().Perm())
+	})
+}
+
+// copyFileWithMode copies the regular file at srcPath to destPath, created with the given mode
+func copyFileWithMode(srcPath, destPath string, mode os.FileMode) error {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dest, err := os.OpenFile(destPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(dest, src); err != nil {
+		dest.Close()
+		return err
+	}
+	return dest.Close()
+}
+
 // CheckPathExists checks if a path exists or not
 func CheckPathExists(path string) bool {
 	return checkPathExistsOnFS(path, filesystem.DefaultFs{})
@@ -183,15 +364,45 @@ func checkPathExistsOnFS(path string, fs filesystem.Filesystem) bool {
 	return false
 }
 
+// TransformFunc transforms the content of a text file copied from srcPath, e.g. to substitute variables
+type TransformFunc func(srcPath string, content []byte) ([]byte, error)
+
+// CopyOptions are the options of a recursive directory copy
+type CopyOptions struct {
+	// TransformText is applied to the content of each text file copied. Binary files are copied untouched.
+	// Files are classified as text or binary by sniffing their content, see IsTextContent.
+	TransformText TransformFunc
+}
+
+// IsTextContent sniffs the content of a file and returns true if it is text, false if it is binary
+func IsTextContent(content []byte) bool {
+	return strings.HasPrefix(http.DetectContentType(content), "text/")
+}
+
 // CopyAllDirFiles recursively copies a source directory to a destination directory
 func CopyAllDirFiles(srcDir, destDir string) error {
 	return copyAllDirFilesOnFS(srcDir, destDir, filesystem.DefaultFs{})
 }
 
+// CopyAllDirFilesWithOptions recursively copies a source directory to a destination directory with the given copy options
+func CopyAllDirFilesWithOptions(srcDir, destDir string, options CopyOptions) error {
+	return copyAllDirFilesBetweenFS(srcDir, destDir, filesystem.DefaultFs{}, filesystem.DefaultFs{}, options)
+}
+
+// CopyAllDirFilesToFs recursively copies a source directory of the OS filesystem, such as a cloned repo,
+// to a destination directory of the destination filesystem
+func CopyAllDirFilesToFs(srcDir, destDir string, destFs filesystem.Filesystem) error {
+	return copyAllDirFilesBetweenFS(srcDir, destDir, filesystem.DefaultFs{}, destFs, CopyOptions{})
+}
+
 func copyAllDirFilesOnFS(srcDir, destDir string, fs filesystem.Filesystem) error {
+	return copyAllDirFilesBetweenFS(srcDir, destDir, fs, fs, CopyOptions{})
+}
+
+func copyAllDirFilesBetweenFS(srcDir, destDir string, srcFs, destFs filesystem.Filesystem, options CopyOptions) error {
 	var info os.FileInfo
 
-	files, err := fs.ReadDir(srcDir)
+	files, err := srcFs.ReadDir(srcDir)
 	if err != nil {
 		return errors.Wrapf(err, "failed reading dir %v", srcDir)
 	}
@@ -201,13 +412,13 @@ func copyAllDirFilesOnFS(srcDir, destDir string, fs filesystem.Filesystem) error
 		destPath := path.Join(destDir, file.Name())
 
 		if file.IsDir() {
-			if info, err = fs.Stat(srcPath); err != nil {
+			if info, err = srcFs.Stat(srcPath); err != nil {
 				return err
 			}
-			if err = fs.MkdirAll(destPath, info.Mode()); err != nil {
+			if err = destFs.MkdirAll(destPath, info.Mode()); err != nil {
 				return err
 			}
-			if err = copyAllDirFilesOnFS(srcPath, destPath, fs); err != nil {
+			if err = copyAllDirFilesBetweenFS(srcPath, destPath, srcFs, destFs, options); err != nil {
 				return err
 			}
 		} else {
@@ -215,8 +426,8 @@ func copyAllDirFilesOnFS(srcDir, destDir string, fs filesystem.Filesystem) error
 				continue
 			}
 			// Only copy files that do not exist in the destination directory
-			if !checkPathExistsOnFS(destPath, fs) {
-				if err := copyFileOnFs(srcPath, destPath, fs); err != nil {
+			if !checkPathExistsOnFS(destPath, destFs) {
+				if err := copyFileBetweenFs(srcPath, destPath, srcFs, destFs, options.TransformText); err != nil {
 					return errors.Wrapf(err, "failed to copy %s to %s", srcPath, destPath)
 				}
 			}
@@ -226,11 +437,11 @@ func copyAllDirFilesOnFS(srcDir, destDir string, fs filesystem.Filesystem) error
 }
 
 // copied from: https://github.com/devfile/registry-support/blob/main/index/generator/library/util.go
-func copyFileOnFs(src, dst string, fs filesystem.Filesystem) error {
+func copyFileBetweenFs(src, dst string, srcFs, destFs filesystem.Filesystem, transformText TransformFunc) error {
 	var err error
 	var srcinfo os.FileInfo
 
-	srcfd, err := fs.Open(src)
+	srcfd, err := srcFs.Open(src)
 	if err != nil {
 		return err
 	}
@@ -240,7 +451,7 @@ func copyFileOnFs(src, dst string, fs filesystem.Filesystem) error {
 		}
 	}()
 
-	dstfd, err := fs.Create(dst)
+	dstfd, err := destFs.Create(dst)
 	if err != nil {
 		return err
 	}
@@ -250,11 +461,49 @@ func copyFileOnFs(src, dst string, fs filesystem.Filesystem) error {
 		}
 	}()
 
-	if _, err = io.Copy(dstfd, srcfd); err != nil {
-		return err
+	if transformText == nil {
+		if _, err = io.Copy(dstfd, srcfd); err != nil {
+			return err
+		}
+	} else {
+		content, err := io.ReadAll(srcfd)
+		if err != nil {
+			return err
+		}
+		if IsTextContent(content) {
+			if content, err = transformText(src, content); err != nil {
+				return err
+			}
+		}
+		if _, err = dstfd.Write(content); err != nil {
+			return err
+		}
 	}
-	if srcinfo, err = fs.Stat(src); err != nil {
+	if srcinfo, err = srcFs.Stat(src); err != nil {
 		return err
 	}
-	return fs.Chmod(dst, srcinfo.Mode())
+	return destFs.Chmod(dst, srcinfo.Mode())
+}
+
+// HTTPClient sends the http requests retried by RetryWithAuthFallback
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// RetryWithAuthFallback retries the request authenticated with the token and rejected with a 401 status, with the header of
+// AuthFallbackHeader instead of the header of AuthHeader, if the url has a fallback header. Returns the response otherwise.
+func RetryWithAuthFallback(httpClient HTTPClient, req *http.Request, resp *http.Response, token string) (*http.Response, error) {
+	if resp.StatusCode != http.StatusUnauthorized || token == "" {
+		return resp, nil
+	}
+	name, value, ok := AuthFallbackHeader(req.URL.String(), token)
+	if !ok {
+		return resp, nil
+	}
+	resp.Body.Close()
+
+	retry := req.Clone(req.Context())
+	retry.Header.Del("Authorization")
+	retry.Header.Set(name, value)
+	return httpClient.Do(retry)
 }
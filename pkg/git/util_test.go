@@ -16,10 +16,15 @@
 package git
 
 import (
+	"bytes"
 	"github.com/devfile/library/v2/pkg/testingutil/filesystem"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -39,10 +44,11 @@ func TestHTTPGetRequest(t *testing.T) {
 	defer server.Close()
 
 	tests := []struct {
-		name    string
-		url     string
-		want    []byte
-		timeout *int
+		name     string
+		url      string
+		want     []byte
+		timeout  *int
+		maxBytes int64
 	}{
 		{
 			name: "Case 1: Input url is valid",
@@ -68,13 +74,26 @@ func TestHTTPGetRequest(t *testing.T) {
 			timeout: &validHTTPTimeout,
 			want:    []byte{79, 75},
 		},
+		{
+			name:     "Case 5: Response body within size limit",
+			url:      server.URL,
+			maxBytes: 2,
+			want:     []byte{79, 75},
+		},
+		{
+			name:     "Case 6: Response body exceeds size limit",
+			url:      server.URL,
+			maxBytes: 1,
+			want:     nil,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			request := HTTPRequestParams{
-				URL:     tt.url,
-				Timeout: tt.timeout,
+				URL:      tt.url,
+				Timeout:  tt.timeout,
+				MaxBytes: tt.maxBytes,
 			}
 			got, err := HTTPGetRequest(request, 0)
 
@@ -86,6 +105,76 @@ func TestHTTPGetRequest(t *testing.T) {
 	}
 }
 
+func TestHTTPGetRequestWithTokenProvider(t *testing.T) {
+	var authHeaders []string
+
+	// Start a local HTTP server
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		authHeaders = append(authHeaders, req.Header.Get("Authorization"))
+		_, err := rw.Write([]byte("OK"))
+		if err != nil {
+			t.Error(err)
+		}
+	}))
+	// Close the server when test finishes
+	defer server.Close()
+
+	request := HTTPRequestParams{
+		URL:           server.URL,
+		Token:         "ignored-token",
+		TokenProvider: &rotatingTokenProvider{},
+	}
+	for i := 0; i < 2; i++ {
+		_, err := HTTPGetRequest(request, 0)
+		if err != nil {
+			t.Errorf("Unxpected error: %v", err)
+		}
+	}
+
+	want := []string{"Bearer token-1", "Bearer token-2"}
+	if !reflect.DeepEqual(authHeaders, want) {
+		t.Errorf("Got: %v, want: %v", authHeaders, want)
+	}
+}
+
+func TestHTTPGetRequestMaxRedirects(t *testing.T) {
+	var requests int
+	// Start a local HTTP server redirecting in a loop
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		requests++
+		http.Redirect(rw, req, req.URL.Path, http.StatusFound)
+	}))
+	// Close the server when test finishes
+	defer server.Close()
+
+	_, err := HTTPGetRequest(HTTPRequestParams{URL: server.URL + "/loop", MaxRedirects: 2}, 0)
+	if err == nil || !strings.Contains(err.Error(), "stopped after the maximum of 2 redirects") {
+		t.Errorf("Got error: %v, want the redirect limit error", err)
+	}
+	if requests != 3 {
+		t.Errorf("Got %v requests, want: 3", requests)
+	}
+}
+
+func TestHTTPGetRequestAllowedHostsRedirect(t *testing.T) {
+	otherServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		_, _ = rw.Write([]byte("OK"))
+	}))
+	defer otherServer.Close()
+	allowedServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		http.Redirect(rw, req, otherServer.URL, http.StatusFound)
+	}))
+	defer allowedServer.Close()
+
+	SetAllowedHosts([]string{strings.TrimPrefix(allowedServer.URL, "http://")})
+	defer SetAllowedHosts(nil)
+
+	_, err := HTTPGetRequest(HTTPRequestParams{URL: allowedServer.URL}, 0)
+	if want := "host not allowed: " + strings.TrimPrefix(otherServer.URL, "http://"); err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("Got error: %v, want: %v", err, want)
+	}
+}
+
 func TestCheckPathExists(t *testing.T) {
 	fs := filesystem.NewFakeFs()
 	fs.MkdirAll("/path/to/devfile", 0755)
@@ -120,3 +209,144 @@ func TestCheckPathExists(t *testing.T) {
 		})
 	}
 }
+
+func TestCopyAllDirFilesToFs(t *testing.T) {
+	// the source directory is on the OS filesystem, as a cloned repo
+	srcDir := t.TempDir()
+	srcFiles := map[string]string{
+		"devfile.yaml":         "schemaVersion: 2.2.0",
+		"deploy.yaml":          "kind: Deployment",
+		"docker/Dockerfile":    "FROM scratch",
+		"docker/.dockerignore": "*.log",
+	}
+	for name, content := range srcFiles {
+		filePath := filepath.Join(srcDir, name)
+		if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	destDir := "/path/to/devfile"
+	destFs := filesystem.NewFakeFs()
+	if err := destFs.MkdirAll(destDir, 0755); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// existing files of the destination directory are kept
+	if err := destFs.WriteFile(path.Join(destDir, "deploy.yaml"), []byte("kind: Pod"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	err := CopyAllDirFilesToFs(srcDir, destDir, destFs)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	wantFiles := map[string]string{
+		"deploy.yaml":          "kind: Pod",
+		"docker/Dockerfile":    "FROM scratch",
+		"docker/.dockerignore": "*.log",
+	}
+	for name, want := range wantFiles {
+		got, err := destFs.ReadFile(path.Join(destDir, name))
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		} else if string(got) != want {
+			t.Errorf("Got: %s, want: %s", got, want)
+		}
+	}
+	// the devfile is not copied
+	if checkPathExistsOnFS(path.Join(destDir, "devfile.yaml"), destFs) {
+		t.Errorf("Got: devfile.yaml copied to %s, want: not copied", destDir)
+	}
+	// the OS filesystem is left untouched
+	if CheckPathExists(destDir) {
+		t.Errorf("Got: %s created on the OS filesystem, want: only on the destination filesystem", destDir)
+	}
+}
+
+func TestCopyAllDirFilesWithOptions(t *testing.T) {
+	textContent := []byte("image: {{image}}\n")
+	// a PNG header followed by binary data, which happens to contain the variable reference
+	binaryContent := append([]byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR"), []byte("{{image}}\x00\xff")...)
+
+	srcDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(srcDir, "assets"), 0755); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(srcDir, "deploy.yaml"), textContent, 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(srcDir, "assets", "icon.png"), binaryContent, 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var transformed []string
+	destDir := t.TempDir()
+	err := CopyAllDirFilesWithOptions(srcDir, destDir, CopyOptions{
+		TransformText: func(srcPath string, content []byte) ([]byte, error) {
+			transformed = append(transformed, srcPath)
+			return bytes.ReplaceAll(content, []byte("{{image}}"), []byte("quay.io/devfile/app:latest")), nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	wantTransformed := []string{path.Join(srcDir, "deploy.yaml")}
+	if !reflect.DeepEqual(transformed, wantTransformed) {
+		t.Errorf("Got transformed files: %v, want: %v", transformed, wantTransformed)
+	}
+	gotText, err := os.ReadFile(filepath.Join(destDir, "deploy.yaml"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if want := "image: quay.io/devfile/app:latest\n"; string(gotText) != want {
+		t.Errorf("Got: %q, want: %q", gotText, want)
+	}
+	gotBinary, err := os.ReadFile(filepath.Join(destDir, "assets", "icon.png"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !bytes.Equal(gotBinary, binaryContent) {
+		t.Errorf("Got: %q, want the binary file untouched: %q", gotBinary, binaryContent)
+	}
+}
+
+func TestIsTextContent(t *testing.T) {
+	tests := []struct {
+		name    string
+		content []byte
+		want    bool
+	}{
+		{
+			name:    "yaml file",
+			content: []byte("schemaVersion: 2.2.0\nmetadata:\n  name: nodejs\n"),
+			want:    true,
+		},
+		{
+			name:    "empty file",
+			content: []byte{},
+			want:    true,
+		},
+		{
+			name:    "png file",
+			content: []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR"),
+			want:    false,
+		},
+		{
+			name:    "binary data",
+			content: []byte{0x00, 0x01, 0x02, 0xff},
+			want:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsTextContent(tt.content); got != tt.want {
+				t.Errorf("Got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+//
+// Copyright 2023 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	indexSchema "github.com/devfile/registry-support/index/generator/schema"
+
+	"github.com/devfile/library/v2/pkg/util"
+)
+
+const (
+	// indexCacheTime is how long a registry index is cached, in minutes
+	indexCacheTime = 15
+	// latestVersion resolves the default version of a stack
+	latestVersion = "latest"
+)
+
+// StackEntry is an entry of the registry index, with the versions of the stack
+type StackEntry = indexSchema.Schema
+
+// GetIndex downloads the index of the devfile registry, with the versions of each stack.
+// The index is cached on disk for up to 15 minutes, as allowed by the cache headers of the registry, so tools
+// resolving several stacks by id only download it once.
+func GetIndex(registryURL string, httpTimeout *int) ([]StackEntry, error) {
+	indexURL := strings.TrimSuffix(registryURL, "/") + "/v2index"
+	param := util.HTTPRequestParams{
+		URL:                 indexURL,
+		Timeout:             httpTimeout,
+		TelemetryClientName: util.TelemetryIndirectDevfileCall,
+	}
+	res, err := util.HTTPGetRequest(param, indexCacheTime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the index of registry %s: %v", registryURL, err)
+	}
+
+	var index []StackEntry
+	if err = json.Unmarshal(res, &index); err != nil {
+		return nil, fmt.Errorf("failed to decode the index of registry %s: %v", registryURL, err)
+	}
+	return index, nil
+}
+
+// ResolveStack resolves the stack id and version to the url of its devfile in the registry.
+// An empty or "latest" version resolves to the default version of the stack.
+func ResolveStack(registryURL, id, version string) (string, error) {
+	index, err := GetIndex(registryURL, nil)
+	if err != nil {
+		return "", err
+	}
+
+	for _, stack := range index {
+		if stack.Name != id {
+			continue
+		}
+		if stack.Type != "" && stack.Type != indexSchema.StackDevfileType {
+			return "", fmt.Errorf("%s of registry %s is not a stack", id, registryURL)
+		}
+		devfileURL := fmt.Sprintf("%s/devfiles/%s", strings.TrimSuffix(registryURL, "/"), id)
+		if version == "" || version == latestVersion {
+			return devfileURL, nil
+		}
+		for _, v := range stack.Versions {
+			if v.Version == version {
+				return devfileURL + "/" + version, nil
+			}
+		}
+		return "", fmt.Errorf("version %s of stack %s not found in registry %s", version, id, registryURL)
+	}
+	return "", fmt.Errorf("stack %s not found in registry %s", id, registryURL)
+}
@@ -0,0 +1,124 @@
+//
+// Copyright 2023 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	indexSchema "github.com/devfile/registry-support/index/generator/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveStack(t *testing.T) {
+	index := []StackEntry{
+		{
+			Name: "nodejs",
+			Type: "stack",
+			Versions: []indexSchema.Version{
+				{Version: "2.1.1", Default: true},
+				{Version: "2.2.0"},
+			},
+		},
+		{
+			Name: "nodejs-basic",
+			Type: "sample",
+		},
+	}
+
+	requests := 0
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2index" {
+			http.NotFound(w, r)
+			return
+		}
+		requests++
+		w.Header().Set("Cache-Control", "max-age=60")
+		_ = json.NewEncoder(w).Encode(index)
+	}))
+	defer testServer.Close()
+
+	tests := []struct {
+		name    string
+		id      string
+		version string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "should resolve the default version of a stack",
+			id:   "nodejs",
+			want: testServer.URL + "/devfiles/nodejs",
+		},
+		{
+			name:    "should resolve the latest version of a stack",
+			id:      "nodejs",
+			version: "latest",
+			want:    testServer.URL + "/devfiles/nodejs",
+		},
+		{
+			name:    "should resolve a version of a stack",
+			id:      "nodejs",
+			version: "2.2.0",
+			want:    testServer.URL + "/devfiles/nodejs/2.2.0",
+		},
+		{
+			name:    "should fail with a missing version",
+			id:      "nodejs",
+			version: "1.0.0",
+			wantErr: "version 1.0.0 of stack nodejs not found in registry .*",
+		},
+		{
+			name:    "should fail with a missing stack",
+			id:      "java-maven",
+			wantErr: "stack java-maven not found in registry .*",
+		},
+		{
+			name:    "should fail with a sample",
+			id:      "nodejs-basic",
+			wantErr: "nodejs-basic of registry .* is not a stack",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveStack(testServer.URL, tt.id, tt.version)
+			if (err != nil) != (tt.wantErr != "") {
+				t.Fatalf("Unexpected error: %v, want: %v", err, tt.wantErr)
+			}
+			if err != nil {
+				assert.Regexp(t, tt.wantErr, err.Error(), "Error message should match")
+			} else if got != tt.want {
+				t.Errorf("Got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+
+	if requests != 1 {
+		t.Errorf("Got %d index requests, want the cached index to be used after the first request", requests)
+	}
+}
+
+func TestGetIndex(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("not an index"))
+	}))
+	defer testServer.Close()
+
+	_, err := GetIndex(testServer.URL, nil)
+	assert.Regexp(t, "failed to decode the index of registry .*", err.Error(), "Error message should match")
+}
@@ -0,0 +1,98 @@
+//
+// Copyright 2023 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package telemetry emits structured events of the parser and the git package, such as parse results and clone timings,
+// to a sink set by the embedding tool, e.g. to gather usage and error metrics
+package telemetry
+
+import (
+	"sync"
+	"time"
+)
+
+// EventType is the type of a telemetry event
+type EventType string
+
+const (
+	// ParseStarted is emitted when the parsing of a devfile starts
+	ParseStarted EventType = "parse.started"
+	// ParseSucceeded is emitted when a devfile is parsed, with the duration of the parsing
+	ParseSucceeded EventType = "parse.succeeded"
+	// ParseFailed is emitted when the parsing of a devfile fails, with the duration of the parsing and its error
+	ParseFailed EventType = "parse.failed"
+	// CloneStarted is emitted when the clone of a git repo starts
+	CloneStarted EventType = "clone.started"
+	// CloneSucceeded is emitted when a git repo is cloned, with the duration of the clone
+	CloneSucceeded EventType = "clone.succeeded"
+	// CloneFailed is emitted when the clone of a git repo fails, with the duration of the clone and its error
+	CloneFailed EventType = "clone.failed"
+)
+
+// Event is a telemetry event
+type Event struct {
+	// Type is the type of the event
+	Type EventType
+	// Time is when the event was emitted
+	Time time.Time
+	// Duration is the duration of the operation of a succeeded or failed event
+	Duration time.Duration
+	// Properties describe the operation of the event, e.g. the source of a parsed devfile or the host of a cloned repo.
+	// They never contain urls or credentials.
+	Properties map[string]string
+	// Err is the error of a failed event
+	Err error
+}
+
+// TelemetrySink receives the telemetry events. Events may be emitted concurrently, so sinks should be safe for concurrent use.
+// Emit is called synchronously by the operation of the event and should return quickly.
+type TelemetrySink interface {
+	Emit(event Event)
+}
+
+var (
+	sinkLock sync.RWMutex
+	sink     TelemetrySink
+)
+
+// SetTelemetrySink sets the sink receiving the telemetry events, nil to stop emitting events
+func SetTelemetrySink(telemetrySink TelemetrySink) {
+	sinkLock.Lock()
+	defer sinkLock.Unlock()
+	sink = telemetrySink
+}
+
+// Emit sends the event to the telemetry sink, if any, setting the time of the event if it is not set
+func Emit(event Event) {
+	sinkLock.RLock()
+	telemetrySink := sink
+	sinkLock.RUnlock()
+	if telemetrySink == nil {
+		return
+	}
+
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	telemetrySink.Emit(event)
+}
+
+// EmitResult emits the succeeded event of an operation started at start, or its failed event if err is not nil
+func EmitResult(succeeded EventType, failed EventType, start time.Time, properties map[string]string, err error) {
+	event := Event{Type: succeeded, Duration: time.Since(start), Properties: properties}
+	if err != nil {
+		event.Type, event.Err = failed, err
+	}
+	Emit(event)
+}
@@ -0,0 +1,81 @@
+//
+// Copyright 2023 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// capturingSink records the events it receives
+type capturingSink struct {
+	lock   sync.Mutex
+	events []Event
+}
+
+func (c *capturingSink) Emit(event Event) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.events = append(c.events, event)
+}
+
+func TestEmitResult(t *testing.T) {
+	sink := &capturingSink{}
+	SetTelemetrySink(sink)
+	defer SetTelemetrySink(nil)
+
+	start := time.Now().Add(-time.Second)
+	properties := map[string]string{"source": "path"}
+	failure := errors.New("failure")
+	EmitResult(ParseSucceeded, ParseFailed, start, properties, nil)
+	EmitResult(ParseSucceeded, ParseFailed, start, properties, failure)
+
+	if len(sink.events) != 2 {
+		t.Fatalf("Got: %v events, want: 2", len(sink.events))
+	}
+	for i, want := range []struct {
+		eventType EventType
+		err       error
+	}{{ParseSucceeded, nil}, {ParseFailed, failure}} {
+		got := sink.events[i]
+		if got.Type != want.eventType || got.Err != want.err {
+			t.Errorf("Got: %v %v, want: %v %v", got.Type, got.Err, want.eventType, want.err)
+		}
+		if got.Duration < time.Second || got.Time.IsZero() {
+			t.Errorf("Got duration: %v and time: %v, want a duration of at least 1s and the time of the event", got.Duration, got.Time)
+		}
+		if !reflect.DeepEqual(got.Properties, properties) {
+			t.Errorf("Got: %v, want: %v", got.Properties, properties)
+		}
+	}
+}
+
+func TestSetTelemetrySink(t *testing.T) {
+	sink := &capturingSink{}
+	SetTelemetrySink(sink)
+	Emit(Event{Type: CloneStarted})
+
+	// events are dropped once the sink is unset
+	SetTelemetrySink(nil)
+	Emit(Event{Type: CloneSucceeded})
+
+	if len(sink.events) != 1 || sink.events[0].Type != CloneStarted {
+		t.Errorf("Got: %v, want a single %v event", sink.events, CloneStarted)
+	}
+}
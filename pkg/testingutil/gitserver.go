@@ -0,0 +1,221 @@
+//
+// Copyright 2023 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testingutil
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// The git providers mimicked by a FakeGitServer, matching the git provider hosts of the git package
+const (
+	FakeGitHubProvider    = "github.com"
+	FakeGitLabProvider    = "gitlab.com"
+	FakeBitbucketProvider = "bitbucket.org"
+)
+
+// fakeAPIPrefixes are the paths of the REST APIs of the git providers on a FakeGitServer
+var fakeAPIPrefixes = map[string]string{
+	FakeGitHubProvider:    "/api/v3",
+	FakeGitLabProvider:    "/api/v4",
+	FakeBitbucketProvider: "/2.0",
+}
+
+// FakeGitRepo is a repository served by a FakeGitServer
+type FakeGitRepo struct {
+	// Owner is the owner of the repository, e.g. devfile
+	Owner string
+	// Repo is the name of the repository, e.g. library
+	Repo string
+	// DefaultBranch is the default branch reported by the REST API. Defaults to main.
+	DefaultBranch string
+	// Token is the token required to access the repository. A repository without a token is public.
+	Token string
+	// Files are the file contents of the repository by revision, then by path, e.g. Files["main"]["devfile.yaml"]
+	Files map[string]map[string]string
+}
+
+// FakeGitServer is a httptest.Server mimicking the REST API and raw file endpoints of a git provider instance, so that
+// tests relying on git providers run without network access. The server serves the following endpoints of its provider:
+//   - GitHub: {APIBaseURL}/repos/{owner}/{repo} and /raw/{owner}/{repo}/{revision}/{path}, as served by GitHub Enterprise
+//   - GitLab: {APIBaseURL}/projects/{owner}%2F{repo} and {APIBaseURL}/projects/{owner}%2F{repo}/repository/files/{path}/raw?ref={revision}
+//   - Bitbucket: {APIBaseURL}/repositories/{owner}/{repo} and {APIBaseURL}/repositories/{owner}/{repo}/src/{revision}/{path}
+//
+// Unknown repositories and files, and private repositories requested without their token, are not found. The token is read
+// from a bearer Authorization header, or from the PRIVATE-TOKEN header by a GitLab server.
+// Register the server with git.RegisterGitHost, using its Host, Provider and APIBaseURL, for git urls of the server to target it.
+type FakeGitServer struct {
+	*httptest.Server
+	// Provider is the git provider mimicked by the server, one of FakeGitHubProvider, FakeGitLabProvider or FakeBitbucketProvider
+	Provider string
+
+	lock  sync.RWMutex
+	repos map[string]FakeGitRepo
+}
+
+// NewFakeGitServer starts a FakeGitServer mimicking the git provider and serving the repositories. The server should be closed when done.
+func NewFakeGitServer(provider string, repos ...FakeGitRepo) *FakeGitServer {
+	server := &FakeGitServer{
+		Provider: provider,
+		repos:    map[string]FakeGitRepo{},
+	}
+	for _, repo := range repos {
+		server.AddRepo(repo)
+	}
+	server.Server = httptest.NewServer(http.HandlerFunc(server.serveHTTP))
+	return server
+}
+
+// AddRepo serves the repository, replacing any repository of the same owner and name
+func (s *FakeGitServer) AddRepo(repo FakeGitRepo) {
+	if repo.DefaultBranch == "" {
+		repo.DefaultBranch = "main"
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.repos[repo.Owner+"/"+repo.Repo] = repo
+}
+
+// Host returns the host and port of the server, e.g. 127.0.0.1:36789
+func (s *FakeGitServer) Host() string {
+	return strings.TrimPrefix(s.URL, "http://")
+}
+
+// APIBaseURL returns the base URL of the REST API of the server
+func (s *FakeGitServer) APIBaseURL() string {
+	return s.URL + fakeAPIPrefixes[s.Provider]
+}
+
+func (s *FakeGitServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	var owner, repoName, revision, filePath string
+	var ok bool
+	switch s.Provider {
+	case FakeGitHubProvider:
+		owner, repoName, revision, filePath, ok = s.parseGitHubPath(r)
+	case FakeGitLabProvider:
+		owner, repoName, revision, filePath, ok = s.parseGitLabPath(r)
+	case FakeBitbucketProvider:
+		owner, repoName, revision, filePath, ok = s.parseBitbucketPath(r)
+	}
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	s.lock.RLock()
+	repo, found := s.repos[owner+"/"+repoName]
+	s.lock.RUnlock()
+	if !found || (repo.Token != "" && !s.authorized(r, repo.Token)) {
+		http.NotFound(w, r)
+		return
+	}
+
+	// raw file request
+	if filePath != "" {
+		content, found := repo.Files[revision][filePath]
+		if !found {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(content))
+		return
+	}
+
+	// repository API request
+	var body interface{}
+	switch s.Provider {
+	case FakeGitHubProvider:
+		body = map[string]interface{}{"name": repo.Repo, "private": repo.Token != "", "default_branch": repo.DefaultBranch}
+	case FakeGitLabProvider:
+		body = map[string]interface{}{"path": repo.Repo, "default_branch": repo.DefaultBranch}
+	case FakeBitbucketProvider:
+		body = map[string]interface{}{"slug": repo.Repo, "is_private": repo.Token != "", "mainbranch": map[string]string{"name": repo.DefaultBranch}}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// authorized checks if the request is authenticated with the token, in a bearer Authorization header, or in the
+// PRIVATE-TOKEN header for GitLab
+func (s *FakeGitServer) authorized(r *http.Request, token string) bool {
+	if s.Provider == FakeGitLabProvider && r.Header.Get("PRIVATE-TOKEN") == token {
+		return true
+	}
+	return r.Header.Get("Authorization") == "Bearer "+token
+}
+
+// parseGitHubPath parses /api/v3/repos/{owner}/{repo} and /raw/{owner}/{repo}/{revision}/{path}
+func (s *FakeGitServer) parseGitHubPath(r *http.Request) (owner, repo, revision, filePath string, ok bool) {
+	if rest := strings.TrimPrefix(r.URL.Path, fakeAPIPrefixes[FakeGitHubProvider]+"/repos/"); rest != r.URL.Path {
+		parts := strings.Split(rest, "/")
+		if len(parts) != 2 {
+			return "", "", "", "", false
+		}
+		return parts[0], parts[1], "", "", true
+	}
+	if rest := strings.TrimPrefix(r.URL.Path, "/raw/"); rest != r.URL.Path {
+		parts := strings.SplitN(rest, "/", 4)
+		if len(parts) != 4 || parts[3] == "" {
+			return "", "", "", "", false
+		}
+		return parts[0], parts[1], parts[2], parts[3], true
+	}
+	return "", "", "", "", false
+}
+
+// parseGitLabPath parses /api/v4/projects/{owner}%2F{repo} and /api/v4/projects/{owner}%2F{repo}/repository/files/{path}/raw?ref={revision}
+func (s *FakeGitServer) parseGitLabPath(r *http.Request) (owner, repo, revision, filePath string, ok bool) {
+	escapedPath := r.URL.EscapedPath()
+	rest := strings.TrimPrefix(escapedPath, fakeAPIPrefixes[FakeGitLabProvider]+"/projects/")
+	if rest == escapedPath {
+		return "", "", "", "", false
+	}
+	project, files, hasFiles := strings.Cut(rest, "/repository/files/")
+	owner, repo, found := strings.Cut(project, "%2F")
+	if !found || strings.Contains(repo, "/") {
+		return "", "", "", "", false
+	}
+	if !hasFiles {
+		return owner, repo, "", "", true
+	}
+	escapedFilePath := strings.TrimSuffix(files, "/raw")
+	filePath, err := url.PathUnescape(escapedFilePath)
+	if err != nil || escapedFilePath == files || filePath == "" {
+		return "", "", "", "", false
+	}
+	return owner, repo, r.URL.Query().Get("ref"), filePath, true
+}
+
+// parseBitbucketPath parses /2.0/repositories/{owner}/{repo} and /2.0/repositories/{owner}/{repo}/src/{revision}/{path}
+func (s *FakeGitServer) parseBitbucketPath(r *http.Request) (owner, repo, revision, filePath string, ok bool) {
+	rest := strings.TrimPrefix(r.URL.Path, fakeAPIPrefixes[FakeBitbucketProvider]+"/repositories/")
+	if rest == r.URL.Path {
+		return "", "", "", "", false
+	}
+	parts := strings.SplitN(rest, "/", 5)
+	switch {
+	case len(parts) == 2:
+		return parts[0], parts[1], "", "", true
+	case len(parts) == 5 && parts[2] == "src" && parts[4] != "":
+		return parts[0], parts[1], parts[3], parts[4], true
+	default:
+		return "", "", "", "", false
+	}
+}
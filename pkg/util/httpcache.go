@@ -21,11 +21,16 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/devfile/library/v2/pkg/git"
 	"k8s.io/klog"
 )
 
-// cleanHttpCache checks cacheDir and deletes all files that were modified more than cacheTime back
+// cleanHttpCache checks cacheDir and deletes all files that were modified more than cacheTime back.
+// Files already deleted, e.g. by another process, are ignored.
 func cleanHttpCache(cacheDir string, cacheTime time.Duration) error {
+	git.HTTPCacheLock.Lock()
+	defer git.HTTPCacheLock.Unlock()
+
 	cacheFiles, err := ioutil.ReadDir(cacheDir)
 	if err != nil {
 		return err
@@ -35,7 +40,7 @@ func cleanHttpCache(cacheDir string, cacheTime time.Duration) error {
 		if f.ModTime().Add(cacheTime).Before(time.Now()) {
 			klog.V(4).Infof("Removing cache file %s, because it is older than %s", f.Name(), cacheTime.String())
 			err := os.Remove(filepath.Join(cacheDir, f.Name()))
-			if err != nil {
+			if err != nil && !os.IsNotExist(err) {
 				return err
 			}
 		}